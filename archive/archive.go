@@ -0,0 +1,463 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	hasher "github.com/prashant-sb/go-utils/file_signatures/hash"
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/pool"
+	"github.com/prashant-sb/go-utils/walker"
+)
+
+// Archive formats
+const (
+	FormatTar   string = "tar"
+	FormatTarGz string = "tar.gz"
+	FormatZip   string = "zip"
+
+	// ManifestName is the entry of embedded checksum manifest,
+	// lines of "name :: checksum" as printed by file_signatures.
+	// Entry of this name is a plain file when manifest is not set.
+	ManifestName string = "MANIFEST"
+)
+
+// Progress of archive creation or extraction
+type Progress struct {
+	// Name is the entry just written.
+	Name string
+
+	// Bytes is the size of entry.
+	Bytes int64
+
+	// Entries and TotalBytes are the totals so far.
+	Entries    int
+	TotalBytes int64
+}
+
+// Options for creating and extracting archives
+type Options struct {
+	// Format is one of tar | tar.gz | zip, tar.gz will be default.
+	Format string
+
+	// Workers is the number of concurrent workers for
+	// reading attributes and checksums of files.
+	Workers int
+
+	// Exclude lists glob patterns of skipped paths.
+	Exclude []string
+
+	// Manifest is the checksum algorithm crc | md5 | sha256 of embedded
+	// manifest, blank disables. Extraction verifies files with manifest.
+	Manifest string
+
+	// OnProgress is called after each entry.
+	OnProgress func(Progress)
+}
+
+// File attributes collected by walker
+type entry struct {
+	name   string // Name in archive, slash separated
+	path   string
+	info   os.FileInfo
+	link   string // Target of symlink
+	xattrs map[string]string
+	sum    string
+}
+
+// Attributes restored on extracted files
+type meta struct {
+	mode    os.FileMode
+	uid     int
+	gid     int
+	modTime time.Time
+	xattrs  map[string]string
+	symlink bool
+}
+
+// Writer of archive entries
+type entryWriter interface {
+	add(e *entry) error
+	addManifest(data []byte) error
+	Close() error
+}
+
+// FormatFor returns format from extension of archive name
+func FormatFor(name string) (string, error) {
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz, nil
+	case strings.HasSuffix(name, ".tar"):
+		return FormatTar, nil
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip, nil
+	}
+
+	return "", errors.New("Archive format of " + name + " not supported.")
+}
+
+// CreateFile creates archive file of root,
+// format is detected from name when not set in options.
+func CreateFile(file, root string, opts Options) error {
+	if opts.Format == "" {
+		var err error
+		if opts.Format, err = FormatFor(file); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if err := Create(f, root, opts); err != nil {
+		f.Close()
+		os.Remove(file)
+		return err
+	}
+
+	return f.Close()
+}
+
+// ExtractFile extracts archive file to dest,
+// format is detected from name when not set in options.
+func ExtractFile(file, dest string, opts Options) error {
+	if opts.Format == "" {
+		var err error
+		if opts.Format, err = FormatFor(file); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return Extract(f, dest, opts)
+}
+
+// Create streams archive of files under root to w.
+// Ownership, modes, modification times and xattrs are kept,
+// zip keeps ownership in unix extra field but not xattrs.
+func Create(w io.Writer, root string, opts Options) error {
+	var sum func(string) (string, error)
+	if opts.Manifest != "" {
+		var err error
+		if sum, err = hasherFor(opts.Manifest); err != nil {
+			return err
+		}
+	}
+
+	aw, err := newWriter(w, opts.Format)
+	if err != nil {
+		return err
+	}
+
+	root = filepath.Clean(root)
+	manifest := &bytes.Buffer{}
+	prog := Progress{}
+
+	// Entries are written in walk order from single goroutine
+	var werr error
+	onResult := func(res pool.Result) {
+		if werr != nil {
+			return
+		}
+		if res.Err != nil {
+			werr = res.Err
+			return
+		}
+
+		e := res.Value.(*entry)
+		if e.name == "" {
+			return
+		}
+		if e.name == ManifestName && opts.Manifest != "" {
+			werr = errors.New("File " + ManifestName + " of " + root + " conflicts with manifest entry.")
+			return
+		}
+		if werr = aw.add(e); werr != nil {
+			return
+		}
+
+		if e.sum != "" {
+			fmt.Fprintf(manifest, "%s :: %s\n", e.name, e.sum)
+		}
+		prog.report(opts.OnProgress, e.name, e.info.Size(), e.info.Mode().IsRegular())
+	}
+
+	err = walker.Walk(context.Background(), root, walker.Options{
+		Workers:  opts.Workers,
+		Ordered:  true,
+		Dirs:     true,
+		Exclude:  opts.Exclude,
+		OnResult: onResult,
+	}, visitor(root, sum))
+	if werr == nil {
+		werr = err
+	}
+
+	if werr == nil && opts.Manifest != "" {
+		werr = aw.addManifest(manifest.Bytes())
+	}
+
+	if err := aw.Close(); werr == nil {
+		werr = err
+	}
+
+	return werr
+}
+
+// Extract extracts archive from r to dest. Ownership is restored when
+// running as root, extracted files are verified with embedded manifest
+// when manifest is set in options.
+func Extract(r io.Reader, dest string, opts Options) error {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	dest = filepath.Clean(dest)
+
+	var manifest []byte
+	var err error
+	switch opts.Format {
+	case FormatTar, FormatTarGz, "":
+		manifest, err = extractTar(r, dest, opts)
+	case FormatZip:
+		manifest, err = extractZip(r, dest, opts)
+	default:
+		err = errors.New("Archive format " + opts.Format + " not supported.")
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.Manifest == "" {
+		return nil
+	}
+	if manifest == nil {
+		return errors.New("Manifest missing in archive.")
+	}
+
+	return verify(dest, manifest, opts.Manifest)
+}
+
+// Returns writer for format
+func newWriter(w io.Writer, format string) (entryWriter, error) {
+	switch format {
+	case FormatTar:
+		return newTarWriter(w, false), nil
+	case FormatTarGz, "":
+		return newTarWriter(w, true), nil
+	case FormatZip:
+		return newZipWriter(w), nil
+	}
+
+	return nil, errors.New("Archive format " + format + " not supported.")
+}
+
+// Visitor collecting attributes and checksum of file
+func visitor(root string, sum func(string) (string, error)) walker.Visit {
+	return func(ctx context.Context, path string, info os.FileInfo) (interface{}, error) {
+		e := &entry{path: path, info: info}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if rel == "." && !info.IsDir() {
+			rel = filepath.Base(path)
+		}
+		if rel != "." {
+			e.name = filepath.ToSlash(rel)
+		}
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			if e.link, err = os.Readlink(path); err != nil {
+				return nil, err
+			}
+			return e, nil
+
+		case info.Mode().IsRegular() && sum != nil:
+			if e.sum, err = sum(path); err != nil {
+				return nil, err
+			}
+		}
+
+		if e.xattrs, err = readXattrs(path); err != nil {
+			return nil, err
+		}
+
+		return e, nil
+	}
+}
+
+// Returns checksum function of manifest algorithm
+func hasherFor(algo string) (func(string) (string, error), error) {
+	switch algo {
+	case "crc":
+		return hasher.FileCrc32, nil
+	case "md5":
+		return hasher.FileMd5Sum, nil
+	case "sha256":
+		return hasher.FileSha256, nil
+	}
+
+	return nil, errors.New("Algorithm " + algo + " not supported.")
+}
+
+// Verifies extracted files with manifest
+func verify(dest string, manifest []byte, algo string) error {
+	sum, err := hasherFor(algo)
+	if err != nil {
+		return err
+	}
+
+	var mismatched []string
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " :: ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+
+		got, err := sum(filepath.Join(dest, filepath.FromSlash(fields[0])))
+		if err != nil || got != fields[1] {
+			mismatched = append(mismatched, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(mismatched) > 0 {
+		return errors.New("Checksum mismatch for " + strconv.Itoa(len(mismatched)) +
+			" files: " + strings.Join(mismatched, ", "))
+	}
+
+	return nil
+}
+
+// Returns path of entry in dest, refuses entries outside of dest
+// and entries below symlinks.
+func safeJoin(dest, name string) (string, error) {
+	target := filepath.Join(dest, filepath.FromSlash(name))
+	if target != dest && !strings.HasPrefix(target, dest+string(filepath.Separator)) {
+		return "", errors.New("Entry " + name + " is outside of destination.")
+	}
+
+	for dir := filepath.Dir(target); dir != dest && len(dir) > len(dest); dir = filepath.Dir(dir) {
+		if info, err := os.Lstat(dir); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return "", errors.New("Entry " + name + " is below symlink.")
+		}
+	}
+
+	return target, nil
+}
+
+// Writes regular file of entry
+func writeFile(target string, body io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	os.Remove(target)
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Creates symlink of entry
+func writeSymlink(target, link string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	os.Remove(target)
+
+	return os.Symlink(link, target)
+}
+
+// Restores ownership, mode, xattrs and modification time of file.
+// Setuid and setgid are restored only when file is owned by original
+// owner, not when extracting as other user or when chown failed.
+func restore(target string, m meta) error {
+	owned := false
+	root := os.Geteuid() == 0
+	if root {
+		if err := os.Lchown(target, m.uid, m.gid); err != nil {
+			log.Warn("Error in restoring owner of ", target, ", setuid and setgid dropped: ", err)
+		} else {
+			owned = true
+		}
+	}
+	if m.symlink {
+		return nil
+	}
+
+	mode := m.mode
+	if !owned {
+		mode &^= os.ModeSetuid | os.ModeSetgid
+	}
+	if err := os.Chmod(target, mode); err != nil {
+		return err
+	}
+	if err := writeXattrs(target, m.xattrs, root); err != nil {
+		return err
+	}
+
+	return os.Chtimes(target, m.modTime, m.modTime)
+}
+
+// Copies r to temporary file for formats needing random access
+func tempCopy(r io.Reader) (*os.File, int64, error) {
+	f, err := ioutil.TempFile("", "archive")
+	if err != nil {
+		return nil, 0, err
+	}
+	os.Remove(f.Name())
+
+	n, err := io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+
+	return f, n, nil
+}
+
+// Updates totals and calls progress callback
+func (p *Progress) report(cb func(Progress), name string, size int64, regular bool) {
+	if !regular {
+		size = 0
+	}
+
+	p.Name = name
+	p.Bytes = size
+	p.Entries++
+	p.TotalBytes += size
+
+	if cb != nil {
+		cb(*p)
+	}
+}
@@ -0,0 +1,204 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/prashant-sb/go-utils/logging"
+)
+
+// Prefix of PAX records for xattrs
+const paxXattr string = "SCHILY.xattr."
+
+type tarWriter struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+// Returns tar writer, gzipped when compress is set
+func newTarWriter(w io.Writer, compress bool) *tarWriter {
+	t := &tarWriter{}
+	if compress {
+		t.gz = gzip.NewWriter(w)
+		w = t.gz
+	}
+	t.tw = tar.NewWriter(w)
+
+	return t
+}
+
+// Writes header and content of entry. Owner names,
+// uid and gid are filled from stat of file.
+func (t *tarWriter) add(e *entry) error {
+	hdr, err := tar.FileInfoHeader(e.info, e.link)
+	if err != nil {
+		return err
+	}
+
+	hdr.Name = e.name
+	if e.info.IsDir() {
+		hdr.Name += "/"
+	}
+
+	if len(e.xattrs) > 0 {
+		hdr.Format = tar.FormatPAX
+		hdr.PAXRecords = make(map[string]string)
+		for k, v := range e.xattrs {
+			hdr.PAXRecords[paxXattr+k] = v
+		}
+	}
+
+	if err := t.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if !e.info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(e.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.CopyN(t.tw, f, hdr.Size)
+	return err
+}
+
+// Writes manifest as last entry
+func (t *tarWriter) addManifest(data []byte) error {
+	hdr := &tar.Header{
+		Name:    ManifestName,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := t.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	_, err := t.tw.Write(data)
+	return err
+}
+
+func (t *tarWriter) Close() error {
+	if err := t.tw.Close(); err != nil {
+		return err
+	}
+	if t.gz != nil {
+		return t.gz.Close()
+	}
+
+	return nil
+}
+
+// Extracts tar stream, returns embedded manifest
+func extractTar(r io.Reader, dest string, opts Options) ([]byte, error) {
+	if opts.Format != FormatTar {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var manifest []byte
+	var dirs []string
+	dirMeta := make(map[string]meta)
+	prog := Progress{}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Name == ManifestName && opts.Manifest != "" {
+			if manifest, err = ioutil.ReadAll(tr); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		target, err := safeJoin(dest, hdr.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		m := meta{
+			mode:    hdr.FileInfo().Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky),
+			uid:     hdr.Uid,
+			gid:     hdr.Gid,
+			modTime: hdr.ModTime,
+			xattrs:  tarXattrs(hdr),
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return nil, err
+			}
+			// Restored after the files are written in directory
+			dirs = append(dirs, target)
+			dirMeta[target] = m
+			prog.report(opts.OnProgress, hdr.Name, 0, false)
+			continue
+
+		case tar.TypeReg:
+			err = writeFile(target, tr)
+
+		case tar.TypeSymlink:
+			m.symlink = true
+			err = writeSymlink(target, hdr.Linkname)
+
+		case tar.TypeLink:
+			var old string
+			if old, err = safeJoin(dest, hdr.Linkname); err == nil {
+				os.Remove(target)
+				err = os.Link(old, target)
+			}
+
+		default:
+			log.Debug("Skipped entry ", hdr.Name, " of type ", string(hdr.Typeflag))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := restore(target, m); err != nil {
+			return nil, err
+		}
+		prog.report(opts.OnProgress, hdr.Name, hdr.Size, hdr.Typeflag == tar.TypeReg)
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := restore(dirs[i], dirMeta[dirs[i]]); err != nil {
+			return nil, err
+		}
+	}
+
+	return manifest, nil
+}
+
+// Returns xattrs from PAX records of header
+func tarXattrs(hdr *tar.Header) map[string]string {
+	xattrs := make(map[string]string)
+	for k, v := range hdr.PAXRecords {
+		if strings.HasPrefix(k, paxXattr) {
+			xattrs[strings.TrimPrefix(k, paxXattr)] = v
+		}
+	}
+
+	return xattrs
+}
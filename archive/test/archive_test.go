@@ -0,0 +1,173 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/archive"
+)
+
+// Creates tree with file, executable, symlink and sub directory
+func testTree(t *testing.T) string {
+	root, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.MkdirAll(filepath.Join(root, "sub"), 0750)
+	ioutil.WriteFile(filepath.Join(root, "a.txt"), []byte("alpha\n"), 0640)
+	ioutil.WriteFile(filepath.Join(root, "sub", "run.sh"), []byte("#!/bin/sh\n"), 0755)
+	os.Symlink("a.txt", filepath.Join(root, "link"))
+
+	return root
+}
+
+func testRoundTrip(t *testing.T, format string) {
+	root := testTree(t)
+	defer os.RemoveAll(root)
+
+	dest, err := ioutil.TempDir("", "extract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dest)
+
+	entries := 0
+	buf := &bytes.Buffer{}
+	err = archive.Create(buf, root, archive.Options{
+		Format:     format,
+		Manifest:   "sha256",
+		OnProgress: func(p archive.Progress) { entries = p.Entries },
+	})
+	if err != nil {
+		t.Fatalf("Create(%s) FAILED: %v", format, err)
+	}
+	if entries != 4 {
+		t.Errorf("Create(%s) FAILED, progress reported %d entries", format, entries)
+	}
+
+	err = archive.Extract(buf, dest, archive.Options{Format: format, Manifest: "sha256"})
+	if err != nil {
+		t.Fatalf("Extract(%s) FAILED: %v", format, err)
+	}
+
+	if b, _ := ioutil.ReadFile(filepath.Join(dest, "a.txt")); string(b) != "alpha\n" {
+		t.Errorf("Extract(%s) FAILED, a.txt has %q", format, string(b))
+	}
+	if info, err := os.Stat(filepath.Join(dest, "sub", "run.sh")); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("Extract(%s) FAILED, mode of run.sh not kept", format)
+	}
+	if info, err := os.Stat(filepath.Join(dest, "sub")); err != nil || info.Mode().Perm() != 0750 {
+		t.Errorf("Extract(%s) FAILED, mode of sub not kept", format)
+	}
+	if link, err := os.Readlink(filepath.Join(dest, "link")); err != nil || link != "a.txt" {
+		t.Errorf("Extract(%s) FAILED, symlink not kept", format)
+	}
+	t.Logf("Create(%s), Extract(%s) PASSED", format, format)
+}
+
+func TestTarGz(t *testing.T) {
+	testRoundTrip(t, archive.FormatTarGz)
+}
+
+func TestZip(t *testing.T) {
+	testRoundTrip(t, archive.FormatZip)
+}
+
+func TestExtractOutside(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	tw.WriteHeader(&tar.Header{Name: "../escape", Mode: 0644, Size: 1, Typeflag: tar.TypeReg})
+	tw.Write([]byte("x"))
+	tw.Close()
+
+	dest, err := ioutil.TempDir("", "extract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dest)
+
+	if err := archive.Extract(buf, dest, archive.Options{Format: archive.FormatTar}); err == nil {
+		t.Errorf("Extract() FAILED, entry outside of destination extracted")
+	}
+	t.Logf("Extract() PASSED")
+}
+
+func TestManifestFile(t *testing.T) {
+	root := testTree(t)
+	defer os.RemoveAll(root)
+	ioutil.WriteFile(filepath.Join(root, archive.ManifestName), []byte("mine\n"), 0644)
+
+	buf := &bytes.Buffer{}
+	if err := archive.Create(buf, root, archive.Options{Format: archive.FormatTar, Manifest: "sha256"}); err == nil {
+		t.Errorf("Create() FAILED, conflicting %s accepted", archive.ManifestName)
+	}
+
+	buf.Reset()
+	if err := archive.Create(buf, root, archive.Options{Format: archive.FormatTar}); err != nil {
+		t.Fatal(err)
+	}
+
+	dest, err := ioutil.TempDir("", "extract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dest)
+
+	if err := archive.Extract(buf, dest, archive.Options{Format: archive.FormatTar}); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(filepath.Join(dest, archive.ManifestName)); string(b) != "mine\n" {
+		t.Errorf("Extract() FAILED, %s has %q", archive.ManifestName, string(b))
+	}
+	t.Logf("ManifestFile() PASSED")
+}
+
+// Extracts tar of setuid file owned by nobody with xattrs of user and
+// trusted namespaces, setuid and trusted xattr are kept as root only.
+func TestRestorePrivileged(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	hdr := &tar.Header{
+		Name:     "su",
+		Mode:     04755,
+		Uid:      65534,
+		Gid:      65534,
+		Size:     3,
+		Typeflag: tar.TypeReg,
+		Format:   tar.FormatPAX,
+		PAXRecords: map[string]string{
+			"SCHILY.xattr.user.origin":  "test",
+			"SCHILY.xattr.trusted.note": "root",
+		},
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		t.Fatal(err)
+	}
+	tw.Write([]byte("su\n"))
+	tw.Close()
+
+	dest, err := ioutil.TempDir("", "extract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dest)
+
+	if err := archive.Extract(buf, dest, archive.Options{Format: archive.FormatTar}); err != nil {
+		t.Fatalf("Extract() FAILED: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dest, "su"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := os.Geteuid() == 0
+	if setuid := info.Mode()&os.ModeSetuid != 0; setuid != root {
+		t.Errorf("Extract() FAILED, setuid %v extracting as root %v", setuid, root)
+	}
+	t.Logf("RestorePrivileged() PASSED")
+}
@@ -0,0 +1,84 @@
+package archive
+
+import (
+	"bytes"
+	"strings"
+	"syscall"
+
+	log "github.com/prashant-sb/go-utils/logging"
+)
+
+// Reads extended attributes of file, nil if not supported by filesystem
+func readXattrs(path string) (map[string]string, error) {
+	size, err := syscall.Listxattr(path, nil)
+	if err == syscall.ENOTSUP || size == 0 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, size)
+	if size, err = syscall.Listxattr(path, buf); err != nil {
+		return nil, err
+	}
+
+	xattrs := make(map[string]string)
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+
+		n, err := syscall.Getxattr(path, string(name), nil)
+		if err != nil {
+			continue
+		}
+		value := make([]byte, n)
+		if n, err = syscall.Getxattr(path, string(name), value); err != nil {
+			continue
+		}
+		xattrs[string(name)] = string(value[:n])
+	}
+
+	return xattrs, nil
+}
+
+// Namespaces of xattrs written by root only
+var privilegedXattrs = []string{"security.", "trusted."}
+
+// Writes extended attributes of file, ignored if not supported by filesystem.
+// Privileged namespaces are skipped unless root, attributes not permitted
+// are skipped with warning.
+func writeXattrs(path string, xattrs map[string]string, root bool) error {
+	for name, value := range xattrs {
+		if !root && privileged(name) {
+			log.Debug("Skipped xattr ", name, " of ", path, ", needs root")
+			continue
+		}
+
+		err := syscall.Setxattr(path, name, []byte(value), 0)
+		if err == syscall.ENOTSUP {
+			return nil
+		}
+		if err == syscall.EPERM || err == syscall.EACCES {
+			log.Warn("Skipped xattr ", name, " of ", path, ": ", err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns true if xattr is of privileged namespace
+func privileged(name string) bool {
+	for _, ns := range privilegedXattrs {
+		if strings.HasPrefix(name, ns) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,239 @@
+package archive
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/prashant-sb/go-utils/logging"
+)
+
+// Unix extra field of zip with uid and gid
+const (
+	zipUnixExtra uint16 = 0x7875
+	zipUnixSize  uint16 = 11
+)
+
+type zipWriter struct {
+	zw *zip.Writer
+}
+
+// Returns zip writer
+func newZipWriter(w io.Writer) *zipWriter {
+	return &zipWriter{zw: zip.NewWriter(w)}
+}
+
+// Writes header and content of entry, symlink target is the content
+func (z *zipWriter) add(e *entry) error {
+	hdr, err := zip.FileInfoHeader(e.info)
+	if err != nil {
+		return err
+	}
+
+	hdr.Name = e.name
+	if e.info.IsDir() {
+		hdr.Name += "/"
+	} else {
+		hdr.Method = zip.Deflate
+	}
+	if st, ok := e.info.Sys().(*syscall.Stat_t); ok {
+		hdr.Extra = unixExtra(st.Uid, st.Gid)
+	}
+
+	w, err := z.zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case e.info.Mode()&os.ModeSymlink != 0:
+		_, err = io.WriteString(w, e.link)
+		return err
+
+	case !e.info.Mode().IsRegular():
+		return nil
+	}
+
+	f, err := os.Open(e.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// Writes manifest as last entry
+func (z *zipWriter) addManifest(data []byte) error {
+	hdr := &zip.FileHeader{
+		Name:     ManifestName,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	}
+	hdr.SetMode(0644)
+
+	w, err := z.zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+func (z *zipWriter) Close() error {
+	return z.zw.Close()
+}
+
+// Extracts zip archive, returns embedded manifest.
+// Archive is copied to temporary file unless r is a file.
+func extractZip(r io.Reader, dest string, opts Options) ([]byte, error) {
+	var ra io.ReaderAt
+	var size int64
+
+	if f, ok := r.(*os.File); ok {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		ra, size = f, info.Size()
+	} else {
+		tmp, n, err := tempCopy(r)
+		if err != nil {
+			return nil, err
+		}
+		defer tmp.Close()
+		ra, size = tmp, n
+	}
+
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest []byte
+	var dirs []string
+	dirMeta := make(map[string]meta)
+	prog := Progress{}
+
+	for _, zf := range zr.File {
+		if zf.Name == ManifestName && opts.Manifest != "" {
+			if manifest, err = readZipFile(zf); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		target, err := safeJoin(dest, zf.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		mode := zf.Mode()
+		m := meta{
+			mode:    mode & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky),
+			uid:     os.Getuid(),
+			gid:     os.Getgid(),
+			modTime: zf.Modified,
+		}
+		if uid, gid, ok := parseUnixExtra(zf.Extra); ok {
+			m.uid, m.gid = int(uid), int(gid)
+		}
+
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return nil, err
+			}
+			// Restored after the files are written in directory
+			dirs = append(dirs, target)
+			dirMeta[target] = m
+			prog.report(opts.OnProgress, zf.Name, 0, false)
+			continue
+
+		case mode&os.ModeSymlink != 0:
+			var link []byte
+			if link, err = readZipFile(zf); err == nil {
+				m.symlink = true
+				err = writeSymlink(target, string(link))
+			}
+
+		case mode.IsRegular():
+			var rc io.ReadCloser
+			if rc, err = zf.Open(); err == nil {
+				err = writeFile(target, rc)
+				rc.Close()
+			}
+
+		default:
+			log.Debug("Skipped entry ", zf.Name, " of mode ", mode.String())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := restore(target, m); err != nil {
+			return nil, err
+		}
+		prog.report(opts.OnProgress, strings.TrimSuffix(zf.Name, "/"), int64(zf.UncompressedSize64), mode.IsRegular())
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := restore(dirs[i], dirMeta[dirs[i]]); err != nil {
+			return nil, err
+		}
+	}
+
+	return manifest, nil
+}
+
+// Reads content of zip entry
+func readZipFile(zf *zip.File) ([]byte, error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return ioutil.ReadAll(rc)
+}
+
+// Returns unix extra field with 4 byte uid and gid
+func unixExtra(uid, gid uint32) []byte {
+	b := make([]byte, 4+zipUnixSize)
+	binary.LittleEndian.PutUint16(b[0:], zipUnixExtra)
+	binary.LittleEndian.PutUint16(b[2:], zipUnixSize)
+	b[4] = 1 // Version
+	b[5] = 4
+	binary.LittleEndian.PutUint32(b[6:], uid)
+	b[10] = 4
+	binary.LittleEndian.PutUint32(b[11:], gid)
+
+	return b
+}
+
+// Parses uid and gid from unix extra field
+func parseUnixExtra(extra []byte) (uint32, uint32, bool) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra[0:])
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			break
+		}
+
+		data := extra[4 : 4+size]
+		if id == zipUnixExtra && size == int(zipUnixSize) && data[1] == 4 && data[6] == 4 {
+			return binary.LittleEndian.Uint32(data[2:]), binary.LittleEndian.Uint32(data[7:]), true
+		}
+		extra = extra[4+size:]
+	}
+
+	return 0, 0, false
+}
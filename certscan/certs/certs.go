@@ -0,0 +1,287 @@
+package certs
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/pool"
+	"github.com/prashant-sb/go-utils/walker"
+)
+
+const (
+	maxCertFile int64 = 1 << 20 // Larger files are not parsed as certificates
+	pemCert           = "CERTIFICATE"
+	day               = 24 * time.Hour
+)
+
+// Status of certificate against expiry thresholds
+const (
+	StatusOK       = "ok"
+	StatusWarning  = "warning"
+	StatusCritical = "critical"
+	StatusExpired  = "expired"
+)
+
+// Extensions tried as DER encoded certificates
+var derExts = map[string]bool{".der": true, ".cer": true, ".crt": true}
+
+// Cert found in file or served by endpoint
+type Cert struct {
+	// Source is the file path or host:port of endpoint.
+	Source string `json:"source"`
+
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	SANs         []string  `json:"sans,omitempty"`
+	Serial       string    `json:"serial"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+	DaysToExpiry int       `json:"daysToExpiry"`
+
+	// Status is one of ok | warning | critical | expired.
+	Status string `json:"status"`
+}
+
+// Error in reading file or probing endpoint
+type Failure struct {
+	Source string `json:"source"`
+	Error  string `json:"error"`
+}
+
+// Report of scanned certificates
+type Report struct {
+	Certs    []Cert    `json:"certs"`
+	Failures []Failure `json:"failures"`
+}
+
+// Options for scanning certificates
+type Options struct {
+	// Warning and Critical days to expiry, zero disables.
+	Warning  int
+	Critical int
+
+	// Exclude lists glob patterns of skipped paths.
+	Exclude []string
+
+	// Timeout of endpoint probes.
+	Timeout time.Duration
+}
+
+// Scanner interface for certificate expiry
+type Scanner interface {
+	Scan(dirs []string, endpoints []string) (*Report, error)
+}
+
+type scanner struct {
+	opts Options   // Scan options
+	now  time.Time // Reference time for expiry
+}
+
+// NewScanner inits the interface for certificate expiry
+func NewScanner(opts Options) Scanner {
+	if opts.Timeout == 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	return &scanner{
+		opts: opts,
+		now:  time.Now(),
+	}
+}
+
+// Scan walks directories for PEM / DER certificates and probes
+// TLS endpoints, certificates are sorted by expiry.
+func (s *scanner) Scan(dirs []string, endpoints []string) (*Report, error) {
+	r := &Report{Certs: []Cert{}, Failures: []Failure{}}
+
+	for _, dir := range dirs {
+		err := walker.Walk(context.Background(), dir, walker.Options{
+			Exclude: s.opts.Exclude,
+			OnResult: func(res pool.Result) {
+				if res.Err != nil {
+					log.Warn("Error in reading file: ", res.Err)
+					r.Failures = append(r.Failures, Failure{Source: failedPath(dir, res.Err), Error: res.Err.Error()})
+					return
+				}
+				r.Certs = append(r.Certs, res.Value.([]Cert)...)
+			},
+		}, s.visit)
+		if err != nil && len(r.Failures) == 0 {
+			return nil, err
+		}
+	}
+
+	for _, ep := range endpoints {
+		c, err := s.probe(ep)
+		if err != nil {
+			log.Warn("Error in probing ", ep, ": ", err)
+			r.Failures = append(r.Failures, Failure{Source: ep, Error: err.Error()})
+			continue
+		}
+		r.Certs = append(r.Certs, *c)
+	}
+
+	sort.Slice(r.Certs, func(i, j int) bool {
+		return r.Certs[i].NotAfter.Before(r.Certs[j].NotAfter)
+	})
+
+	return r, nil
+}
+
+// Returns path of file failed in walk of dir, errors
+// of reading and walking files carry the path.
+func failedPath(dir string, err error) string {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Path
+	}
+
+	return dir
+}
+
+// Worst returns the highest status of certificates
+func Worst(certs []Cert) string {
+	rank := map[string]int{StatusOK: 0, StatusWarning: 1, StatusCritical: 2, StatusExpired: 3}
+	worst := StatusOK
+
+	for _, c := range certs {
+		if rank[c.Status] > rank[worst] {
+			worst = c.Status
+		}
+	}
+
+	return worst
+}
+
+// ReadEndpoints reads host:port endpoints, one per line.
+// Blank lines and lines starting with # are skipped.
+func ReadEndpoints(file string) ([]string, error) {
+	var endpoints []string
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		endpoints = append(endpoints, line)
+	}
+
+	return endpoints, s.Err()
+}
+
+// Visitor parsing certificates in file
+func (s *scanner) visit(ctx context.Context, path string, info os.FileInfo) (interface{}, error) {
+	certs := []Cert{}
+
+	if !info.Mode().IsRegular() || info.Size() > maxCertFile {
+		return certs, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != pemCert {
+			continue
+		}
+		if c, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, s.describe(path, c))
+		}
+	}
+
+	if len(certs) == 0 && derExts[strings.ToLower(filepath.Ext(path))] {
+		if c, err := x509.ParseCertificate(data); err == nil {
+			certs = append(certs, s.describe(path, c))
+		}
+	}
+
+	return certs, nil
+}
+
+// Connects to endpoint and returns the leaf certificate.
+// Chain is not verified, expired or self signed certificates are reported.
+func (s *scanner) probe(endpoint string) (*Cert, error) {
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: s.opts.Timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", endpoint, &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	peers := conn.ConnectionState().PeerCertificates
+	if len(peers) == 0 {
+		return nil, errors.New("No certificate served by " + endpoint)
+	}
+
+	c := s.describe(endpoint, peers[0])
+	return &c, nil
+}
+
+// Describes certificate with expiry status
+func (s *scanner) describe(source string, c *x509.Certificate) Cert {
+	cert := Cert{
+		Source:       source,
+		Subject:      c.Subject.String(),
+		Issuer:       c.Issuer.String(),
+		Serial:       c.SerialNumber.String(),
+		NotBefore:    c.NotBefore,
+		NotAfter:     c.NotAfter,
+		DaysToExpiry: int(c.NotAfter.Sub(s.now) / day),
+	}
+
+	cert.SANs = append(cert.SANs, c.DNSNames...)
+	for _, ip := range c.IPAddresses {
+		cert.SANs = append(cert.SANs, ip.String())
+	}
+	cert.SANs = append(cert.SANs, c.EmailAddresses...)
+	for _, u := range c.URIs {
+		cert.SANs = append(cert.SANs, u.String())
+	}
+
+	switch {
+	case !s.now.Before(c.NotAfter):
+		cert.Status = StatusExpired
+	case s.opts.Critical > 0 && cert.DaysToExpiry < s.opts.Critical:
+		cert.Status = StatusCritical
+	case s.opts.Warning > 0 && cert.DaysToExpiry < s.opts.Warning:
+		cert.Status = StatusWarning
+	default:
+		cert.Status = StatusOK
+	}
+
+	return cert
+}
@@ -0,0 +1,25 @@
+module github.com/prashant-sb/go-utils/certscan
+
+go 1.13
+
+require (
+	github.com/prashant-sb/go-utils/logging v0.0.0
+	github.com/prashant-sb/go-utils/pool v0.0.0
+	github.com/prashant-sb/go-utils/userinfo v0.0.0
+	github.com/prashant-sb/go-utils/walker v0.0.0
+)
+
+replace (
+	github.com/prashant-sb/go-utils/archive => ../archive
+	github.com/prashant-sb/go-utils/config => ../config
+	github.com/prashant-sb/go-utils/file_signatures => ../file_signatures
+	github.com/prashant-sb/go-utils/lifecycle => ../lifecycle
+	github.com/prashant-sb/go-utils/logging => ../logging
+	github.com/prashant-sb/go-utils/output => ../output
+	github.com/prashant-sb/go-utils/pool => ../pool
+	github.com/prashant-sb/go-utils/privs => ../privs
+	github.com/prashant-sb/go-utils/retry => ../retry
+	github.com/prashant-sb/go-utils/signing => ../signing
+	github.com/prashant-sb/go-utils/userinfo => ../userinfo
+	github.com/prashant-sb/go-utils/walker => ../walker
+)
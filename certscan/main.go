@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prashant-sb/go-utils/certscan/certs"
+	log "github.com/prashant-sb/go-utils/logging"
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+)
+
+// Exit codes for monitoring probes
+const (
+	exitOK       = 0
+	exitWarning  = 1
+	exitCritical = 2
+	exitUnknown  = 3
+)
+
+// CLI Flags:
+//
+// -dirs <d1,d2>      : Directories walked for PEM / DER certificates
+// -endpoints <file>  : File with host:port of TLS endpoints, one per line
+// -warn <days>       : Exit with 1 when any certificate expires within days
+// -crit <days>       : Exit with 2 when any certificate expires within days
+var (
+	dirs      = flag.String("dirs", "", "Comma separated directories to scan for certificates")
+	endpoints = flag.String("endpoints", "", "File with TLS endpoints host:port, one per line")
+	exclude   = flag.String("exclude", "", "Comma separated glob patterns of skipped paths")
+	warn      = flag.Int("warn", 30, "Warning threshold of days to expiry, 0 disables")
+	crit      = flag.Int("crit", 7, "Critical threshold of days to expiry, 0 disables")
+	timeout   = flag.Duration("timeout", 10*time.Second, "Timeout of endpoint probe")
+)
+
+// Splits comma separated flag value
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
+
+func main() {
+	flag.Parse()
+
+	if *dirs == "" && *endpoints == "" {
+		flag.Usage()
+		os.Exit(exitUnknown)
+	}
+
+	var eps []string
+	if *endpoints != "" {
+		var err error
+		if eps, err = certs.ReadEndpoints(*endpoints); err != nil {
+			log.Error("Error in reading ", *endpoints, ": ", err)
+			os.Exit(exitUnknown)
+		}
+	}
+
+	s := certs.NewScanner(certs.Options{
+		Warning:  *warn,
+		Critical: *crit,
+		Exclude:  split(*exclude),
+		Timeout:  *timeout,
+	})
+
+	r, err := s.Scan(split(*dirs), eps)
+	if err != nil {
+		log.Error("Error in scanning certificates: ", err)
+		os.Exit(exitUnknown)
+	}
+
+	jsonReport, err := uinfo.Decode(r)
+	if err != nil {
+		log.Error("Error in decode: ", err)
+		os.Exit(exitUnknown)
+	}
+	fmt.Printf("%v\n", jsonReport)
+
+	switch certs.Worst(r.Certs) {
+	case certs.StatusCritical, certs.StatusExpired:
+		os.Exit(exitCritical)
+	case certs.StatusWarning:
+		os.Exit(exitWarning)
+	}
+	os.Exit(exitOK)
+}
@@ -0,0 +1,147 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prashant-sb/go-utils/certscan/certs"
+)
+
+// Returns DER of self signed certificate of name, expiring after ttl
+func generateCert(t *testing.T, name string, ttl time.Duration) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-48 * time.Hour),
+		NotAfter:     time.Now().Add(ttl),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return der
+}
+
+func pemCert(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestScan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certscan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	day := 24 * time.Hour
+	files := map[string][]byte{
+		"expired.pem":  pemCert(generateCert(t, "expired.test", -day)),
+		"critical.pem": pemCert(generateCert(t, "critical.test", 3*day+time.Hour)),
+		"warning.der":  generateCert(t, "warning.test", 20*day+time.Hour),
+		"ok.pem":       pemCert(generateCert(t, "ok.test", 100*day+time.Hour)),
+		"skip/ok.pem":  pemCert(generateCert(t, "skip.test", 100*day)),
+		"notes.txt":    []byte("not a certificate\n"),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := certs.NewScanner(certs.Options{Warning: 30, Critical: 7, Exclude: []string{"skip"}})
+	r, err := s.Scan([]string{dir}, nil)
+	if err != nil {
+		t.Fatalf("Scan() FAILED, %v", err)
+	}
+
+	want := []struct{ source, subject, status string }{
+		{"expired.pem", "CN=expired.test", certs.StatusExpired},
+		{"critical.pem", "CN=critical.test", certs.StatusCritical},
+		{"warning.der", "CN=warning.test", certs.StatusWarning},
+		{"ok.pem", "CN=ok.test", certs.StatusOK},
+	}
+	if len(r.Certs) != len(want) || len(r.Failures) != 0 {
+		t.Fatalf("Scan() FAILED, scanned %+v", r)
+	}
+	for i, w := range want {
+		c := r.Certs[i]
+		if c.Source != filepath.Join(dir, w.source) || c.Subject != w.subject || c.Status != w.status {
+			t.Errorf("Scan() FAILED, expected %+v got %+v", w, c)
+		}
+	}
+	if r.Certs[0].DaysToExpiry != -1 || r.Certs[3].DaysToExpiry != 100 || r.Certs[3].SANs[0] != "ok.test" {
+		t.Errorf("Scan() FAILED, days to expiry or SANs %+v", r.Certs)
+	}
+	if certs.Worst(r.Certs) != certs.StatusExpired || certs.Worst(r.Certs[1:]) != certs.StatusCritical {
+		t.Errorf("Worst() FAILED, %s", certs.Worst(r.Certs))
+	}
+	t.Logf("Scan() PASSED")
+}
+
+func TestScanFailures(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("Files are readable by root")
+	}
+
+	dir, err := ioutil.TempDir("", "certscan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	unreadable := filepath.Join(dir, "unreadable.pem")
+	ioutil.WriteFile(unreadable, pemCert(generateCert(t, "unreadable.test", time.Hour)), 0000)
+
+	r, err := certs.NewScanner(certs.Options{}).Scan([]string{dir}, nil)
+	if err != nil || len(r.Certs) != 0 || len(r.Failures) != 1 || r.Failures[0].Source != unreadable {
+		t.Errorf("Scan() FAILED, failures of unreadable file %+v, %v", r, err)
+	}
+	t.Logf("ScanFailures() PASSED")
+}
+
+func TestScanEndpoints(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	endpoint := strings.TrimPrefix(server.URL, "https://")
+
+	file, err := ioutil.TempFile("", "endpoints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("# test server\n\n" + endpoint + "\n127.0.0.1:1\n")
+	file.Close()
+
+	eps, err := certs.ReadEndpoints(file.Name())
+	if err != nil || len(eps) != 2 || eps[0] != endpoint {
+		t.Fatalf("ReadEndpoints() FAILED, %v, %v", eps, err)
+	}
+
+	r, err := certs.NewScanner(certs.Options{Timeout: time.Second}).Scan(nil, eps)
+	if err != nil || len(r.Certs) != 1 || r.Certs[0].Source != endpoint ||
+		len(r.Failures) != 1 || r.Failures[0].Source != "127.0.0.1:1" {
+		t.Errorf("Scan() FAILED, endpoints scanned %+v, %v", r, err)
+	}
+	t.Logf("ScanEndpoints() PASSED")
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Options for loading the configuration
+type Options struct {
+	// File is the yaml configuration file, toml for file
+	// of .toml extension / optional
+	File string
+
+	// EnvPrefix is prepended to environment variable names,
+	// flag "dest" is read from PREFIX_DEST.
+	EnvPrefix string
+
+	// CommandLineOnly lists flags set from command line only, as
+	// actions and confirmations. Values of file and environment
+	// are ignored with warning / optional
+	CommandLineOnly []string
+}
+
+// Load sets flags from configuration file and environment.
+// Precedence, from lowest to highest:
+//
+//	flag defaults < configuration file < environment < command line
+//
+// Must be called after flag set is parsed.
+func Load(fs *flag.FlagSet, opts Options) error {
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	cmdline := make(map[string]bool)
+	for _, name := range opts.CommandLineOnly {
+		cmdline[name] = true
+	}
+
+	values, err := readFile(opts.File)
+	if err != nil {
+		return err
+	}
+
+	var ferr error
+	fs.VisitAll(func(f *flag.Flag) {
+		if ferr != nil || explicit[f.Name] {
+			return
+		}
+
+		val, ok := values[f.Name]
+		if env, found := os.LookupEnv(EnvName(opts.EnvPrefix, f.Name)); found {
+			val, ok = env, true
+		}
+		if !ok {
+			return
+		}
+		if cmdline[f.Name] {
+			log.Warn("Flag ", f.Name, " is set from command line only, value of configuration ignored")
+			return
+		}
+
+		if err := fs.Set(f.Name, val); err != nil {
+			ferr = fmt.Errorf("invalid value %q for %s: %v", val, f.Name, err)
+		}
+	})
+
+	return ferr
+}
+
+// EnvName returns environment variable name for flag
+func EnvName(prefix, name string) string {
+	env := strings.ToUpper(strings.Replace(name, "-", "_", -1))
+	if prefix == "" {
+		return env
+	}
+
+	return strings.ToUpper(prefix) + "_" + env
+}
+
+// Reads yaml or toml file as map of flag name and value
+func readFile(file string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	if file == "" {
+		return values, nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		log.Error("Error in reading config ", file)
+		return nil, err
+	}
+
+	if strings.HasSuffix(file, ".toml") {
+		return parseTOML(data)
+	}
+
+	raw := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		log.Error("Error in unmarshal: ", err.Error())
+		return nil, err
+	}
+
+	for k, v := range raw {
+		if values[k], err = yamlValue(k, v); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+// Returns yaml scalar or sequence of scalars as flag value,
+// sequences are joined as comma separated value like toml arrays.
+func yamlValue(key string, v interface{}) (string, error) {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		return "", errors.New("Yaml key " + key + ": maps are not supported, flags are top-level keys.")
+	case []interface{}:
+		items := make([]string, len(val))
+		for i, item := range val {
+			switch item.(type) {
+			case map[interface{}]interface{}, []interface{}:
+				return "", errors.New("Yaml key " + key + ": sequence items must be scalars.")
+			}
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ","), nil
+	}
+
+	return fmt.Sprint(v), nil
+}
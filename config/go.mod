@@ -0,0 +1,10 @@
+module github.com/prashant-sb/go-utils/config
+
+go 1.13
+
+require (
+	github.com/prashant-sb/go-utils/logging v0.0.0
+	gopkg.in/yaml.v2 v2.2.2
+)
+
+replace github.com/prashant-sb/go-utils/logging => ../logging
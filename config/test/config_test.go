@@ -0,0 +1,145 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/config"
+)
+
+const testConfig = "dest: /var\nsign: sha256\nworkers: 2\n"
+
+func TestLoad(t *testing.T) {
+	f, err := ioutil.TempFile("", "config")
+	if err != nil {
+		t.Errorf("Load() FAILED with %v", err.Error())
+		return
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(testConfig)
+	f.Close()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	dest := fs.String("dest", "/tmp", "")
+	sign := fs.String("sign", "md5", "")
+	workers := fs.Int("workers", 1, "")
+	ordered := fs.Bool("ordered", false, "")
+
+	os.Setenv("TEST_SIGN", "crc")
+	defer os.Unsetenv("TEST_SIGN")
+
+	if err := fs.Parse([]string{"-dest", "/home"}); err != nil {
+		t.Errorf("Load() FAILED with %v", err.Error())
+		return
+	}
+
+	err = config.Load(fs, config.Options{File: f.Name(), EnvPrefix: "test"})
+	if err != nil {
+		t.Errorf("Load() FAILED with %v", err.Error())
+		return
+	}
+
+	switch {
+	case *dest != "/home":
+		t.Errorf("Load() FAILED, command line: expected /home got %v", *dest)
+	case *sign != "crc":
+		t.Errorf("Load() FAILED, environment: expected crc got %v", *sign)
+	case *workers != 2:
+		t.Errorf("Load() FAILED, file: expected 2 got %v", *workers)
+	case *ordered:
+		t.Errorf("Load() FAILED, default: expected false got %v", *ordered)
+	default:
+		t.Logf("Load() PASSED")
+	}
+}
+
+const testTOML = `# flags of tool
+dest = "/var/log" # inline comment
+"sign" = 'sha256'
+workers = 1_000
+exclude = ["*.tmp", "cache"]
+ordered = true
+`
+
+func TestLoadTOML(t *testing.T) {
+	f, err := ioutil.TempFile("", "config*.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(testTOML)
+	f.Close()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	dest := fs.String("dest", "/tmp", "")
+	sign := fs.String("sign", "md5", "")
+	workers := fs.Int("workers", 1, "")
+	exclude := fs.String("exclude", "", "")
+	ordered := fs.Bool("ordered", false, "")
+	fs.Parse(nil)
+
+	if err := config.Load(fs, config.Options{File: f.Name()}); err != nil {
+		t.Fatalf("Load() FAILED with %v", err)
+	}
+	if *dest != "/var/log" || *sign != "sha256" || *workers != 1000 || *exclude != "*.tmp,cache" || !*ordered {
+		t.Errorf("Load() FAILED, toml: %v %v %v %v %v", *dest, *sign, *workers, *exclude, *ordered)
+	}
+
+	for _, bad := range []string{"[tool]\ndest = 1\n", "dest = /var\n", "dest = \"open\n", "dest = 1\ndest = 2\n"} {
+		ioutil.WriteFile(f.Name(), []byte(bad), 0600)
+		if err := config.Load(fs, config.Options{File: f.Name()}); err == nil {
+			t.Errorf("Load() FAILED, toml %q accepted", bad)
+		}
+	}
+	t.Logf("LoadTOML() PASSED")
+}
+
+func TestLoadYAMLList(t *testing.T) {
+	f, err := ioutil.TempFile("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("exclude: [\"*.log\", tmp]\nsign:\n  - sha256\n")
+	f.Close()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	exclude := fs.String("exclude", "", "")
+	sign := fs.String("sign", "md5", "")
+	fs.Parse(nil)
+
+	if err := config.Load(fs, config.Options{File: f.Name()}); err != nil {
+		t.Fatalf("Load() FAILED with %v", err)
+	}
+	if *exclude != "*.log,tmp" || *sign != "sha256" {
+		t.Errorf("Load() FAILED, yaml list: %v %v", *exclude, *sign)
+	}
+
+	for _, bad := range []string{"dest:\n  path: /var\n", "exclude: [[a, b]]\n", "exclude: [{a: b}]\n"} {
+		ioutil.WriteFile(f.Name(), []byte(bad), 0600)
+		if err := config.Load(fs, config.Options{File: f.Name()}); err == nil {
+			t.Errorf("Load() FAILED, yaml %q accepted", bad)
+		}
+	}
+	t.Logf("LoadYAMLList() PASSED")
+}
+
+func TestCommandLineOnly(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	force := fs.Bool("force", false, "")
+	user := fs.String("user", "", "")
+	fs.Parse(nil)
+
+	os.Setenv("TEST_FORCE", "true")
+	os.Setenv("TEST_USER", "test")
+	defer os.Unsetenv("TEST_FORCE")
+	defer os.Unsetenv("TEST_USER")
+
+	err := config.Load(fs, config.Options{EnvPrefix: "test", CommandLineOnly: []string{"force"}})
+	if err != nil || *force || *user != "test" {
+		t.Errorf("Load() FAILED, command line only: force %v, user %v, %v", *force, *user, err)
+	}
+	t.Logf("CommandLineOnly() PASSED")
+}
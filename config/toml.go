@@ -0,0 +1,189 @@
+package config
+
+// Toml configuration of flags, top-level keys of scalar values or
+// arrays of scalars. Tables and multi-line strings are not supported.
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Parses toml document as map of flag name and value,
+// arrays are joined as comma separated value.
+func parseTOML(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lineErr := func(msg string) error {
+			return errors.New("Toml line " + strconv.Itoa(n) + ": " + msg)
+		}
+		if strings.HasPrefix(line, "[") {
+			return nil, lineErr("tables are not supported, flags are top-level keys.")
+		}
+
+		key, rest, err := tomlKey(line)
+		if err != nil {
+			return nil, lineErr(err.Error())
+		}
+		value, rest, err := tomlValue(rest)
+		if err != nil {
+			return nil, lineErr(err.Error())
+		}
+		if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+			return nil, lineErr("unexpected " + rest + " after value.")
+		}
+		if _, ok := values[key]; ok {
+			return nil, lineErr("key " + key + " is duplicated.")
+		}
+
+		values[key] = value
+	}
+
+	return values, scanner.Err()
+}
+
+// Returns key of line and text after =
+func tomlKey(line string) (string, string, error) {
+	var key string
+	if strings.HasPrefix(line, `"`) || strings.HasPrefix(line, "'") {
+		k, rest, err := tomlString(line)
+		if err != nil {
+			return "", "", err
+		}
+		key, line = k, rest
+	} else {
+		end := strings.IndexFunc(line, func(r rune) bool {
+			return !(r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+		})
+		if end <= 0 {
+			return "", "", errors.New("key is missing.")
+		}
+		key, line = line[:end], line[end:]
+	}
+
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "=") {
+		return "", "", errors.New("= expected after key " + key + ".")
+	}
+
+	return key, strings.TrimSpace(line[1:]), nil
+}
+
+// Returns scalar or array value at start of s, and text after it
+func tomlValue(s string) (string, string, error) {
+	if !strings.HasPrefix(s, "[") {
+		return tomlScalar(s)
+	}
+
+	var items []string
+	s = strings.TrimSpace(s[1:])
+	for !strings.HasPrefix(s, "]") {
+		item, rest, err := tomlScalar(s)
+		if err != nil {
+			return "", "", err
+		}
+		items = append(items, item)
+
+		s = strings.TrimSpace(rest)
+		if strings.HasPrefix(s, ",") {
+			s = strings.TrimSpace(s[1:])
+		} else if !strings.HasPrefix(s, "]") {
+			return "", "", errors.New("array is not closed on its line.")
+		}
+	}
+
+	return strings.Join(items, ","), s[1:], nil
+}
+
+// Returns string, number, boolean or date at start of s
+func tomlScalar(s string) (string, string, error) {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "'") {
+		return tomlString(s)
+	}
+
+	end := strings.IndexAny(s, " \t,]#")
+	if end < 0 {
+		end = len(s)
+	}
+	value := s[:end]
+
+	switch {
+	case value == "":
+		return "", "", errors.New("value is missing.")
+	case value == "true" || value == "false":
+	case value[0] == '+' || value[0] == '-' || value[0] >= '0' && value[0] <= '9' || value == "inf" || value == "nan":
+		// Numbers lose digit separators, dates are kept as written
+		if !strings.ContainsAny(value, ":T") && strings.Count(value, "-") < 2 {
+			value = strings.Replace(value, "_", "", -1)
+		}
+	default:
+		return "", "", errors.New("value " + value + " is not string, number or boolean.")
+	}
+
+	return value, s[end:], nil
+}
+
+// Returns basic or literal string at start of s
+func tomlString(s string) (string, string, error) {
+	quote := s[0]
+	if strings.HasPrefix(s, strings.Repeat(string(quote), 3)) {
+		return "", "", errors.New("multi-line strings are not supported.")
+	}
+
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == quote:
+			return sb.String(), s[i+1:], nil
+		case c == '\\' && quote == '"':
+			if i+1 >= len(s) {
+				return "", "", errors.New("string is not closed.")
+			}
+			i++
+			switch s[i] {
+			case '"', '\\':
+				sb.WriteByte(s[i])
+			case 'b':
+				sb.WriteByte('\b')
+			case 't':
+				sb.WriteByte('\t')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'u', 'U':
+				size := 4
+				if s[i] == 'U' {
+					size = 8
+				}
+				if i+size >= len(s) {
+					return "", "", errors.New("escape of unicode is truncated.")
+				}
+				r, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+				if err != nil || !utf8.ValidRune(rune(r)) {
+					return "", "", errors.New("escape of unicode is invalid.")
+				}
+				sb.WriteRune(rune(r))
+				i += size
+			default:
+				return "", "", errors.New("escape \\" + string(s[i]) + " is invalid.")
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return "", "", errors.New("string is not closed.")
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	du "github.com/prashant-sb/go-utils/diskusage/usage"
+	log "github.com/prashant-sb/go-utils/logging"
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+)
+
+// CLI Flags:
+//
+// -dest <dir>         : Root directory for scanning / tmp will be default
+// -depth <n>          : Reports directories up to depth n below root
+// -top <n>            : Reports n largest files
+// -exclude <p1,p2>    : Skips paths matching glob patterns
+// -format json | csv  : Output format / json will be default
+// -apparent           : Reports file sizes instead of allocated blocks
+var (
+	dest     = flag.String("dest", "/tmp", "Root directory for scanning disk usage")
+	depth    = flag.Int("depth", 1, "Reports directories up to depth below root")
+	top      = flag.Int("top", 10, "Number of largest files to report")
+	exclude  = flag.String("exclude", "", "Comma separated glob patterns of skipped paths")
+	format   = flag.String("format", "json", "Output format, json | csv")
+	apparent = flag.Bool("apparent", false, "Reports file sizes instead of allocated blocks")
+	workers  = flag.Int("workers", runtime.NumCPU(), "Number of concurrent workers")
+)
+
+// Prints report in given format
+func printReport(r *du.Report, ft string) error {
+	switch ft {
+	case "json":
+		jsonReport, err := uinfo.Decode(r)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%v\n", jsonReport)
+
+	case "csv":
+		return r.WriteCSV(os.Stdout)
+
+	default:
+		return errors.New("Format " + ft + " not supported.")
+	}
+
+	return nil
+}
+
+func main() {
+	flag.Parse()
+
+	var patterns []string
+	if *exclude != "" {
+		patterns = strings.Split(*exclude, ",")
+	}
+
+	s := du.NewScanner(du.Options{
+		Workers:  *workers,
+		Exclude:  patterns,
+		Depth:    *depth,
+		Top:      *top,
+		Apparent: *apparent,
+	})
+
+	r, err := s.Scan(*dest)
+	if err != nil {
+		log.Error("Error in scanning ", *dest, ": ", err)
+		os.Exit(1)
+	}
+
+	if err := printReport(r, *format); err != nil {
+		log.Error(err.Error())
+		os.Exit(1)
+	}
+}
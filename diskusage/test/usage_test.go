@@ -0,0 +1,131 @@
+package usage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/diskusage/usage"
+)
+
+// Sizes of test files, relative to root
+var testFiles = map[string]int{
+	"a":          100,
+	"sub/b":      300,
+	"sub/deep/c": 50,
+	"skip/d":     1000,
+}
+
+// Creates test tree with hard link of a, returns its root
+func writeTree(t *testing.T) string {
+	root, err := ioutil.TempDir("", "diskusage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, size := range testFiles {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, bytes.Repeat([]byte("x"), size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Link(filepath.Join(root, "a"), filepath.Join(root, "sub/link")); err != nil {
+		t.Fatal(err)
+	}
+
+	return root
+}
+
+func TestScan(t *testing.T) {
+	root := writeTree(t)
+	defer os.RemoveAll(root)
+
+	s := usage.NewScanner(usage.Options{Depth: 1, Top: 2, Apparent: true, Exclude: []string{"skip"}})
+	r, err := s.Scan(root + "/")
+	if err != nil {
+		t.Fatalf("Scan() FAILED, %v", err)
+	}
+
+	// Hard link is counted once
+	if r.Root != root || r.TotalBytes != 450 || r.Files != 3 || r.Errors != 0 {
+		t.Errorf("Scan() FAILED, totals %+v", r)
+	}
+
+	dirs := len(r.Directories) == 2 && r.Directories[0].Path == root && r.Directories[0].Bytes == 450
+	if !dirs || r.Directories[1].Path != filepath.Join(root, "sub") {
+		t.Errorf("Scan() FAILED, directories %+v", r.Directories)
+	}
+
+	if len(r.LargestFiles) != 2 || r.LargestFiles[0].Path != filepath.Join(root, "sub/b") ||
+		r.LargestFiles[0].Bytes != 300 || r.LargestFiles[1].Bytes != 100 {
+		t.Errorf("Scan() FAILED, largest files %+v", r.LargestFiles)
+	}
+
+	if len(r.Owners) != 1 || r.Owners[0].Uid != strconv.Itoa(os.Getuid()) ||
+		r.Owners[0].Username == "" || r.Owners[0].Files != 3 || r.Owners[0].Bytes != 450 {
+		t.Errorf("Scan() FAILED, owners %+v", r.Owners)
+	}
+	t.Logf("Scan() PASSED")
+}
+
+func TestScanDepth(t *testing.T) {
+	root := writeTree(t)
+	defer os.RemoveAll(root)
+
+	r, err := usage.NewScanner(usage.Options{Depth: 2, Apparent: true}).Scan(root)
+	if err != nil || len(r.LargestFiles) != 0 {
+		t.Fatalf("Scan() FAILED, %+v, %v", r, err)
+	}
+
+	want := map[string]uint64{root: 1450, root + "/skip": 1000, root + "/sub": 350, root + "/sub/deep": 50}
+	got := make(map[string]uint64)
+	for _, d := range r.Directories {
+		got[d.Path] = d.Bytes
+	}
+	if len(got) != len(want) || r.Directories[1].Path != root+"/skip" {
+		t.Errorf("Scan() FAILED, directories %+v", r.Directories)
+	}
+	for path, bytes := range want {
+		if got[path] != bytes {
+			t.Errorf("Scan() FAILED, %s expected: %d got: %d", path, bytes, got[path])
+		}
+	}
+
+	// Allocated blocks are counted without Apparent
+	if r, err := usage.NewScanner(usage.Options{}).Scan(root); err != nil || r.TotalBytes%512 != 0 || r.TotalBytes == 0 {
+		t.Errorf("Scan() FAILED, allocated %+v, %v", r, err)
+	}
+	t.Logf("ScanDepth() PASSED")
+}
+
+func TestScanErrors(t *testing.T) {
+	r, err := usage.NewScanner(usage.Options{}).Scan("/no/such/dir")
+	if err != nil || r.Errors != 1 || r.Files != 0 {
+		t.Errorf("Scan() FAILED, missing root %+v, %v", r, err)
+	}
+	t.Logf("ScanErrors() PASSED")
+}
+
+func TestWriteCSV(t *testing.T) {
+	r := &usage.Report{
+		Directories:  []usage.Entry{{Path: "/srv", Bytes: 2048}},
+		LargestFiles: []usage.Entry{{Path: "/srv/a, b", Bytes: 1024}},
+		Owners:       []usage.OwnerUsage{{Uid: "0", Username: "root", Bytes: 2048, Files: 2}},
+	}
+
+	var b bytes.Buffer
+	if err := r.WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "type,path,uid,userName,bytes,files\n" +
+		"dir,/srv,,,2048,\n" +
+		"file,\"/srv/a, b\",,,1024,\n" +
+		"owner,,0,root,2048,2\n"
+	if b.String() != want {
+		t.Errorf("WriteCSV() FAILED, expected:\n%s\ngot:\n%s", want, b.String())
+	}
+	t.Logf("WriteCSV() PASSED")
+}
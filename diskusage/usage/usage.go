@@ -0,0 +1,266 @@
+package usage
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/pool"
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+	"github.com/prashant-sb/go-utils/walker"
+)
+
+// Size of block reported in stat
+const blockSize = 512
+
+// Options for scanning disk usage
+type Options struct {
+	// Workers is the number of concurrent workers.
+	Workers int
+
+	// Exclude lists glob patterns of skipped paths.
+	Exclude []string
+
+	// Depth limits the reported directories below root.
+	Depth int
+
+	// Top is the number of reported largest files.
+	Top int
+
+	// Apparent reports file sizes instead of allocated blocks.
+	Apparent bool
+}
+
+// Entry is the usage of directory or file
+type Entry struct {
+	Path  string `json:"path"`
+	Bytes uint64 `json:"bytes"`
+}
+
+// OwnerUsage is the usage of all files owned by user
+type OwnerUsage struct {
+	// Uid is the numeric owner of files.
+	Uid string `json:"uid"`
+
+	// Username is blank if uid is not known to system.
+	Username string `json:"userName,omitempty"`
+
+	Bytes uint64 `json:"bytes"`
+	Files int    `json:"files"`
+}
+
+// Report of disk usage under root
+type Report struct {
+	Root         string       `json:"root"`
+	TotalBytes   uint64       `json:"totalBytes"`
+	Files        int          `json:"files"`
+	Errors       int          `json:"errors"`
+	Directories  []Entry      `json:"directories"`
+	LargestFiles []Entry      `json:"largestFiles"`
+	Owners       []OwnerUsage `json:"owners"`
+}
+
+// Scanner interface for disk usage
+type Scanner interface {
+	Scan(string) (*Report, error)
+}
+
+// File attributes collected by walker
+type fileStat struct {
+	path  string
+	bytes uint64
+	uid   uint32
+	dev   uint64
+	ino   uint64
+	links uint64
+}
+
+type inode struct {
+	dev uint64
+	ino uint64
+}
+
+type scanner struct {
+	opts  Options       // Scan options
+	users uinfo.UserOps // Resolves owner uid to username
+}
+
+// NewScanner inits the interface for disk usage
+func NewScanner(opts Options) Scanner {
+	return &scanner{
+		opts:  opts,
+		users: uinfo.NewUserOps(),
+	}
+}
+
+// Scan walks root and aggregates the usage.
+// Hard linked files are counted once.
+func (s *scanner) Scan(root string) (*Report, error) {
+
+	root = filepath.Clean(root)
+	dirs := make(map[string]uint64)
+	owners := make(map[uint32]*OwnerUsage)
+	seen := make(map[inode]bool)
+	r := &Report{
+		Root:         root,
+		LargestFiles: []Entry{},
+	}
+
+	onResult := func(res pool.Result) {
+		if res.Err != nil {
+			log.Warn("Error in reading file: ", res.Err)
+			r.Errors++
+			return
+		}
+
+		fs := res.Value.(fileStat)
+		if fs.links > 1 {
+			if seen[inode{fs.dev, fs.ino}] {
+				return
+			}
+			seen[inode{fs.dev, fs.ino}] = true
+		}
+
+		r.Files++
+		r.TotalBytes += fs.bytes
+
+		for dir := filepath.Dir(fs.path); ; dir = filepath.Dir(dir) {
+			if s.depth(root, dir) <= s.opts.Depth {
+				dirs[dir] += fs.bytes
+			}
+			if dir == root || dir == filepath.Dir(dir) {
+				break
+			}
+		}
+
+		o, ok := owners[fs.uid]
+		if !ok {
+			o = &OwnerUsage{Uid: strconv.FormatUint(uint64(fs.uid), 10)}
+			if u, err := s.users.GetByUid(o.Uid); err == nil {
+				o.Username = u.Username
+			}
+			owners[fs.uid] = o
+		}
+		o.Bytes += fs.bytes
+		o.Files++
+
+		r.LargestFiles = s.insertTop(r.LargestFiles, Entry{Path: fs.path, Bytes: fs.bytes})
+	}
+
+	err := walker.Walk(context.Background(), root, walker.Options{
+		Workers:  s.opts.Workers,
+		Exclude:  s.opts.Exclude,
+		OnResult: onResult,
+	}, s.stat)
+	if err != nil && r.Errors == 0 {
+		return nil, err
+	}
+
+	r.Directories = sortedEntries(dirs)
+	r.Owners = []OwnerUsage{}
+	for _, o := range owners {
+		r.Owners = append(r.Owners, *o)
+	}
+	sort.Slice(r.Owners, func(i, j int) bool {
+		return r.Owners[i].Bytes > r.Owners[j].Bytes
+	})
+
+	return r, nil
+}
+
+// Visitor collecting size and owner of file
+func (s *scanner) stat(ctx context.Context, path string, info os.FileInfo) (interface{}, error) {
+	fs := fileStat{path: path, bytes: uint64(info.Size())}
+
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		fs.uid = st.Uid
+		fs.dev = uint64(st.Dev)
+		fs.ino = uint64(st.Ino)
+		fs.links = uint64(st.Nlink)
+		if !s.opts.Apparent {
+			fs.bytes = uint64(st.Blocks) * blockSize
+		}
+	}
+
+	return fs, nil
+}
+
+// Returns depth of dir below root
+func (s *scanner) depth(root, dir string) int {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return 0
+	}
+
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// Inserts entry in descending list, keeping top entries
+func (s *scanner) insertTop(top []Entry, e Entry) []Entry {
+	if s.opts.Top <= 0 {
+		return top
+	}
+
+	i := sort.Search(len(top), func(i int) bool {
+		return top[i].Bytes < e.Bytes
+	})
+	if i >= s.opts.Top {
+		return top
+	}
+
+	top = append(top, Entry{})
+	copy(top[i+1:], top[i:])
+	top[i] = e
+	if len(top) > s.opts.Top {
+		top = top[:s.opts.Top]
+	}
+
+	return top
+}
+
+// Returns entries sorted by size, largest first
+func sortedEntries(m map[string]uint64) []Entry {
+	entries := []Entry{}
+	for path, bytes := range m {
+		entries = append(entries, Entry{Path: path, Bytes: bytes})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Bytes == entries[j].Bytes {
+			return entries[i].Path < entries[j].Path
+		}
+		return entries[i].Bytes > entries[j].Bytes
+	})
+
+	return entries
+}
+
+// WriteCSV writes report as rows of type,path,uid,userName,bytes,files
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	rows := [][]string{{"type", "path", "uid", "userName", "bytes", "files"}}
+	for _, d := range r.Directories {
+		rows = append(rows, []string{"dir", d.Path, "", "", strconv.FormatUint(d.Bytes, 10), ""})
+	}
+	for _, f := range r.LargestFiles {
+		rows = append(rows, []string{"file", f.Path, "", "", strconv.FormatUint(f.Bytes, 10), ""})
+	}
+	for _, o := range r.Owners {
+		rows = append(rows, []string{"owner", "", o.Uid, o.Username,
+			strconv.FormatUint(o.Bytes, 10), strconv.Itoa(o.Files)})
+	}
+
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
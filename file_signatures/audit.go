@@ -0,0 +1,243 @@
+package main
+
+// Attribution of changed files with events of audit log
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/prashant-sb/go-utils/logging"
+)
+
+// Auid of processes not started by login
+const unsetAuid string = "4294967295"
+
+// Fields of strings, hex encoded by audit when having special characters
+var encodedFields = map[string]bool{"name": true, "cwd": true, "exe": true, "comm": true}
+
+// Event of audit log changing a file, of SYSCALL, CWD and PATH records
+type auditEvent struct {
+	serial string
+	time   time.Time
+	fields map[string]string // Fields of SYSCALL record
+	cwd    string
+	paths  []string // Names of PATH records, not of parent directories
+}
+
+// Audit log attributing changes, events before since are of
+// changes older than the baseline, as time of manifest.
+type auditSource struct {
+	file  string
+	since time.Time
+}
+
+// Prints for each failed file the last audit event naming it, with process
+// and user responsible. Events are recorded for paths watched by audit
+// rules, as auditctl -w /etc -p wa.
+func attributeChanges(src *auditSource, failed []string) {
+	if len(failed) == 0 {
+		return
+	}
+
+	// Audit records absolute names
+	abs := make([]string, len(failed))
+	for i, path := range failed {
+		if a, err := filepath.Abs(path); err == nil {
+			abs[i] = a
+		}
+	}
+
+	events, err := readAuditEvents(src.file, abs, src.since)
+	if err != nil {
+		log.Error("Error in reading audit log: ", err)
+		return
+	}
+
+	for i, path := range failed {
+		e, ok := events[abs[i]]
+		if !ok {
+			fmt.Printf("%s: no audit event of change\n", path)
+			continue
+		}
+		fmt.Printf("%s: changed by %s (pid %s, uid %s, auid %s) at %s\n", path,
+			e.fields["exe"], e.fields["pid"], userName(e.fields["uid"]),
+			userName(e.fields["auid"]), e.time.Format(time.RFC3339))
+	}
+}
+
+// Returns last successful event of each path of paths, not before since.
+// Records of concurrent events are interleaved in log, they are collected
+// by serial and resolved at end of event or of log.
+func readAuditEvents(logFile string, paths []string, since time.Time) (map[string]*auditEvent, error) {
+	f, err := os.Open(logFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	wanted := make(map[string]bool)
+	for _, p := range paths {
+		wanted[p] = true
+	}
+
+	found := make(map[string]*auditEvent)
+	resolve := func(e *auditEvent) {
+		if e.fields["success"] != "yes" || e.time.Before(since) {
+			return
+		}
+		for _, name := range e.paths {
+			if !filepath.IsAbs(name) {
+				name = filepath.Join(e.cwd, name)
+			}
+			name = filepath.Clean(name)
+			if last, ok := found[name]; wanted[name] && (!ok || last.before(e)) {
+				found[name] = e
+			}
+		}
+	}
+
+	pending := make(map[string]*auditEvent)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		kind, serial, stamp, fields, ok := parseAuditRecord(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		e, ok := pending[serial]
+		if !ok {
+			e = &auditEvent{serial: serial, time: stamp, fields: map[string]string{}}
+			pending[serial] = e
+		}
+
+		switch kind {
+		case "SYSCALL":
+			e.fields = fields
+		case "CWD":
+			e.cwd = fields["cwd"]
+		case "PATH":
+			if fields["nametype"] != "PARENT" && fields["name"] != "" {
+				e.paths = append(e.paths, fields["name"])
+			}
+		case "EOE":
+			resolve(e)
+			delete(pending, serial)
+		}
+	}
+	for _, e := range pending {
+		resolve(e)
+	}
+
+	return found, scanner.Err()
+}
+
+// Reports if event occurred before other, by time and serial
+func (e *auditEvent) before(other *auditEvent) bool {
+	if !e.time.Equal(other.time) {
+		return e.time.Before(other.time)
+	}
+	a, _ := strconv.ParseUint(e.serial, 10, 64)
+	b, _ := strconv.ParseUint(other.serial, 10, 64)
+
+	return a < b
+}
+
+// Parses record as type=PATH msg=audit(1700000000.123:42): key=value ...
+// Returns type, serial and time of event with fields.
+func parseAuditRecord(line string) (string, string, time.Time, map[string]string, bool) {
+	// Enriched logs append interpreted fields after group separator
+	if i := strings.IndexByte(line, 0x1d); i >= 0 {
+		line = line[:i]
+	}
+
+	if !strings.HasPrefix(line, "type=") {
+		return "", "", time.Time{}, nil, false
+	}
+	start := strings.Index(line, "msg=audit(")
+	end := strings.Index(line, "):")
+	if start < 0 || end < start {
+		return "", "", time.Time{}, nil, false
+	}
+	kind := strings.TrimSpace(line[len("type="):start])
+
+	stamp := strings.SplitN(line[start+len("msg=audit("):end], ":", 2)
+	if len(stamp) != 2 {
+		return "", "", time.Time{}, nil, false
+	}
+	secs, err := strconv.ParseFloat(stamp[0], 64)
+	if err != nil {
+		return "", "", time.Time{}, nil, false
+	}
+	at := time.Unix(int64(secs), int64((secs-float64(int64(secs)))*1e9)).UTC()
+
+	fields := make(map[string]string)
+	for _, kv := range splitAuditFields(line[end+2:]) {
+		if i := strings.IndexByte(kv, '='); i > 0 {
+			fields[kv[:i]] = auditValue(kv[:i], kv[i+1:])
+		}
+	}
+
+	return kind, stamp[1], at, fields, true
+}
+
+// Splits fields by spaces, quoted values may contain spaces
+func splitAuditFields(s string) []string {
+	var fields []string
+	var sb strings.Builder
+	quoted := false
+
+	for _, c := range s {
+		switch {
+		case c == '"':
+			quoted = !quoted
+			sb.WriteRune(c)
+		case c == ' ' && !quoted:
+			if sb.Len() > 0 {
+				fields = append(fields, sb.String())
+				sb.Reset()
+			}
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	if sb.Len() > 0 {
+		fields = append(fields, sb.String())
+	}
+
+	return fields
+}
+
+// Returns value of key unquoted, strings with special characters are
+// hex encoded without quotes by audit.
+func auditValue(key, v string) string {
+	if strings.HasPrefix(v, "\"") && strings.HasSuffix(v, "\"") && len(v) >= 2 {
+		return v[1 : len(v)-1]
+	}
+	if encodedFields[key] && v != "(null)" {
+		if decoded, err := hex.DecodeString(v); err == nil {
+			return string(decoded)
+		}
+	}
+
+	return v
+}
+
+// Returns name of user of uid, uid when unknown or unset
+func userName(uid string) string {
+	if uid == unsetAuid {
+		return "unset"
+	}
+	if u, err := user.LookupId(uid); err == nil {
+		return u.Username
+	}
+
+	return uid
+}
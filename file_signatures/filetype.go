@@ -0,0 +1,178 @@
+package main
+
+// Detection of file types from magic numbers, for filtering scans
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Types of files
+const (
+	typeELF     string = "elf"
+	typeScript  string = "script"
+	typeArchive string = "archive"
+	typeImage   string = "image"
+	typeMedia   string = "media"
+	typePDF     string = "pdf"
+	typeText    string = "text"
+	typeData    string = "data"
+	typeEmpty   string = "empty"
+)
+
+// Bytes read for detection, tar magic is at offset 257
+const sniffLen = 512
+
+// Magic numbers at offset of file
+type magic struct {
+	offset int
+	prefix []byte
+	kind   string
+}
+
+// Magic numbers of types, checked in order
+var magics = []magic{
+	{0, []byte("\x7fELF"), typeELF},
+	{0, []byte("#!"), typeScript},
+	{0, []byte("\x1f\x8b"), typeArchive},           // gzip
+	{0, []byte("PK\x03\x04"), typeArchive},         // zip, jar
+	{0, []byte("\xfd7zXZ\x00"), typeArchive},       // xz
+	{0, []byte("BZh"), typeArchive},                // bzip2
+	{0, []byte("\x28\xb5\x2f\xfd"), typeArchive},   // zstd
+	{0, []byte("7z\xbc\xaf\x27\x1c"), typeArchive}, // 7z
+	{257, []byte("ustar"), typeArchive},            // tar
+	{0, []byte("\x89PNG\r\n\x1a\n"), typeImage},    // png
+	{0, []byte("\xff\xd8\xff"), typeImage},         // jpeg
+	{0, []byte("GIF8"), typeImage},                 // gif
+	{0, []byte("ID3"), typeMedia},                  // mp3
+	{0, []byte("OggS"), typeMedia},                 // ogg
+	{0, []byte("fLaC"), typeMedia},                 // flac
+	{0, []byte("\x1a\x45\xdf\xa3"), typeMedia},     // mkv, webm
+	{4, []byte("ftyp"), typeMedia},                 // mp4, mov
+	{0, []byte("%PDF-"), typePDF},
+}
+
+// Types accepted by filter
+var fileTypes = []string{
+	typeELF, typeScript, typeArchive, typeImage, typeMedia, typePDF, typeText, typeData, typeEmpty,
+}
+
+// Filter of file types, included types or excluded with ! prefix
+type typeFilter struct {
+	include map[string]bool
+	exclude map[string]bool
+}
+
+// Parses comma separated types, nil filter without types.
+// Types are either included, as elf,script, or excluded, as !media.
+func parseTypeFilter(spec string) (*typeFilter, error) {
+	if spec == "" {
+		return nil, nil
+	}
+
+	f := &typeFilter{include: make(map[string]bool), exclude: make(map[string]bool)}
+	for _, t := range strings.Split(spec, ",") {
+		t = strings.TrimSpace(t)
+		exclude := strings.HasPrefix(t, "!")
+		t = strings.TrimPrefix(t, "!")
+		if !knownType(t) {
+			return nil, errors.New("File type " + t + " not supported, types are " + strings.Join(fileTypes, ", "))
+		}
+
+		if exclude {
+			f.exclude[t] = true
+		} else {
+			f.include[t] = true
+		}
+	}
+
+	if len(f.include) > 0 && len(f.exclude) > 0 {
+		return nil, errors.New("File types are either included or excluded with !, not both.")
+	}
+
+	return f, nil
+}
+
+// Returns true if files of type are scanned
+func (f *typeFilter) allows(kind string) bool {
+	if len(f.include) > 0 {
+		return f.include[kind]
+	}
+
+	return !f.exclude[kind]
+}
+
+// Returns type of file, read with first bytes only
+func detectType(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	return sniff(buf[:n]), nil
+}
+
+// Returns type of leading bytes of file
+func sniff(head []byte) string {
+	if len(head) == 0 {
+		return typeEmpty
+	}
+
+	for _, m := range magics {
+		if len(head) >= m.offset+len(m.prefix) && bytes.Equal(head[m.offset:m.offset+len(m.prefix)], m.prefix) {
+			return m.kind
+		}
+	}
+
+	// RIFF containers are webp images or wav and avi media
+	if len(head) >= 12 && bytes.HasPrefix(head, []byte("RIFF")) {
+		if bytes.Equal(head[8:12], []byte("WEBP")) {
+			return typeImage
+		}
+		return typeMedia
+	}
+
+	if isText(head) {
+		return typeText
+	}
+
+	return typeData
+}
+
+// Returns true if sample has no NUL bytes and is valid UTF-8,
+// multi-byte rune may be cut at end of sample.
+func isText(head []byte) bool {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return false
+	}
+
+	for cut := 0; cut < utf8.UTFMax && cut < len(head); cut++ {
+		if utf8.Valid(head[:len(head)-cut]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns true if type is known
+func knownType(kind string) bool {
+	for _, t := range fileTypes {
+		if t == kind {
+			return true
+		}
+	}
+
+	return false
+}
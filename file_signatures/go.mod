@@ -1,3 +1,29 @@
 module github.com/prashant-sb/go-utils/file_signatures
 
 go 1.13
+
+require (
+	github.com/prashant-sb/go-utils/config v0.0.0
+	github.com/prashant-sb/go-utils/lifecycle v0.0.0
+	github.com/prashant-sb/go-utils/logging v0.0.0
+	github.com/prashant-sb/go-utils/output v0.0.0
+	github.com/prashant-sb/go-utils/pool v0.0.0
+	github.com/prashant-sb/go-utils/privs v0.0.0
+	github.com/prashant-sb/go-utils/retry v0.0.0
+	github.com/prashant-sb/go-utils/signing v0.0.0
+	github.com/prashant-sb/go-utils/walker v0.0.0
+	golang.org/x/crypto v0.0.0-20201002170205-7f63de1d35b0
+	gopkg.in/yaml.v2 v2.2.2
+)
+
+replace (
+	github.com/prashant-sb/go-utils/config => ../config
+	github.com/prashant-sb/go-utils/lifecycle => ../lifecycle
+	github.com/prashant-sb/go-utils/logging => ../logging
+	github.com/prashant-sb/go-utils/output => ../output
+	github.com/prashant-sb/go-utils/pool => ../pool
+	github.com/prashant-sb/go-utils/privs => ../privs
+	github.com/prashant-sb/go-utils/retry => ../retry
+	github.com/prashant-sb/go-utils/signing => ../signing
+	github.com/prashant-sb/go-utils/walker => ../walker
+)
@@ -0,0 +1,110 @@
+package hash
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// cpuinfo lists CPU flags on linux
+const cpuinfo = "/proc/cpuinfo"
+
+// AccelOff is the GODEBUG setting disabling CPU features in
+// runtime, hashing of standard library falls back to generic code.
+const AccelOff = "cpu.all=off"
+
+// Features of CPU used by hashing
+type Features struct {
+	SHANI     bool // SHA extensions of x86
+	AVX2      bool
+	BMI2      bool // Needed with AVX2 by sha256 of x86
+	PCLMULQDQ bool // Carry-less multiply for crc32 of x86
+	SSE41     bool
+	SHA2      bool // SHA2 instructions of arm64
+	CRC32     bool // CRC32 instructions of arm64
+}
+
+// DetectFeatures reads CPU features from /proc/cpuinfo,
+// no features are reported when not readable.
+func DetectFeatures() Features {
+	return ReadFeatures(cpuinfo)
+}
+
+// ReadFeatures reads CPU features from file of cpuinfo format
+func ReadFeatures(file string) Features {
+	f, err := os.Open(file)
+	if err != nil {
+		return Features{}
+	}
+	defer f.Close()
+
+	flags := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		// flags on x86, Features on arm64, same for all cores
+		key := strings.TrimSpace(fields[0])
+		if key != "flags" && key != "Features" {
+			continue
+		}
+		for _, flag := range strings.Fields(fields[1]) {
+			flags[flag] = true
+		}
+		break
+	}
+
+	return Features{
+		SHANI:     flags["sha_ni"],
+		AVX2:      flags["avx2"],
+		BMI2:      flags["bmi2"],
+		PCLMULQDQ: flags["pclmulqdq"],
+		SSE41:     flags["sse4_1"],
+		SHA2:      flags["sha2"],
+		CRC32:     flags["crc32"],
+	}
+}
+
+// AccelDisabled returns true if CPU features are disabled with GODEBUG
+func AccelDisabled() bool {
+	for _, opt := range strings.Split(os.Getenv("GODEBUG"), ",") {
+		if strings.TrimSpace(opt) == AccelOff {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Implementation returns the implementation selected by standard
+// library for algorithm on CPU, generic without features or when
+// disabled.
+func Implementation(algo string, f Features) string {
+	if AccelDisabled() {
+		return "generic"
+	}
+
+	switch algo {
+	case "sha256":
+		switch {
+		case f.SHANI:
+			return "sha-ni"
+		case f.AVX2 && f.BMI2:
+			return "avx2"
+		case f.SHA2:
+			return "arm64-sha2"
+		}
+
+	case "crc":
+		switch {
+		case f.PCLMULQDQ && f.SSE41:
+			return "pclmulqdq"
+		case f.CRC32:
+			return "arm64-crc32"
+		}
+	}
+
+	return "generic"
+}
@@ -4,9 +4,12 @@ import (
 	"crypto/md5"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"hash/crc32"
 	"io"
 	"os"
+	"time"
 )
 
 // Polynomial seed for CRC calculation.
@@ -77,3 +80,63 @@ func FileCrc32(filePath string) (string, error) {
 
 	return crcCheckSum, nil
 }
+
+// ErrTimeout is wrapped by errors of reads not completed within timeout
+var ErrTimeout = errors.New("timed out")
+
+// Result of checksum read in background
+type sumResult struct {
+	sum string
+	err error
+}
+
+// WithTimeout returns checksum function failing the file when it is not
+// read within timeout, as reads on dead NFS or FUSE mounts may hang forever.
+// Stuck reads can't be interrupted and are left behind in background.
+func WithTimeout(filehash func(string) (string, error), timeout time.Duration) func(string) (string, error) {
+	if timeout <= 0 {
+		return filehash
+	}
+
+	return func(filePath string) (string, error) {
+		done := make(chan sumResult, 1)
+		go func() {
+			sum, err := filehash(filePath)
+			done <- sumResult{sum: sum, err: err}
+		}()
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case r := <-done:
+			return r.sum, r.err
+		case <-timer.C:
+			return "", fmt.Errorf("Read of %s is stuck, %w after %s", filePath, ErrTimeout, timeout)
+		}
+	}
+}
+
+// Sum returns checksum of data with algorithm crc | md5 | sha256,
+// formatted as checksums of files.
+func Sum(algo string, data []byte) (string, error) {
+	switch algo {
+	case "crc":
+		// Checksums of files are padded to 16 bytes
+		sum := make([]byte, 16)
+		h := crc32.New(crc32.MakeTable(polynomial))
+		h.Write(data)
+		copy(sum, h.Sum(nil))
+		return hex.EncodeToString(sum), nil
+
+	case "md5":
+		sum := md5.Sum(data)
+		return hex.EncodeToString(sum[:]), nil
+
+	case "sha256":
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:]), nil
+	}
+
+	return "", errors.New("Algorithm " + algo + " not supported.")
+}
@@ -0,0 +1,168 @@
+package main
+
+// Keys for signed and encrypted manifests
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prashant-sb/go-utils/file_signatures/manifest"
+	"github.com/prashant-sb/go-utils/signing"
+)
+
+// Keys of manifests read and written
+type manifestKeys struct {
+	pub        ed25519.PublicKey           // Verifies signed manifests
+	priv       ed25519.PrivateKey          // Signs written manifests
+	identities []*manifest.X25519Identity  // Decrypts encrypted manifests
+	recipients []*manifest.X25519Recipient // Encrypts written manifests
+}
+
+// Loads keys of files, recipients are comma separated keys or files of keys.
+// Blank names are skipped.
+func loadKeys(pubFile, privFile, identityFile, recipients string) (*manifestKeys, error) {
+	keys := &manifestKeys{}
+	var err error
+
+	if pubFile != "" {
+		if keys.pub, err = signing.LoadPublicKey(pubFile); err != nil {
+			return nil, err
+		}
+	}
+	if privFile != "" {
+		if keys.priv, err = signing.LoadPrivateKey(privFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if identityFile != "" {
+		f, err := os.Open(identityFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		if keys.identities, err = manifest.ParseIdentities(f); err != nil {
+			return nil, errors.New("Error in reading identities of " + identityFile + ": " + err.Error())
+		}
+	}
+
+	if recipients != "" {
+		if keys.recipients, err = parseRecipients(recipients); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
+
+// Parses comma separated recipients of age1 form, others
+// are read as files of recipients, one per line.
+func parseRecipients(list string) ([]*manifest.X25519Recipient, error) {
+	var recipients []*manifest.X25519Recipient
+
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		lines := []string{item}
+		if !strings.HasPrefix(item, "age1") {
+			data, err := ioutil.ReadFile(item)
+			if err != nil {
+				return nil, err
+			}
+			lines = strings.Split(string(data), "\n")
+		}
+
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			r, err := manifest.ParseX25519Recipient(line)
+			if err != nil {
+				return nil, err
+			}
+			recipients = append(recipients, r)
+		}
+	}
+
+	if len(recipients) == 0 {
+		return nil, errors.New("No recipients in " + list)
+	}
+
+	return recipients, nil
+}
+
+// Writes new identity to file in age-keygen format,
+// returns its recipient.
+func generateIdentity(file string) (string, error) {
+	id, err := manifest.GenerateX25519Identity()
+	if err != nil {
+		return "", err
+	}
+	recipient := id.Recipient().String()
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("# created: " + time.Now().Format(time.RFC3339) + "\n")
+	buf.WriteString("# public key: " + recipient + "\n")
+	buf.WriteString(id.String() + "\n")
+
+	// Existing identity is not replaced, manifests encrypted to it would be lost
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return "", err
+	}
+
+	return recipient, f.Close()
+}
+
+// Returns true if written manifests are encrypted
+func (k *manifestKeys) encrypts() bool {
+	return len(k.recipients) > 0
+}
+
+// Returns writer of manifest into w, encrypting to recipients
+// when set. Close flushes encryption, w is not closed.
+func (k *manifestKeys) writer(w io.Writer) (io.WriteCloser, error) {
+	if !k.encrypts() {
+		return nopCloser{w}, nil
+	}
+
+	return manifest.Encrypt(w, k.recipients...)
+}
+
+// Returns reader of manifest of r, decrypted with identities
+// when manifest is encrypted.
+func (k *manifestKeys) reader(r io.Reader) (io.Reader, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !manifest.IsEncrypted(data) {
+		return bytes.NewReader(data), nil
+	}
+
+	if len(k.identities) == 0 {
+		return nil, errors.New("Manifest is encrypted, identity needed for decrypting.")
+	}
+
+	return manifest.Decrypt(bytes.NewReader(data), k.identities...)
+}
+
+// Writer with Close doing nothing
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
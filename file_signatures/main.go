@@ -3,73 +3,382 @@ package main
 // CLI to calculate checksum of all files in given directory
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
-	"path/filepath"
-    hasher "github.com/prashant-sb/go-utils/file_signatures/hash"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/prashant-sb/go-utils/config"
+	hasher "github.com/prashant-sb/go-utils/file_signatures/hash"
+	"github.com/prashant-sb/go-utils/lifecycle"
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/output"
+	"github.com/prashant-sb/go-utils/pool"
+	"github.com/prashant-sb/go-utils/privs"
+	"github.com/prashant-sb/go-utils/walker"
 )
 
+// Options for CLI
 //
-//  Options for CLI
-//      dest: Destination dir / tmp will be default
-//      sign: Checksum algorithm / md5 will be default
+//	dest: Destination dir / tmp will be default
+//	sign: Checksum algorithm / md5 will be default
+//	workers: Number of concurrent workers / CPUs will be default
+//	ordered: Prints checksums in walk order
+//	file-timeout: Fails the file not read in time / disabled will be default
+//	verify: Verifies files with manifest instead of printing checksums
+//	verify-packages: Verifies packaged files with rpm or dpkg database
+//	verify-key: Public key file for verifying signed manifests
+//	identity: File of age identities for decrypting manifests
+//	recipient: Encrypts manifest output to age recipients or files of them
+//	age-keygen: Writes new age identity to file
+//	audit-log: Audit log attributing changes of failed files to processes
+//	base-dir: Directory or old=new remap of manifest paths for verify
+//	merge: Merges manifests given as arguments into file
+//	merge-format: Format of merged manifest, plain will be default
+//	signing-key: Private key file for signing merged manifests
+//	prune: Drops entries of files not existing while merging
+//	type: Scans only files of types, or skips types prefixed with !
+//	rollup: Prints digests of directories after checksums of files
+//	sample: Hashes or verifies sample of files, as 5%, for quick estimates
+//	sample-seed: Seed of sample, same seed selects same files
+//	policy: Yaml file of volatile paths tracked without checksum
+//	sink: Outputs of checksums, stdout, file:<path>, http(s)://<url> or syslog
+//	sink-batch: Checksums of each post to http sink
+//	sink-token: Bearer token of http sink
+//	summary: Prints json summary of scan after checksums
+//	summary-file: Writes json summary of scan to file instead
+//	no-accel: Disables CPU accelerated hashing, for comparing benchmarks
+//	output: auto | table | plain, table on terminal will be default
+//	config: Yaml or toml file with values for options
+//	log-level, log-format: Logging of errors on stderr
 //
+// Options are also read from FILE_SIGNATURES_<OPTION> environment.
 var (
-	dest = flag.String("dest", "/tmp", "root direcory for calculate file hashes")
-	sign = flag.String("sign", "md5", "Hashing algorithm")
+	dest    = flag.String("dest", "/tmp", "root direcory for calculate file hashes")
+	sign    = flag.String("sign", "md5", "Hashing algorithm")
+	workers = flag.Int("workers", runtime.NumCPU(), "Number of concurrent workers")
+	ordered = flag.Bool("ordered", false, "Prints checksums in walk order")
+	timeout = flag.Duration("file-timeout", 0, "Fails reading of file after timeout, e.g. 30s")
+	verify  = flag.String("verify", "", "Manifest file to verify checksums of files")
+	pkgs    = flag.Bool("verify-packages", false, "Verifies packaged files with digests of rpm or dpkg database")
+	pubFile = flag.String("verify-key", "", "Public key file for verifying signed manifests")
+	idFile  = flag.String("identity", "", "File of age identities for decrypting manifests")
+	recips  = flag.String("recipient", "", "Comma separated age recipients or files of them, encrypts manifest output")
+	keygen  = flag.String("age-keygen", "", "Writes new age identity to file, prints its recipient")
+	auditIn = flag.String("audit-log", "", "Audit log for attributing changes of failed files, e.g. /var/log/audit/audit.log")
+	baseDir = flag.String("base-dir", "", "Directory of relative paths in manifest, or old=new remap")
+	merge   = flag.String("merge", "", "Merges manifests given as arguments into file")
+	mergeAs = flag.String("merge-format", "plain", "Format of merged manifest, plain | coreutils | bsd | json | signed")
+	signKey = flag.String("signing-key", "", "Private key file for signing merged manifests")
+	prune   = flag.Bool("prune", false, "Drops entries of files not existing while merging")
+	types   = flag.String("type", "", "Comma separated file types to scan, or to skip with ! prefix")
+	rollups = flag.Bool("rollup", false, "Prints digests of directories after checksums of files")
+	sampled = flag.String("sample", "", "Hashes or verifies sample of files, e.g. 5%")
+	seed    = flag.String("sample-seed", toolName, "Seed of sample, same seed selects same files")
+	polFile = flag.String("policy", "", "Yaml policy file of volatile paths")
+	sinkTo  = flag.String("sink", "stdout", "Comma separated outputs of checksums, stdout | file:<path> | http(s)://<url> | syslog[(+tcp)://<host:port>]")
+	batch   = flag.Int("sink-batch", 500, "Checksums of each post to http sink")
+	token   = flag.String("sink-token", "", "Bearer token of http sink")
+	summ    = flag.Bool("summary", false, "Prints json summary of scan after checksums")
+	summOut = flag.String("summary-file", "", "Writes json summary of scan to file")
+	noAccel = flag.Bool("no-accel", false, "Disables CPU accelerated hashing")
+	format  = flag.String("output", "auto", "Output format, auto | table | plain")
+	cfgFile = flag.String("config", "", "Yaml or toml configuration file for options")
+
+	logLevel  = flag.String("log-level", "info", "Log level, debug | info | warn | error")
+	logFormat = flag.String("log-format", "text", "Log format, text | json")
 )
 
-// Worker thread for calculating checksum of file
-// depending on algorithm provided by user
+// Prefix of environment variables for options
+const envPrefix = "FILE_SIGNATURES"
+
+// Checksum of the file
+type fileSum struct {
+	path string
+	sum  string
+	size int64
+}
 
-func checksumWorker(filePath string) error {
-	var filehash func(filePath string) (string, error)
+// Returns checksum function for algorithm provided by user
+func hasherFor(algo string) (func(filePath string) (string, error), error) {
 
-	switch *sign {
+	switch algo {
 
 	case "crc":
-		filehash = hasher.FileCrc32
+		return hasher.FileCrc32, nil
 
 	case "md5":
-		filehash = hasher.FileMd5Sum
+		return hasher.FileMd5Sum, nil
 
 	case "sha256":
-		filehash = hasher.FileSha256
+		return hasher.FileSha256, nil
+	}
 
-	default:
-		err := errors.New("Algorithm not supported.")
-		return err
+	return nil, errors.New("Algorithm not supported.")
+}
+
+// Visitor for calculating checksum of file, volatile files
+// of policies are recorded by existence and permissions.
+// Files of types not allowed by filter, or not in sample, are skipped.
+func checksumWorker(filehash func(string) (string, error), pol *policies,
+	filter *typeFilter, detect func(string) (string, error), sample *sampler) walker.Visit {
+	return func(ctx context.Context, filePath string, info os.FileInfo) (interface{}, error) {
+		track := pol.track(filePath)
+		if track == trackIgnore || !sample.selects(filePath) {
+			return nil, nil
+		}
+
+		if filter != nil {
+			kind, err := detect(filePath)
+			if err != nil {
+				return nil, err
+			}
+			if !filter.allows(kind) {
+				return nil, nil
+			}
+		}
+
+		switch track {
+		case trackMetadata, trackExists:
+			return fileSum{path: filePath, sum: metadataToken(track, info), size: info.Size()}, nil
+		}
+
+		cs, err := filehash(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		return fileSum{path: filePath, sum: cs, size: info.Size()}, nil
 	}
+}
 
-	cs, err := filehash(filePath)
+// Returns result handler writing checksums to sinks. Checksums
+// are collected for digests of directories when rollup is set.
+func printer(out sink, stats *summary, roll *rollup) func(pool.Result) {
+	return func(r pool.Result) {
+		if r.Err != nil {
+			log.Error("Error in reading file: ", r.Err)
+			stats.addError(r.Err)
+			return
+		}
+
+		fs, ok := r.Value.(fileSum)
+		if !ok {
+			return
+		}
+		stats.add(fs)
+		if roll != nil {
+			roll.add(fs)
+		}
+		if err := out.Write(fs); err != nil {
+			log.Error("Error in writing output: ", err)
+		}
+	}
+}
+
+// Runs the executable again with CPU features disabled in runtime,
+// features can't be disabled once process is started.
+func execWithoutAccel() error {
+	exe, err := os.Executable()
 	if err != nil {
-		fmt.Printf("Error: %v", err)
 		return err
 	}
 
-	fmt.Printf("%s :: %s\n", filePath, cs)
-	return nil
-}
+	godebug := hasher.AccelOff
+	if env := os.Getenv("GODEBUG"); env != "" {
+		godebug = env + "," + godebug
+	}
+	os.Setenv("GODEBUG", godebug)
 
-// Callback for walking destination directory
+	return syscall.Exec(exe, os.Args, os.Environ())
+}
 
-func walkWith(path string, info os.FileInfo, err error) error {
-	if info.IsDir() {
-		return nil
+// Returns table for output format, nil for plain lines
+func tableFor(format string) (*output.Table, error) {
+	switch format {
+	case "auto":
+		if !lifecycle.IsTerminal(os.Stdout) {
+			return nil, nil
+		}
+	case "table":
+	case "plain":
+		return nil, nil
+	default:
+		return nil, errors.New("Output format " + format + " not supported.")
 	}
-	go checksumWorker(path)
 
-	return nil
+	return output.NewTable(os.Stdout, "FILE", "SIZE", strings.ToUpper(*sign)), nil
 }
 
 func main() {
 	flag.Parse()
 
-	err := filepath.Walk(*dest, walkWith)
+	err := config.Load(flag.CommandLine, config.Options{File: *cfgFile, EnvPrefix: envPrefix})
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+
+	if err := log.Setup(*logLevel, *logFormat); err != nil {
+		log.Error(err.Error())
+		return
+	}
+
+	if *noAccel && !hasher.AccelDisabled() {
+		if err := execWithoutAccel(); err != nil {
+			log.Error("Error in disabling acceleration: ", err)
+			return
+		}
+	}
+	log.Debug("Hashing with ", *sign, " implementation ",
+		hasher.Implementation(*sign, hasher.DetectFeatures()))
+
+	// Files of other users fail without privilege, reported once up front
+	if err := privs.Require(privs.CapDacReadSearch); err != nil {
+		log.Warn(err.Error(), ", files not readable by user will fail")
+	}
+
+	pol, err := loadPolicies(*polFile)
+	if err != nil {
+		log.Error("Error in reading policy: ", err)
+		return
+	}
+
+	if *keygen != "" {
+		recipient, err := generateIdentity(*keygen)
+		if err != nil {
+			log.Error("Error in generating identity: ", err)
+			os.Exit(1)
+		}
+		fmt.Println("Public key:", recipient)
+		return
+	}
+
+	keys, err := loadKeys(*pubFile, *signKey, *idFile, *recips)
+	if err != nil {
+		log.Error("Error in reading keys: ", err)
+		return
+	}
+
+	if *merge != "" {
+		if err := mergeManifests(*merge, flag.Args(), *baseDir, *sign, *mergeAs, *prune, keys); err != nil {
+			log.Error("Error in merging manifests: ", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	sample, err := parseSample(*sampled, *seed)
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+
+	if *pkgs {
+		if !verifyPackages(*baseDir, *workers, *timeout, pol, sample, *auditIn) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *verify != "" {
+		if !verifyManifest(*verify, *baseDir, *sign, *workers, *timeout, pol, keys, sample, *auditIn) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	filehash, err := hasherFor(*sign)
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+	filehash = hasher.WithTimeout(filehash, *timeout)
+
+	filter, err := parseTypeFilter(*types)
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+
+	table, err := tableFor(*format)
 	if err != nil {
-		fmt.Printf("Error : %s", err.Error())
+		log.Error(err.Error())
 		return
 	}
+
+	out, err := newSinks(*sinkTo, sinkOptions{
+		root:   *dest,
+		algo:   *sign,
+		batch:  *batch,
+		token:  *token,
+		table:  table,
+		format: *format,
+		keys:   keys,
+	})
+	if err != nil {
+		log.Error("Error in opening output: ", err)
+		return
+	}
+
+	stats := newSummary(*dest, *sign)
+	if sample != nil {
+		stats.Sample, stats.Seed = sample.String(), sample.seed
+	}
+
+	var roll *rollup
+	if *rollups && sample != nil {
+		log.Error("Digests of directories need all files, rollup can't be sampled.")
+		out.Close(nil)
+		return
+	}
+	if *rollups && isDir(*dest) {
+		roll = newRollup(*dest, *sign)
+	}
+
+	// Errors of files are printed with results
+	err = walker.Walk(context.Background(), *dest, walker.Options{
+		Workers:  *workers,
+		Ordered:  *ordered,
+		OnResult: printer(out, stats, roll),
+	}, checksumWorker(filehash, pol, filter, hasher.WithTimeout(detectType, *timeout), sample))
+
+	if roll != nil {
+		dirs, rerr := roll.digests()
+		if rerr != nil {
+			log.Error("Error in digesting directories: ", rerr)
+		}
+		for _, fs := range dirs {
+			if werr := out.Write(fs); werr != nil {
+				log.Error("Error in writing output: ", werr)
+			}
+		}
+	}
+
+	// Summary is the trailer of sinks, or written to file instead
+	stats.finish()
+	var trailer *summary
+	if *summ && *summOut == "" {
+		trailer = stats
+	}
+
+	// Table is rendered, batches posted and last chunk
+	// of encrypted manifest written once closed
+	if cerr := out.Close(trailer); cerr != nil {
+		log.Error("Error in writing output: ", cerr)
+	}
+
+	if *summOut != "" {
+		if serr := stats.write(*summOut); serr != nil {
+			log.Error("Error in writing summary: ", serr)
+		}
+	}
+
+	if err != nil || out.failed {
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,453 @@
+package manifest
+
+// Encryption of manifests to X25519 recipients in age format v1,
+// manifests are decrypted and encrypted with age tools as well.
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	ageIntro        string = "age-encryption.org/v1"
+	ageX25519       string = "X25519"
+	ageX25519Label  string = "age-encryption.org/v1/X25519"
+	ageRecipientHRP string = "age"
+	ageIdentityHRP  string = "AGE-SECRET-KEY-"
+
+	fileKeySize  = 16
+	nonceSize    = 16
+	chunkSize    = 64 * 1024
+	stanzaColumn = 64 // Wrapping of stanza bodies
+)
+
+var b64 = base64.RawStdEncoding
+
+// X25519Recipient is the public key manifests are encrypted to
+type X25519Recipient struct {
+	public []byte
+}
+
+// X25519Identity is the private key decrypting manifests
+type X25519Identity struct {
+	secret []byte
+	public []byte
+}
+
+// ParseX25519Recipient parses recipient of age1 form
+func ParseX25519Recipient(s string) (*X25519Recipient, error) {
+	hrp, key, err := bech32Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if hrp != ageRecipientHRP || len(key) != curve25519.PointSize {
+		return nil, errors.New("Recipient " + s + " is not age X25519 key.")
+	}
+
+	return &X25519Recipient{public: key}, nil
+}
+
+// String returns recipient in age1 form
+func (r *X25519Recipient) String() string {
+	s, _ := bech32Encode(ageRecipientHRP, r.public)
+	return s
+}
+
+// GenerateX25519Identity returns new random identity
+func GenerateX25519Identity() (*X25519Identity, error) {
+	secret := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	return newIdentity(secret)
+}
+
+// ParseX25519Identity parses identity of AGE-SECRET-KEY-1 form
+func ParseX25519Identity(s string) (*X25519Identity, error) {
+	hrp, secret, err := bech32Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if hrp != strings.ToLower(ageIdentityHRP) || len(secret) != curve25519.ScalarSize {
+		return nil, errors.New("Identity is not age X25519 key.")
+	}
+
+	return newIdentity(secret)
+}
+
+// ParseIdentities parses identities of file in age-keygen
+// format, lines of # are comments.
+func ParseIdentities(r io.Reader) ([]*X25519Identity, error) {
+	var ids []*X25519Identity
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		id, err := ParseX25519Identity(line)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, errors.New("No identities found.")
+	}
+
+	return ids, nil
+}
+
+// Returns identity of secret with its public key
+func newIdentity(secret []byte) (*X25519Identity, error) {
+	public, err := curve25519.X25519(secret, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &X25519Identity{secret: secret, public: public}, nil
+}
+
+// String returns identity in AGE-SECRET-KEY-1 form
+func (i *X25519Identity) String() string {
+	s, _ := bech32Encode(ageIdentityHRP, i.secret)
+	return strings.ToUpper(s)
+}
+
+// Recipient returns public key of identity
+func (i *X25519Identity) Recipient() *X25519Recipient {
+	return &X25519Recipient{public: i.public}
+}
+
+// IsEncrypted returns true if leading bytes of manifest are of age header
+func IsEncrypted(head []byte) bool {
+	return bytes.HasPrefix(head, []byte(ageIntro+"\n"))
+}
+
+// Encrypt returns writer encrypting to recipients into w, data
+// is flushed by Close.
+func Encrypt(w io.Writer, recipients ...*X25519Recipient) (io.WriteCloser, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("No recipients to encrypt to.")
+	}
+
+	fileKey := make([]byte, fileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, err
+	}
+
+	header := &bytes.Buffer{}
+	header.WriteString(ageIntro + "\n")
+	for _, r := range recipients {
+		share, body, err := r.wrap(fileKey)
+		if err != nil {
+			return nil, err
+		}
+		header.WriteString("-> " + ageX25519 + " " + b64.EncodeToString(share) + "\n")
+		writeWrapped(header, b64.EncodeToString(body))
+	}
+	header.WriteString("---")
+
+	mac := hmac.New(sha256.New, deriveKey(fileKey, nil, "header"))
+	mac.Write(header.Bytes())
+	header.WriteString(" " + b64.EncodeToString(mac.Sum(nil)) + "\n")
+
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	header.Write(nonce)
+
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.New(deriveKey(fileKey, nonce, "payload"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptWriter{w: w, aead: aead, buf: make([]byte, 0, chunkSize)}, nil
+}
+
+// Decrypt returns reader of data of r decrypted with
+// any of identities matching the recipients.
+func Decrypt(r io.Reader, identities ...*X25519Identity) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	header := &bytes.Buffer{}
+
+	readLine := func() (string, error) {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return "", errors.New("Header of encrypted manifest is truncated.")
+		}
+		header.WriteString(line)
+		return strings.TrimSuffix(line, "\n"), nil
+	}
+
+	if line, err := readLine(); err != nil || line != ageIntro {
+		return nil, errors.New("Manifest is not encrypted with age v1.")
+	}
+
+	var fileKey []byte
+	var macLine string
+	for {
+		line, err := readLine()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, "---") {
+			macLine = line
+			break
+		}
+		if !strings.HasPrefix(line, "-> ") {
+			return nil, errors.New("Header of encrypted manifest is malformed.")
+		}
+
+		args := strings.Fields(strings.TrimPrefix(line, "-> "))
+		var body strings.Builder
+		for {
+			part, err := readLine()
+			if err != nil {
+				return nil, err
+			}
+			body.WriteString(part)
+			if len(part) < stanzaColumn {
+				break
+			}
+		}
+
+		if fileKey != nil || len(args) != 2 || args[0] != ageX25519 {
+			continue
+		}
+		for _, id := range identities {
+			if fileKey, err = id.unwrap(args[1], body.String()); err == nil {
+				break
+			}
+		}
+	}
+
+	if fileKey == nil {
+		return nil, errors.New("No identity matches recipients of manifest.")
+	}
+
+	// Header is authenticated up to and including ---
+	mac, err := b64.DecodeString(strings.TrimPrefix(macLine, "--- "))
+	if err != nil {
+		return nil, err
+	}
+	authed := header.Bytes()[:header.Len()-len(macLine)-1+len("---")]
+	h := hmac.New(sha256.New, deriveKey(fileKey, nil, "header"))
+	h.Write(authed)
+	if !hmac.Equal(h.Sum(nil), mac) {
+		return nil, errors.New("Header of encrypted manifest is not authentic.")
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(br, nonce); err != nil {
+		return nil, errors.New("Payload of encrypted manifest is truncated.")
+	}
+
+	aead, err := chacha20poly1305.New(deriveKey(fileKey, nonce, "payload"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptReader{r: br, aead: aead, buf: make([]byte, chunkSize+aead.Overhead())}, nil
+}
+
+// Returns ephemeral share and file key wrapped for recipient
+func (r *X25519Recipient) wrap(fileKey []byte) ([]byte, []byte, error) {
+	ephemeral := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(ephemeral); err != nil {
+		return nil, nil, err
+	}
+	share, err := curve25519.X25519(ephemeral, curve25519.Basepoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	shared, err := curve25519.X25519(ephemeral, r.public)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aead, err := chacha20poly1305.New(deriveKey(shared, append(share, r.public...), ageX25519Label))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return share, aead.Seal(nil, make([]byte, aead.NonceSize()), fileKey, nil), nil
+}
+
+// Returns file key unwrapped from stanza of X25519 recipient
+func (i *X25519Identity) unwrap(shareArg, bodyArg string) ([]byte, error) {
+	share, err := b64.DecodeString(shareArg)
+	if err != nil || len(share) != curve25519.PointSize {
+		return nil, errors.New("Invalid share of X25519 stanza.")
+	}
+	body, err := b64.DecodeString(bodyArg)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := curve25519.X25519(i.secret, share)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.New(deriveKey(shared, append(share, i.public...), ageX25519Label))
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Open(nil, make([]byte, aead.NonceSize()), body, nil)
+}
+
+// Returns 32 byte key derived with HKDF-SHA256
+func deriveKey(secret, salt []byte, info string) []byte {
+	key := make([]byte, chacha20poly1305.KeySize)
+	io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte(info)), key)
+	return key
+}
+
+// Writes base64 body wrapped at 64 columns, body of full lines
+// ends with empty line so last line is always short.
+func writeWrapped(buf *bytes.Buffer, s string) {
+	for len(s) >= stanzaColumn {
+		buf.WriteString(s[:stanzaColumn] + "\n")
+		s = s[stanzaColumn:]
+	}
+	buf.WriteString(s + "\n")
+}
+
+// Returns nonce of chunk, counter with flag of last chunk
+func chunkNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	for i := 10; i >= 3; i-- {
+		nonce[i] = byte(counter)
+		counter >>= 8
+	}
+	if last {
+		nonce[11] = 1
+	}
+
+	return nonce
+}
+
+// Writer sealing data in chunks of 64 KiB
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	buf     []byte
+	counter uint64
+	err     error
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 && e.err == nil {
+		// Full chunk is sealed once more data follows, last chunk may be full
+		if len(e.buf) == chunkSize {
+			e.flush(false)
+			continue
+		}
+		c := copy(e.buf[len(e.buf):chunkSize], p)
+		e.buf = e.buf[:len(e.buf)+c]
+		p = p[c:]
+		n += c
+	}
+
+	return n, e.err
+}
+
+// Close seals last chunk, w is not closed
+func (e *encryptWriter) Close() error {
+	if e.err == nil {
+		e.flush(true)
+	}
+
+	return e.err
+}
+
+// Seals and writes buffered chunk
+func (e *encryptWriter) flush(last bool) {
+	out := e.aead.Seal(nil, chunkNonce(e.counter, last), e.buf, nil)
+	e.counter++
+	e.buf = e.buf[:0]
+	_, e.err = e.w.Write(out)
+}
+
+// Reader opening chunks of 64 KiB
+type decryptReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	buf     []byte
+	plain   []byte
+	counter uint64
+	done    bool
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// Reads and opens next chunk, chunk followed by end of data is last
+func (d *decryptReader) next() error {
+	n, err := io.ReadFull(d.r, d.buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+	if n < d.aead.Overhead() {
+		return errors.New("Payload of encrypted manifest is truncated.")
+	}
+
+	last := err != nil
+	if !last {
+		if _, perr := d.r.Peek(1); perr == io.EOF {
+			last = true
+		}
+	}
+
+	plain, err := d.aead.Open(d.buf[:0], chunkNonce(d.counter, last), d.buf[:n], nil)
+	if err != nil {
+		return errors.New("Payload of encrypted manifest is not authentic.")
+	}
+	if last && len(plain) == 0 && d.counter > 0 {
+		return errors.New("Payload of encrypted manifest has empty last chunk.")
+	}
+
+	d.counter++
+	d.plain = plain
+	d.done = last
+	return nil
+}
@@ -0,0 +1,318 @@
+// Package manifest reads and writes checksum manifests of file_signatures.
+//
+// Line manifests are of this tool (path :: sum), coreutils (sum  path)
+// and BSD (ALGO (path) = sum) formats, and are accepted interchangeably.
+// JSON manifests list entries with algorithm, signed manifests carry
+// ed25519 signature of the JSON manifest.
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// Formats of manifests
+const (
+	FormatPlain     string = "plain"     // path :: sum
+	FormatCoreutils string = "coreutils" // sum  path
+	FormatBSD       string = "bsd"       // ALGO (path) = sum
+	FormatJSON      string = "json"      // Manifest as json
+	FormatSigned    string = "signed"    // Json document with signature
+)
+
+// Tokens recorded instead of checksum for volatile files,
+// files are verified by existence or permissions.
+const (
+	TokenExists string = "exists"
+	TokenMode   string = "mode="
+)
+
+// Tags of BSD manifests by algorithm
+var bsdTags = map[string]string{
+	"md5":    "MD5",
+	"sha256": "SHA256",
+	"crc":    "CRC32",
+}
+
+var (
+	bsdLine       = regexp.MustCompile(`^([A-Za-z0-9]+)\s*\((.*)\)\s*=\s*([0-9A-Fa-f]+)$`)
+	coreutilsLine = regexp.MustCompile(`^\\?([0-9A-Fa-f]+)\s+\*?(.+)$`)
+)
+
+// Entry is the checksum of file
+type Entry struct {
+	Path string `json:"path"`
+
+	// Sum is the lowercase hex digest, or token of volatile file.
+	Sum string `json:"sum"`
+
+	// Algo is the algorithm of entry, blank when of manifest.
+	Algo string `json:"algorithm,omitempty"`
+
+	// Package owning the file, for package databases.
+	Package string `json:"package,omitempty"`
+
+	// Line of entry in line manifests.
+	Line int `json:"-"`
+}
+
+// Manifest is the list of entries with algorithm
+type Manifest struct {
+	// Algo is the algorithm of entries, blank when unknown as
+	// for line manifests of this tool and coreutils.
+	Algo    string  `json:"algorithm,omitempty"`
+	Entries []Entry `json:"entries"`
+}
+
+// Read parses manifest of any format from r. Signed manifests are
+// verified with pub, and rejected when pub is nil. Returns count of
+// malformed lines skipped in line manifests.
+func Read(r io.Reader, pub ed25519.PublicKey) (*Manifest, int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Line manifests may start with envelope of scan summary
+	trimmed := bytes.TrimSpace(data)
+	first := trimmed
+	if i := bytes.IndexByte(first, '\n'); i >= 0 {
+		first = first[:i]
+	}
+	if !bytes.HasPrefix(trimmed, []byte("{")) || IsEnvelope(string(first)) {
+		entries, malformed, err := Parse(bytes.NewReader(data))
+		if err != nil {
+			return nil, 0, err
+		}
+		return &Manifest{Entries: entries}, malformed, nil
+	}
+
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, err
+	}
+	if doc.Manifest != nil || doc.Signature != nil {
+		if pub == nil {
+			return nil, 0, errors.New("Manifest is signed, public key needed for verifying.")
+		}
+		if err := doc.Verify(pub); err != nil {
+			return nil, 0, err
+		}
+		return doc.Manifest, 0, nil
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, 0, err
+	}
+	for _, e := range m.Entries {
+		if e.Path == "" || !validSum(e.Sum) {
+			return nil, 0, errors.New("Entry " + e.Path + " of manifest is invalid.")
+		}
+	}
+
+	return m, 0, nil
+}
+
+// Parse parses line manifests of this tool, coreutils and BSD formats.
+// CRLF endings and surrounding whitespace are ignored, malformed lines
+// are counted and skipped. Envelopes of scan summary printed along with
+// checksums are skipped too.
+func Parse(r io.Reader) ([]Entry, int, error) {
+	var entries []Entry
+	malformed := 0
+	lineNo := 0
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNo++
+
+		line := strings.TrimSpace(strings.TrimSuffix(scanner.Text(), "\r"))
+		if line == "" || strings.HasPrefix(line, "#") || IsEnvelope(line) {
+			continue
+		}
+
+		e, ok := ParseLine(line)
+		if !ok {
+			malformed++
+			continue
+		}
+		e.Line = lineNo
+		entries = append(entries, e)
+	}
+
+	return entries, malformed, scanner.Err()
+}
+
+// IsEnvelope returns true if line is json envelope of go-utils tools,
+// as summary of scan.
+func IsEnvelope(line string) bool {
+	if !strings.HasPrefix(line, "{") {
+		return false
+	}
+
+	var e struct {
+		Tool          string `json:"tool"`
+		Kind          string `json:"kind"`
+		SchemaVersion int    `json:"schemaVersion"`
+	}
+	if err := json.Unmarshal([]byte(line), &e); err != nil {
+		return false
+	}
+
+	return e.Tool != "" && e.Kind != "" && e.SchemaVersion > 0
+}
+
+// ParseLine parses single line of line manifest. Line is of this tool
+// when :: with whitespace before it is followed by the whole last field,
+// so coreutils names with :: in them are not mistaken for it. Path::sum
+// without whitespace is accepted when line is of no other format.
+func ParseLine(line string) (Entry, bool) {
+	if e, ok := parsePlain(line, true); ok {
+		return e, true
+	}
+
+	if m := bsdLine.FindStringSubmatch(line); m != nil {
+		algo, ok := bsdAlgo(m[1])
+		if !ok {
+			return Entry{}, false
+		}
+		return Entry{Path: m[2], Sum: strings.ToLower(m[3]), Algo: algo}, true
+	}
+
+	if m := coreutilsLine.FindStringSubmatch(line); m != nil {
+		path := m[2]
+		// Names with newline or backslash are escaped by coreutils
+		if strings.HasPrefix(line, "\\") {
+			path = strings.NewReplacer("\\\\", "\\", "\\n", "\n").Replace(path)
+		}
+		return Entry{Path: path, Sum: strings.ToLower(m[1])}, true
+	}
+
+	return parsePlain(line, false)
+}
+
+// Parses line of this tool, spaced requires whitespace before ::
+func parsePlain(line string, spaced bool) (Entry, bool) {
+	i := strings.LastIndex(line, "::")
+	if i <= 0 || spaced && line[i-1] != ' ' && line[i-1] != '\t' {
+		return Entry{}, false
+	}
+
+	path := strings.TrimSpace(line[:i])
+	sum := strings.TrimSpace(line[i+2:])
+	if path == "" || strings.ContainsAny(sum, " \t") {
+		return Entry{}, false
+	}
+	if IsDigest(sum) {
+		return Entry{Path: path, Sum: strings.ToLower(sum)}, true
+	}
+	if sum == TokenExists || strings.HasPrefix(sum, TokenMode) {
+		return Entry{Path: path, Sum: sum}, true
+	}
+
+	return Entry{}, false
+}
+
+// Write writes manifest in plain, coreutils, BSD or JSON format.
+// Tokens of volatile files are written in plain and JSON only,
+// BSD lines need algorithm of entry or manifest. Names with
+// newline are written in coreutils and JSON only.
+func Write(w io.Writer, m *Manifest, format string) error {
+	if format == FormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(m)
+	}
+	if format == FormatSigned {
+		return errors.New("Signed manifests are written with WriteSigned.")
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, e := range m.Entries {
+		line, err := formatLine(e, m.Algo, format)
+		if err != nil {
+			return err
+		}
+		bw.WriteString(line + "\n")
+	}
+
+	return bw.Flush()
+}
+
+// Returns entry formatted as line of format
+func formatLine(e Entry, algo, format string) (string, error) {
+	// Only coreutils escapes newlines in names
+	if format != FormatCoreutils && strings.Contains(e.Path, "\n") {
+		return "", errors.New("Entry " + e.Path + " has newline, not written in " + format + " format.")
+	}
+	if format == FormatPlain {
+		return fmt.Sprintf("%s :: %s", e.Path, e.Sum), nil
+	}
+	if !IsDigest(e.Sum) {
+		return "", errors.New("Entry " + e.Path + " has no checksum for " + format + " format.")
+	}
+
+	switch format {
+	case FormatCoreutils:
+		if strings.ContainsAny(e.Path, "\\\n") {
+			path := strings.NewReplacer("\\", "\\\\", "\n", "\\n").Replace(e.Path)
+			return fmt.Sprintf("\\%s  %s", e.Sum, path), nil
+		}
+		return fmt.Sprintf("%s  %s", e.Sum, e.Path), nil
+
+	case FormatBSD:
+		if e.Algo != "" {
+			algo = e.Algo
+		}
+		tag, ok := bsdTags[algo]
+		if !ok {
+			return "", errors.New("Entry " + e.Path + " has no algorithm of BSD tags.")
+		}
+		return fmt.Sprintf("%s (%s) = %s", tag, e.Path, e.Sum), nil
+	}
+
+	return "", errors.New("Manifest format " + format + " not supported.")
+}
+
+// IsDigest returns true if s is the hex digest
+func IsDigest(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns true if sum is digest or token of volatile file
+func validSum(sum string) bool {
+	return IsDigest(sum) || sum == TokenExists || strings.HasPrefix(sum, TokenMode)
+}
+
+// Returns algorithm of BSD tag, CRC is accepted for CRC32
+func bsdAlgo(tag string) (string, bool) {
+	tag = strings.ToUpper(tag)
+	if tag == "CRC" {
+		return "crc", true
+	}
+	for algo, t := range bsdTags {
+		if t == tag {
+			return algo, true
+		}
+	}
+
+	return "", false
+}
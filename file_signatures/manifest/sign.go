@@ -0,0 +1,54 @@
+package manifest
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/prashant-sb/go-utils/signing"
+)
+
+// Signature of manifest
+type Signature = signing.Signature
+
+// Document is the manifest with its signature
+type Document struct {
+	Manifest  *Manifest  `json:"manifest"`
+	Signature *Signature `json:"signature,omitempty"`
+}
+
+// Sign returns document of manifest signed with private key
+func Sign(m *Manifest, key ed25519.PrivateKey) (*Document, error) {
+	sig, err := signing.Sign(m, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Document{Manifest: m, Signature: sig}, nil
+}
+
+// Verify checks signature of document with public key of signer.
+// Key embedded in document is not trusted.
+func (d *Document) Verify(pub ed25519.PublicKey) error {
+	if d.Manifest == nil {
+		return errors.New("Manifest missing in document.")
+	}
+	if d.Signature == nil {
+		return errors.New("Manifest is not signed.")
+	}
+
+	return d.Signature.Verify("manifest", d.Manifest, pub)
+}
+
+// WriteSigned writes manifest signed with private key as json document
+func WriteSigned(w io.Writer, m *Manifest, key ed25519.PrivateKey) error {
+	doc, err := Sign(m, key)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
@@ -0,0 +1,168 @@
+package main
+
+// Merging and pruning of checksum manifests
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/prashant-sb/go-utils/file_signatures/manifest"
+	log "github.com/prashant-sb/go-utils/logging"
+)
+
+// Merges manifests of inputs into out in format of manifest package, entries
+// of later inputs replace earlier ones of same path. With prune, entries of
+// files not existing are dropped. Out may be one of inputs.
+func mergeManifests(out string, inputs []string, base, algo, format string, prune bool, keys *manifestKeys) error {
+	if len(inputs) == 0 {
+		return errors.New("No manifests to merge.")
+	}
+	if err := checkFormat(format, keys); err != nil {
+		return err
+	}
+
+	merged := make(map[string]manifest.Entry)
+	rollups := 0
+	for _, in := range inputs {
+		entries, err := readManifest(in, keys)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			// Digests of directories are stale once files are merged
+			if isRollup(e) {
+				rollups++
+				continue
+			}
+			if e.Algo != "" && e.Algo != algo {
+				return errors.New("Entry " + e.Path + " of " + in + " is " + e.Algo + ", not " + algo + ".")
+			}
+			if prev, ok := merged[e.Path]; ok && prev.Sum != e.Sum {
+				log.Debug("Checksum of ", e.Path, " replaced by ", in)
+			}
+			merged[e.Path] = e
+		}
+	}
+
+	if rollups > 0 {
+		log.Warn(rollups, " digests of directories dropped, scan with -rollup again for new digests")
+	}
+
+	pruned := 0
+	if prune {
+		remap := remapper(base)
+		for path := range merged {
+			if _, err := os.Lstat(remap(path)); os.IsNotExist(err) {
+				delete(merged, path)
+				pruned++
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(merged))
+	for path := range merged {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	if err := writeManifest(out, paths, merged, algo, format, keys); err != nil {
+		return err
+	}
+	log.Info("Merged ", len(inputs), " manifests, ", len(paths), " entries, ", pruned, " pruned")
+
+	return nil
+}
+
+// Reads entries of manifest file of any format, encrypted manifests are
+// decrypted and signed ones verified with keys. Entries get algorithm
+// of manifest when it has one.
+func readManifest(file string, keys *manifestKeys) ([]manifest.Entry, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := keys.reader(f)
+	if err != nil {
+		return nil, err
+	}
+
+	m, malformed, err := manifest.Read(r, keys.pub)
+	if err != nil {
+		return nil, err
+	}
+	if malformed > 0 {
+		log.Warn(malformed, " lines of ", file, " are improperly formatted")
+	}
+
+	for i := range m.Entries {
+		if m.Entries[i].Algo == "" {
+			m.Entries[i].Algo = m.Algo
+		}
+	}
+
+	return m.Entries, nil
+}
+
+// Checks format of merged manifest, signed ones need private key
+func checkFormat(format string, keys *manifestKeys) error {
+	switch format {
+	case manifest.FormatPlain, manifest.FormatCoreutils, manifest.FormatBSD, manifest.FormatJSON:
+	case manifest.FormatSigned:
+		if keys.priv == nil {
+			return errors.New("Signed manifest needs private key of -signing-key.")
+		}
+	default:
+		return errors.New("Manifest format " + format + " not supported.")
+	}
+
+	return nil
+}
+
+// Writes entries to temporary file renamed to file, so file is either
+// old or complete manifest. Manifest is signed with private key of keys
+// in signed format, and encrypted to recipients of keys.
+func writeManifest(file string, paths []string, entries map[string]manifest.Entry, algo, format string, keys *manifestKeys) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	m := &manifest.Manifest{Algo: algo}
+	for _, path := range paths {
+		m.Entries = append(m.Entries, manifest.Entry{Path: path, Sum: entries[path].Sum})
+	}
+	w, err := keys.writer(tmp)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if format == manifest.FormatSigned {
+		err = manifest.WriteSigned(w, m, keys.priv)
+	} else {
+		err = manifest.Write(w, m, format)
+	}
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
@@ -0,0 +1,168 @@
+package main
+
+// Verification of packaged files with digests of rpm or dpkg database
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prashant-sb/go-utils/file_signatures/manifest"
+	log "github.com/prashant-sb/go-utils/logging"
+)
+
+// Package databases
+const (
+	dpkgInfoDir string = "/var/lib/dpkg/info"
+
+	// Files of all packages, with algorithm and flags of digests
+	rpmQueryFormat string = "[%{=NAME}\t%{=FILEDIGESTALGO}\t%{FILEFLAGS}\t%{FILEDIGESTS}\t%{FILENAMES}\n]"
+)
+
+// Flags of rpm files not verified, config files are expected to
+// change and ghost files are not shipped in package.
+const (
+	rpmFileConfig = 1 << 0
+	rpmFileGhost  = 1 << 6
+)
+
+// Digest algorithms of rpm, others are skipped
+var rpmAlgos = map[string]string{
+	"1": "md5",
+	"8": "sha256",
+}
+
+// Verifies files of installed packages with digests of dpkg or
+// rpm database, prints modified and missing files only. Failed files
+// are attributed with all events of auditLog when set, the database has
+// no time of baseline. Returns false if any file failed.
+func verifyPackages(base string, workers int, timeout time.Duration, pol *policies,
+	sample *sampler, auditLog string) bool {
+	entries, err := packageEntries()
+	if err != nil {
+		log.Error("Error in reading package database: ", err)
+		return false
+	}
+
+	var src *auditSource
+	if auditLog != "" {
+		src = &auditSource{file: auditLog}
+	}
+
+	return verifyEntries(entries, remapper(base), "", workers, timeout, pol, sample, true, src)
+}
+
+// Returns files of installed packages from database of host
+func packageEntries() ([]manifest.Entry, error) {
+	if info, err := os.Stat(dpkgInfoDir); err == nil && info.IsDir() {
+		return dpkgEntries(dpkgInfoDir)
+	}
+	if _, err := exec.LookPath("rpm"); err == nil {
+		return rpmEntries()
+	}
+
+	return nil, errors.New("No dpkg or rpm package database found.")
+}
+
+// Reads md5sums lists of dpkg, in coreutils format with paths
+// relative to root. Conffiles are not listed in md5sums.
+func dpkgEntries(dir string) ([]manifest.Entry, error) {
+	lists, err := filepath.Glob(filepath.Join(dir, "*.md5sums"))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []manifest.Entry
+	for _, list := range lists {
+		f, err := os.Open(list)
+		if err != nil {
+			return nil, err
+		}
+
+		pkgEntries, malformed, err := manifest.Parse(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		if malformed > 0 {
+			log.Warn(malformed, " lines of ", list, " are improperly formatted")
+		}
+
+		pkg := strings.TrimSuffix(filepath.Base(list), ".md5sums")
+		for _, e := range pkgEntries {
+			e.Path = "/" + strings.TrimPrefix(e.Path, "/")
+			e.Algo = "md5"
+			e.Package = pkg
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+// Queries files of all packages from rpm
+func rpmEntries() ([]manifest.Entry, error) {
+	out, err := exec.Command("rpm", "-qa", "--qf", rpmQueryFormat).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []manifest.Entry
+	skipped := 0
+	lineNo := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		lineNo++
+
+		e, ok := parseRpmLine(scanner.Text())
+		if !ok {
+			skipped++
+			continue
+		}
+		e.Line = lineNo
+		entries = append(entries, e)
+	}
+	if skipped > 0 {
+		log.Debug(skipped, " files of rpm database without verifiable digest are skipped")
+	}
+
+	return entries, scanner.Err()
+}
+
+// Parses line of name, digest algorithm, flags, digest and path.
+// Directories, symlinks, config and ghost files have no digest
+// to verify.
+func parseRpmLine(line string) (manifest.Entry, bool) {
+	fields := strings.SplitN(line, "\t", 5)
+	if len(fields) != 5 || fields[3] == "" || !manifest.IsDigest(fields[3]) {
+		return manifest.Entry{}, false
+	}
+
+	flags, err := strconv.Atoi(fields[2])
+	if err != nil || flags&(rpmFileConfig|rpmFileGhost) != 0 {
+		return manifest.Entry{}, false
+	}
+
+	// Databases of old rpm have no algorithm, digests are md5
+	algo := "md5"
+	if fields[1] != "(none)" {
+		var ok bool
+		if algo, ok = rpmAlgos[fields[1]]; !ok {
+			return manifest.Entry{}, false
+		}
+	}
+
+	return manifest.Entry{
+		Path:    fields[4],
+		Sum:     strings.ToLower(fields[3]),
+		Algo:    algo,
+		Package: fields[0],
+	}, true
+}
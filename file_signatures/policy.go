@@ -0,0 +1,112 @@
+package main
+
+// Policies of paths expected to change
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/prashant-sb/go-utils/file_signatures/manifest"
+	"gopkg.in/yaml.v2"
+)
+
+// Tracking of paths by policy
+const (
+	trackDigest   string = "digest"   // Content checksum, default
+	trackMetadata string = "metadata" // Existence and permissions
+	trackExists   string = "exists"   // Existence only
+	trackIgnore   string = "ignore"   // Not scanned or verified
+)
+
+// Tokens of manifest recorded instead of checksum for volatile paths
+const (
+	tokenExists string = manifest.TokenExists
+	tokenMode   string = manifest.TokenMode
+)
+
+// Policy of paths matching glob pattern
+type policy struct {
+	// Path is the glob pattern, matching the file or any of its parents.
+	Path string `yaml:"path"`
+
+	// Track is one of digest | metadata | exists | ignore.
+	Track string `yaml:"track"`
+}
+
+// Policies are checked in file order, first match wins
+type policies struct {
+	Volatile []policy `yaml:"volatile"`
+}
+
+// Loads policy file, nil policies track all paths by digest
+func loadPolicies(file string) (*policies, error) {
+	if file == "" {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &policies{}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+
+	for _, v := range p.Volatile {
+		switch v.Track {
+		case trackDigest, trackMetadata, trackExists, trackIgnore:
+		default:
+			return nil, errors.New("Track " + v.Track + " of " + v.Path + " not supported.")
+		}
+		if _, err := filepath.Match(v.Path, ""); err != nil {
+			return nil, errors.New("Invalid pattern " + v.Path + " in " + file)
+		}
+	}
+
+	return p, nil
+}
+
+// Returns tracking of path, digest when no policy matches
+func (p *policies) track(path string) string {
+	if p == nil {
+		return trackDigest
+	}
+
+	path = filepath.Clean(path)
+	for _, v := range p.Volatile {
+		for dir := path; ; dir = filepath.Dir(dir) {
+			if ok, _ := filepath.Match(v.Path, dir); ok {
+				return v.Track
+			}
+			if parent := filepath.Dir(dir); parent == dir {
+				break
+			}
+		}
+	}
+
+	return trackDigest
+}
+
+// Returns manifest token recorded for volatile file
+func metadataToken(track string, info os.FileInfo) string {
+	if track == trackExists {
+		return tokenExists
+	}
+
+	return tokenMode + permissions(info)
+}
+
+// Returns octal permission bits with setuid, setgid and sticky
+func permissions(info os.FileInfo) string {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return fmt.Sprintf("%04o", st.Mode&07777)
+	}
+
+	return fmt.Sprintf("%04o", uint32(info.Mode().Perm()))
+}
@@ -0,0 +1,144 @@
+package main
+
+// Rollup digests of directories from checksums of files
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	hasher "github.com/prashant-sb/go-utils/file_signatures/hash"
+	"github.com/prashant-sb/go-utils/file_signatures/manifest"
+)
+
+// Suffix of directory paths in manifests, entries of rollups
+const dirSuffix = "/"
+
+// Child of directory, file or subdirectory
+type rollupChild struct {
+	name string
+	sum  string // Blank for subdirectory until computed
+	size int64
+	dir  bool
+}
+
+// Collects checksums of files under root for digests of directories
+type rollup struct {
+	root     string
+	algo     string
+	children map[string]map[string]*rollupChild // Directory to children by name
+}
+
+// Inits rollup of directories under root
+func newRollup(root, algo string) *rollup {
+	return &rollup{
+		root:     filepath.Clean(root),
+		algo:     algo,
+		children: make(map[string]map[string]*rollupChild),
+	}
+}
+
+// Adds checksum of file to its directory, and directories up to
+// root as children of parents.
+func (r *rollup) add(fs fileSum) {
+	path := filepath.Clean(fs.path)
+	child := &rollupChild{name: filepath.Base(path), sum: fs.sum, size: fs.size}
+
+	for dir := filepath.Dir(path); r.under(dir); dir = filepath.Dir(dir) {
+		if r.children[dir] == nil {
+			r.children[dir] = make(map[string]*rollupChild)
+		}
+		if _, ok := r.children[dir][child.name]; ok {
+			return
+		}
+		r.children[dir][child.name] = child
+
+		if dir == r.root {
+			return
+		}
+		child = &rollupChild{name: filepath.Base(dir), dir: true}
+	}
+}
+
+// Returns true if dir is root or below
+func (r *rollup) under(dir string) bool {
+	if r.root == "." {
+		return !filepath.IsAbs(dir) && dir != ".." && !strings.HasPrefix(dir, ".."+dirSuffix)
+	}
+
+	return dir == r.root || strings.HasPrefix(dir, strings.TrimSuffix(r.root, dirSuffix)+dirSuffix)
+}
+
+// Returns digests of directories sorted by path. Digest is checksum
+// of sorted "name :: sum" lines of children, subdirectories named
+// with trailing slash. Size is total size of files below.
+func (r *rollup) digests() ([]fileSum, error) {
+	var sums []fileSum
+	if _, err := r.digest(r.root, &sums); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(sums, func(i, j int) bool {
+		return sums[i].path < sums[j].path
+	})
+
+	return sums, nil
+}
+
+// Computes digest of dir after its subdirectories
+func (r *rollup) digest(dir string, sums *[]fileSum) (*rollupChild, error) {
+	names := make([]string, 0, len(r.children[dir]))
+	for name := range r.children[dir] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	self := &rollupChild{name: filepath.Base(dir), dir: true}
+	buf := &bytes.Buffer{}
+	for _, name := range names {
+		c := r.children[dir][name]
+		if c.dir {
+			sub, err := r.digest(filepath.Join(dir, name), sums)
+			if err != nil {
+				return nil, err
+			}
+			c.sum, c.size = sub.sum, sub.size
+			name += dirSuffix
+		}
+		fmt.Fprintf(buf, "%s :: %s\n", name, c.sum)
+		self.size += c.size
+	}
+
+	var err error
+	if self.sum, err = hasher.Sum(r.algo, buf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	*sums = append(*sums, fileSum{path: dirPath(dir), sum: self.sum, size: self.size})
+
+	return self, nil
+}
+
+// Returns path of directory with trailing slash
+func dirPath(dir string) string {
+	if strings.HasSuffix(dir, dirSuffix) {
+		return dir
+	}
+
+	return dir + dirSuffix
+}
+
+// Returns true if entry of manifest is rollup of directory
+func isRollup(e manifest.Entry) bool {
+	return strings.HasSuffix(e.Path, dirSuffix)
+}
+
+// Returns true if root is directory, rollups are computed for
+// directories only.
+func isDir(root string) bool {
+	info, err := os.Stat(root)
+	return err == nil && info.IsDir()
+}
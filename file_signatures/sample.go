@@ -0,0 +1,83 @@
+package main
+
+// Deterministic sampling of files for quick estimates
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Resolution of sample rate
+const sampleScale = 1000000
+
+// Selects same files of same seed, so sampled runs are comparable
+type sampler struct {
+	rate float64 // Fraction of files selected, 0 - 1
+	seed string
+}
+
+// Parses sample rate as percent, 5%, or fraction, 0.05.
+// Returns nil for blank rate, all files are selected.
+func parseSample(rate, seed string) (*sampler, error) {
+	if rate == "" {
+		return nil, nil
+	}
+
+	value := strings.TrimSuffix(rate, "%")
+	r, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, errors.New("Sample " + rate + " is not a rate, use percent as 5%.")
+	}
+	if value != rate {
+		r /= 100
+	}
+	if r <= 0 || r > 1 {
+		return nil, errors.New("Sample " + rate + " out of range, use above 0% up to 100%.")
+	}
+
+	return &sampler{rate: r, seed: seed}, nil
+}
+
+// Returns true if path is in sample, selected by hash of
+// seed and path. Nil sampler selects all paths.
+func (s *sampler) selects(path string) bool {
+	if s == nil {
+		return true
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(s.seed))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+
+	return h.Sum64()%sampleScale < uint64(s.rate*sampleScale)
+}
+
+// Returns estimated number of changed files of population with margin
+// of 95% confidence, from failed of sampled files. Margin is corrected
+// for sample drawn without replacement from population.
+func (s *sampler) estimate(failed, sampled, population int) (float64, float64) {
+	if sampled == 0 {
+		return 0, 0
+	}
+
+	p := float64(failed) / float64(sampled)
+	margin := 1.96 * math.Sqrt(p*(1-p)/float64(sampled))
+	if failed == 0 {
+		// Upper bound of rule of three, margin is otherwise zero
+		margin = 3 / float64(sampled)
+	}
+	if population > 1 {
+		margin *= math.Sqrt(float64(population-sampled) / float64(population-1))
+	}
+
+	return p * float64(population), margin * float64(population)
+}
+
+// Returns sample rate as percent
+func (s *sampler) String() string {
+	return strconv.FormatFloat(s.rate*100, 'g', -1, 64) + "%"
+}
@@ -0,0 +1,354 @@
+package main
+
+// Sinks receiving checksums of scan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/syslog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prashant-sb/go-utils/lifecycle"
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/output"
+	"github.com/prashant-sb/go-utils/retry"
+)
+
+// Schema version of checksums posted to http sinks
+const checksumsVersion = 1
+
+// Timeout of each post to http sink
+const postTimeout = 30 * time.Second
+
+// Retry of posts failing with network error or server error
+var postRetry = retry.Options{
+	Attempts: 5,
+	Initial:  500 * time.Millisecond,
+	Jitter:   0.2,
+}
+
+// Sink receives checksums of scanned files from single goroutine
+type sink interface {
+	// Write emits checksum of file.
+	Write(fs fileSum) error
+
+	// Close flushes pending checksums, and emits summary
+	// of scan when not nil.
+	Close(stats *summary) error
+}
+
+// Options of sinks
+type sinkOptions struct {
+	root   string        // Scanned root
+	algo   string        // Algorithm of checksums
+	batch  int           // Checksums of each http post
+	token  string        // Bearer token of http sinks
+	table  *output.Table // Table of stdout sink, nil for lines
+	format string        // Output format of stdout
+	keys   *manifestKeys // Encrypts stdout and file sinks
+}
+
+// Returns sinks of comma separated specs: stdout, file:<path>,
+// http(s)://<url>, syslog and syslog(+tcp)://<host:port>.
+func newSinks(specs string, opts sinkOptions) (*sinks, error) {
+	out := &sinks{}
+
+	for _, spec := range strings.Split(specs, ",") {
+		s, err := newSink(strings.TrimSpace(spec), opts)
+		if err != nil {
+			out.Close(nil)
+			return nil, err
+		}
+		out.list = append(out.list, s)
+	}
+
+	return out, nil
+}
+
+// Returns sink of spec
+func newSink(spec string, opts sinkOptions) (sink, error) {
+	switch {
+	case spec == "stdout":
+		if !opts.keys.encrypts() {
+			if opts.table != nil {
+				return &tableSink{table: opts.table}, nil
+			}
+			return &lineSink{w: os.Stdout}, nil
+		}
+		if opts.format == "table" {
+			return nil, errors.New("Table output can't be encrypted, use plain output.")
+		}
+		if lifecycle.IsTerminal(os.Stdout) {
+			return nil, errors.New("Encrypted manifest is not written to terminal, redirect output.")
+		}
+		enc, err := opts.keys.writer(os.Stdout)
+		if err != nil {
+			return nil, err
+		}
+		return &lineSink{w: enc, closers: []io.Closer{enc}}, nil
+
+	case strings.HasPrefix(spec, "file:"):
+		f, err := os.Create(strings.TrimPrefix(spec, "file:"))
+		if err != nil {
+			return nil, err
+		}
+		enc, err := opts.keys.writer(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		// Encryption is flushed before file is closed
+		return &lineSink{w: enc, closers: []io.Closer{enc, f}}, nil
+
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		if opts.keys.encrypts() {
+			return nil, errors.New("Sink " + spec + " can't be encrypted, use stdout or file.")
+		}
+		batch := opts.batch
+		if batch <= 0 {
+			batch = 500
+		}
+		return &httpSink{
+			url:    spec,
+			token:  opts.token,
+			root:   opts.root,
+			algo:   opts.algo,
+			batch:  batch,
+			client: &http.Client{Timeout: postTimeout},
+		}, nil
+
+	case spec == "syslog", strings.HasPrefix(spec, "syslog://"), strings.HasPrefix(spec, "syslog+tcp://"):
+		if opts.keys.encrypts() {
+			return nil, errors.New("Sink " + spec + " can't be encrypted, use stdout or file.")
+		}
+		network, addr := "", ""
+		if i := strings.Index(spec, "://"); i >= 0 {
+			network, addr = "udp", spec[i+3:]
+			if strings.HasPrefix(spec, "syslog+tcp") {
+				network = "tcp"
+			}
+		}
+		w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_DAEMON, toolName)
+		if err != nil {
+			return nil, err
+		}
+		return &syslogSink{w: w}, nil
+	}
+
+	return nil, errors.New("Sink " + spec + " not supported, use stdout, file:<path>, http(s)://<url> or syslog.")
+}
+
+// Sinks receiving every checksum, failed is set once any write failed
+type sinks struct {
+	list   []sink
+	failed bool
+}
+
+// Writes checksum to all sinks, returns first error
+func (ss *sinks) Write(fs fileSum) error {
+	var first error
+	for _, s := range ss.list {
+		if err := s.Write(fs); err != nil && first == nil {
+			first = err
+		}
+	}
+	if first != nil {
+		ss.failed = true
+	}
+
+	return first
+}
+
+// Closes all sinks, returns first error
+func (ss *sinks) Close(stats *summary) error {
+	var first error
+	for _, s := range ss.list {
+		if err := s.Close(stats); err != nil && first == nil {
+			first = err
+		}
+	}
+	if first != nil {
+		ss.failed = true
+	}
+
+	return first
+}
+
+// Sink writing checksum lines, as of coreutils
+type lineSink struct {
+	w       io.Writer
+	closers []io.Closer // Closed in order
+}
+
+func (s *lineSink) Write(fs fileSum) error {
+	_, err := fmt.Fprintf(s.w, "%s :: %s\n", fs.path, fs.sum)
+	return err
+}
+
+// Summary is written as last line, encrypted along with checksums
+func (s *lineSink) Close(stats *summary) error {
+	var err error
+	if stats != nil {
+		err = stats.envelope().Write(s.w, "")
+	}
+	for _, c := range s.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// Sink collecting table rows, rendered once scan completes
+type tableSink struct {
+	table *output.Table
+}
+
+func (s *tableSink) Write(fs fileSum) error {
+	s.table.Append(fs.path, output.HumanBytes(fs.size), fs.sum)
+	return nil
+}
+
+func (s *tableSink) Close(stats *summary) error {
+	if err := s.table.Render(); err != nil {
+		return err
+	}
+	if stats != nil {
+		return stats.envelope().Write(os.Stdout, "")
+	}
+
+	return nil
+}
+
+// Checksum of file posted to http sink
+type sumRecord struct {
+	Path string `json:"path"`
+	Sum  string `json:"sum"`
+	Size int64  `json:"size"`
+}
+
+// Payload of checksums posted in batch
+type checksumBatch struct {
+	Root      string      `json:"root"`
+	Algorithm string      `json:"algorithm"`
+	Files     []sumRecord `json:"files"`
+}
+
+// Sink posting batches of checksums to http endpoint, as json envelopes
+// of kind checksums. Summary is posted as envelope of kind summary.
+type httpSink struct {
+	url    string
+	token  string
+	root   string
+	algo   string
+	batch  int
+	client *http.Client
+
+	pending []sumRecord
+}
+
+func (s *httpSink) Write(fs fileSum) error {
+	s.pending = append(s.pending, sumRecord{Path: fs.path, Sum: fs.sum, Size: fs.size})
+	if len(s.pending) < s.batch {
+		return nil
+	}
+
+	return s.flush()
+}
+
+func (s *httpSink) Close(stats *summary) error {
+	err := s.flush()
+	if stats != nil {
+		if perr := s.post(stats.envelope()); perr != nil && err == nil {
+			err = perr
+		}
+	}
+
+	return err
+}
+
+// Posts pending checksums, dropped when post fails after retries
+func (s *httpSink) flush() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	files := s.pending
+	s.pending = nil
+
+	payload := &checksumBatch{Root: s.root, Algorithm: s.algo, Files: files}
+	if err := s.post(output.NewEnvelope(toolName, "checksums", checksumsVersion, payload)); err != nil {
+		return errors.New(strconv.Itoa(len(files)) + " checksums not posted to " + s.url + ": " + err.Error())
+	}
+
+	return nil
+}
+
+// Posts envelope, retried on network errors and server errors
+func (s *httpSink) post(e *output.Envelope) error {
+	body := &bytes.Buffer{}
+	if err := e.Write(body, ""); err != nil {
+		return err
+	}
+
+	return retry.Do(context.Background(), postRetry, func(ctx context.Context) error {
+		req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body.Bytes()))
+		if err != nil {
+			return retry.Permanent(err)
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set("Content-Type", "application/json")
+		if s.token != "" {
+			req.Header.Set("Authorization", "Bearer "+s.token)
+		}
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			log.Debug("Post to ", s.url, " failed: ", err)
+			return err
+		}
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode < 300:
+			return nil
+		case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+			log.Debug("Post to ", s.url, " failed: ", resp.Status)
+			return errors.New(resp.Status)
+		}
+
+		// Refused posts fail the same when retried
+		return retry.Permanent(errors.New(resp.Status))
+	})
+}
+
+// Sink logging checksum lines to syslog, summary as json
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+func (s *syslogSink) Write(fs fileSum) error {
+	return s.w.Info(fs.path + " :: " + fs.sum)
+}
+
+func (s *syslogSink) Close(stats *summary) error {
+	var err error
+	if stats != nil {
+		buf := &bytes.Buffer{}
+		if err = stats.envelope().Write(buf, ""); err == nil {
+			err = s.w.Info(strings.TrimSpace(buf.String()))
+		}
+	}
+	if cerr := s.w.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+
+	return err
+}
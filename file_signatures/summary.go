@@ -0,0 +1,108 @@
+package main
+
+// Machine-readable statistics of scan
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+
+	hasher "github.com/prashant-sb/go-utils/file_signatures/hash"
+	"github.com/prashant-sb/go-utils/output"
+)
+
+// Name of tool in summary
+const toolName = "file_signatures"
+
+// Schema version of summary in envelope
+const summaryVersion = 1
+
+// Version of tool, set with -ldflags "-X main.version=<version>"
+var version = "dev"
+
+// Kinds of errors counted in summary
+const (
+	errPermission = "permission"
+	errNotExist   = "not-exist"
+	errTimeout    = "timeout"
+	errRead       = "read"
+)
+
+// Summary of scan, printed as trailer or written to file
+type summary struct {
+	Tool      string         `json:"tool"`
+	Version   string         `json:"version"`
+	Hostname  string         `json:"hostname"`
+	Algorithm string         `json:"algorithm"`
+	Impl      string         `json:"implementation"`
+	Root      string         `json:"root"`
+	Start     time.Time      `json:"start"`
+	End       time.Time      `json:"end"`
+	Duration  float64        `json:"durationSeconds"`
+	Files     int            `json:"files"`
+	Bytes     int64          `json:"bytes"`
+	Errors    map[string]int `json:"errors"`
+
+	// Sample is rate of files hashed with its seed, blank for all files
+	Sample string `json:"sample,omitempty"`
+	Seed   string `json:"sampleSeed,omitempty"`
+}
+
+// Inits summary of scan starting now
+func newSummary(root, algo string) *summary {
+	host, _ := os.Hostname()
+
+	return &summary{
+		Tool:      toolName,
+		Version:   version,
+		Hostname:  host,
+		Algorithm: algo,
+		Impl:      hasher.Implementation(algo, hasher.DetectFeatures()),
+		Root:      root,
+		Start:     time.Now().UTC(),
+		Errors:    make(map[string]int),
+	}
+}
+
+// Counts checksum of file
+func (s *summary) add(fs fileSum) {
+	s.Files++
+	s.Bytes += fs.size
+}
+
+// Counts error by kind
+func (s *summary) addError(err error) {
+	switch {
+	case os.IsPermission(err):
+		s.Errors[errPermission]++
+	case os.IsNotExist(err):
+		s.Errors[errNotExist]++
+	case errors.Is(err, hasher.ErrTimeout):
+		s.Errors[errTimeout]++
+	default:
+		s.Errors[errRead]++
+	}
+}
+
+// Sets end time of scan
+func (s *summary) finish() {
+	s.End = time.Now().UTC()
+	s.Duration = s.End.Sub(s.Start).Seconds()
+}
+
+// Returns summary in envelope
+func (s *summary) envelope() *output.Envelope {
+	return output.NewEnvelope(toolName, "summary", summaryVersion, s)
+}
+
+// Writes summary in envelope as json line to file
+func (s *summary) write(file string) error {
+	buf := &bytes.Buffer{}
+	if err := s.envelope().Write(buf, ""); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, buf.Bytes(), 0644)
+}
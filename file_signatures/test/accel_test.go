@@ -0,0 +1,102 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	hasher "github.com/prashant-sb/go-utils/file_signatures/hash"
+)
+
+func TestImplementation(t *testing.T) {
+	os.Unsetenv("GODEBUG")
+
+	for _, tc := range []struct {
+		algo string
+		f    hasher.Features
+		want string
+	}{
+		{"sha256", hasher.Features{SHANI: true, AVX2: true, BMI2: true}, "sha-ni"},
+		{"sha256", hasher.Features{AVX2: true, BMI2: true}, "avx2"},
+		{"sha256", hasher.Features{AVX2: true}, "generic"},
+		{"sha256", hasher.Features{BMI2: true}, "generic"},
+		{"sha256", hasher.Features{SHA2: true}, "arm64-sha2"},
+		{"sha256", hasher.Features{}, "generic"},
+		{"crc", hasher.Features{PCLMULQDQ: true, SSE41: true}, "pclmulqdq"},
+		{"crc", hasher.Features{PCLMULQDQ: true}, "generic"},
+		{"crc", hasher.Features{CRC32: true}, "arm64-crc32"},
+		{"md5", hasher.Features{SHANI: true, AVX2: true, BMI2: true, PCLMULQDQ: true, SSE41: true}, "generic"},
+	} {
+		if got := hasher.Implementation(tc.algo, tc.f); got != tc.want {
+			t.Errorf("Implementation() FAILED, %s of %+v expected: %s got: %s", tc.algo, tc.f, tc.want, got)
+		}
+	}
+	t.Logf("Implementation() PASSED")
+}
+
+func TestAccelDisabled(t *testing.T) {
+	defer os.Unsetenv("GODEBUG")
+
+	for godebug, want := range map[string]bool{
+		"":                            false,
+		"cpu.avx2=off":                false,
+		"cpu.all=off":                 true,
+		"madvdontneed=1, cpu.all=off": true,
+		"cpu.all=offline,gctrace=1":   false,
+		"gctrace=1,cpu.all=off,x=1":   true,
+	} {
+		os.Setenv("GODEBUG", godebug)
+		if got := hasher.AccelDisabled(); got != want {
+			t.Errorf("AccelDisabled() FAILED, GODEBUG=%q expected: %v got: %v", godebug, want, got)
+		}
+	}
+
+	os.Setenv("GODEBUG", hasher.AccelOff)
+	if got := hasher.Implementation("sha256", hasher.Features{SHANI: true}); got != "generic" {
+		t.Errorf("Implementation() FAILED, disabled acceleration reported %s", got)
+	}
+	t.Logf("AccelDisabled() PASSED")
+}
+
+func TestReadFeatures(t *testing.T) {
+	f, err := ioutil.TempFile("", "cpuinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("processor\t: 0\nflags\t\t: fpu sse4_1 pclmulqdq avx2 bmi2 sha_ni\n\n" +
+		"processor\t: 1\nflags\t\t: fpu\n")
+	f.Close()
+
+	want := hasher.Features{SHANI: true, AVX2: true, BMI2: true, PCLMULQDQ: true, SSE41: true}
+	if got := hasher.ReadFeatures(f.Name()); got != want {
+		t.Errorf("ReadFeatures() FAILED, expected: %+v got: %+v", want, got)
+	}
+
+	ioutil.WriteFile(f.Name(), []byte("processor\t: 0\nFeatures\t: fp asimd sha2 crc32\n"), 0644)
+	if got := hasher.ReadFeatures(f.Name()); got != (hasher.Features{SHA2: true, CRC32: true}) {
+		t.Errorf("ReadFeatures() FAILED, arm64 features %+v", got)
+	}
+	if got := hasher.ReadFeatures("/no/such/cpuinfo"); got != (hasher.Features{}) {
+		t.Errorf("ReadFeatures() FAILED, missing file %+v", got)
+	}
+	t.Logf("ReadFeatures() PASSED")
+}
+
+func TestNoAccel(t *testing.T) {
+	dir := writeTree(t, map[string]string{"a": "a\n"})
+	defer os.RemoveAll(dir)
+
+	// Runs again with acceleration disabled, appended to GODEBUG of caller
+	cmd := exec.Command(command, "-dest", dir, "-sign", "sha256", "-no-accel", "-summary", "-log-level", "debug")
+	cmd.Env = append(os.Environ(), "GODEBUG=madvdontneed=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil || !strings.Contains(string(out), `"implementation":"generic"`) ||
+		!strings.Contains(string(out), "Hashing with sha256 implementation generic") ||
+		!strings.Contains(string(out), dir+"/a :: "+sha256Hex("a\n")) {
+		t.Errorf("NoAccel() FAILED, %v\n%s", err, out)
+	}
+	t.Logf("NoAccel() PASSED")
+}
@@ -0,0 +1,188 @@
+package manifest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/file_signatures/manifest"
+)
+
+// Key pair of age-keygen manual. Vectors x25519.age and x25519-chunks.age
+// are encrypted to it with file key YELLOW SUBMARINE by implementation of
+// age v1 spec independent of manifest package.
+const (
+	testIdentity  = "AGE-SECRET-KEY-1N9JEPW6DWJ0ZQUDX63F5A03GX8QUW7PXDE39N8UYF82VZ9PC8UFS3M7XA9"
+	testRecipient = "age1lvyvwawkr0mcnnnncaghunadrqkmuf9e6507x9y920xxpp866cnql7dp2z"
+)
+
+// Size of payload chunks of age
+const ageChunk = 64 * 1024
+
+// Plaintext of x25519-chunks.age, spanning two chunks
+func chunksPlain() []byte {
+	data := make([]byte, ageChunk+10)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+
+	return data
+}
+
+func testKeys(t *testing.T) *manifest.X25519Identity {
+	id, err := manifest.ParseX25519Identity(testIdentity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
+
+func decryptAll(data []byte, ids ...*manifest.X25519Identity) ([]byte, error) {
+	r, err := manifest.Decrypt(bytes.NewReader(data), ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadAll(r)
+}
+
+func encryptAll(t *testing.T, plain []byte, recipients ...*manifest.X25519Recipient) []byte {
+	buf := &bytes.Buffer{}
+	w, err := manifest.Encrypt(buf, recipients...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(plain); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestAgeKeys(t *testing.T) {
+	id := testKeys(t)
+	if id.String() != testIdentity || id.Recipient().String() != testRecipient {
+		t.Errorf("ParseX25519Identity() FAILED, %s of %s", id.Recipient(), id)
+	}
+
+	r, err := manifest.ParseX25519Recipient(testRecipient)
+	if err != nil || r.String() != testRecipient {
+		t.Errorf("ParseX25519Recipient() FAILED, %v, %v", r, err)
+	}
+
+	bad := []string{
+		testRecipient[:len(testRecipient)-1] + "q", // Checksum
+		"Age1" + testRecipient[4:],                 // Mixed case
+		strings.ToLower(testIdentity),              // Identity as recipient
+		"age1qqqqqqqqqqqqqqqqqx8mmpn",              // Short key
+	}
+	for _, s := range bad {
+		if _, err := manifest.ParseX25519Recipient(s); err == nil {
+			t.Errorf("ParseX25519Recipient() FAILED, %s accepted", s)
+		}
+	}
+
+	keygen := "# created: 2021-01-02T15:30:45+01:00\n# public key: " + testRecipient + "\n" + testIdentity + "\n"
+	ids, err := manifest.ParseIdentities(strings.NewReader(keygen))
+	if err != nil || len(ids) != 1 || ids[0].String() != testIdentity {
+		t.Errorf("ParseIdentities() FAILED, %v, %v", ids, err)
+	}
+	t.Logf("AgeKeys() PASSED")
+}
+
+func TestAgeVectors(t *testing.T) {
+	id := testKeys(t)
+
+	vectors := map[string][]byte{
+		"x25519.age":        []byte("/etc/hosts :: 2bd8b4df8a7ed4a8b5a7f4c56f0a0d43b9d8c5a40d4c1f1e6ce2bea42b7ed0d1\n"),
+		"x25519-chunks.age": chunksPlain(),
+	}
+	for file, want := range vectors {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !manifest.IsEncrypted(data) {
+			t.Errorf("IsEncrypted() FAILED, %s", file)
+		}
+		got, err := decryptAll(data, id)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("Decrypt() FAILED, %s: %d bytes, %v", file, len(got), err)
+		}
+	}
+	t.Logf("AgeVectors() PASSED")
+}
+
+func TestAgeRoundTrip(t *testing.T) {
+	id := testKeys(t)
+	other, err := manifest.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stranger, err := manifest.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range []int{0, 1, ageChunk - 1, ageChunk, ageChunk + 1, 3 * ageChunk} {
+		plain := bytes.Repeat([]byte{'m'}, size)
+		data := encryptAll(t, plain, id.Recipient(), other.Recipient())
+
+		for _, key := range []*manifest.X25519Identity{id, other} {
+			got, err := decryptAll(data, stranger, key)
+			if err != nil || !bytes.Equal(got, plain) {
+				t.Errorf("Decrypt() FAILED, size %d: %d bytes, %v", size, len(got), err)
+			}
+		}
+		if _, err := decryptAll(data, stranger); err == nil {
+			t.Errorf("Decrypt() FAILED, size %d decrypted by other identity", size)
+		}
+	}
+
+	if _, err := manifest.Encrypt(&bytes.Buffer{}); err == nil {
+		t.Errorf("Encrypt() FAILED, no recipients accepted")
+	}
+	t.Logf("AgeRoundTrip() PASSED")
+}
+
+func TestAgeTamper(t *testing.T) {
+	id := testKeys(t)
+	data, err := ioutil.ReadFile("x25519-chunks.age")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := bytes.Index(data, []byte("\n--- ")) + 1
+	payload := bytes.IndexByte(data[header:], '\n') + header + 1 + 16
+
+	flip := func(at int) []byte {
+		c := append([]byte{}, data...)
+		c[at] ^= 1
+		return c
+	}
+	cases := map[string][]byte{
+		"truncated header":     data[:header],
+		"truncated nonce":      data[:payload-4],
+		"no payload":           data[:payload],
+		"missing last chunk":   data[:payload+ageChunk+16],
+		"truncated last chunk": data[:len(data)-1],
+		"trailing data":        append(append([]byte{}, data...), 0),
+		"changed stanza":       flip(len("age-encryption.org/v1\n-> X25519 ") + 60),
+		"changed mac":          flip(header + 10),
+		"changed first chunk":  flip(payload + 100),
+		"changed last chunk":   flip(len(data) - 20),
+		"changed nonce":        flip(payload - 1),
+		"changed version":      flip(len("age-encryption.org/v") + 1),
+		"added stanza":         append(append(append([]byte{}, data[:header]...), "-> X25519 x\n"...), data[header:]...),
+	}
+	for name, c := range cases {
+		if got, err := decryptAll(c, id); err == nil {
+			t.Errorf("Decrypt() FAILED, %s decrypted %d bytes", name, len(got))
+		}
+	}
+	t.Logf("AgeTamper() PASSED")
+}
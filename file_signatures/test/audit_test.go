@@ -0,0 +1,81 @@
+package manifest
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Records of audit event writing name, relative to cwd when not absolute
+func auditEvent(serial, stamp, exe, success, cwd, name string) string {
+	return "type=SYSCALL msg=audit(" + stamp + ":" + serial + "): arch=c000003e syscall=257 success=" + success +
+		" exit=3 pid=4182 uid=0 auid=4294967295 comm=\"vim\" exe=" + exe + " key=\"integrity\"\n" +
+		"type=CWD msg=audit(" + stamp + ":" + serial + "): cwd=\"" + cwd + "\"\n" +
+		"type=PATH msg=audit(" + stamp + ":" + serial + "): item=0 name=\"" + filepath.Dir(name) + "\" nametype=PARENT\n" +
+		"type=PATH msg=audit(" + stamp + ":" + serial + "): item=1 name=\"" + name + "\" nametype=CREATE\n"
+}
+
+// Interleaves records of two events, as written by concurrent processes
+func interleave(a, b string) string {
+	ra := strings.SplitAfter(a, "\n")
+	rb := strings.SplitAfter(b, "\n")
+
+	var sb strings.Builder
+	for i := range ra {
+		sb.WriteString(ra[i] + rb[i])
+	}
+
+	return sb.String()
+}
+
+func TestAuditLog(t *testing.T) {
+	dir := writeTree(t, map[string]string{"hosts": "hosts\n", "passwd": "passwd\n", "shadow": "shadow\n"})
+	defer os.RemoveAll(dir)
+
+	out, _, _ := runCommand(t, "-dest", dir, "-sign", "sha256")
+	m := filepath.Join(dir, "..", filepath.Base(dir)+".sha256")
+	ioutil.WriteFile(m, []byte(out), 0644)
+	defer os.Remove(m)
+
+	// Manifest is baseline of 2024-03-02T10:00:00Z
+	baseline := time.Unix(1709373600, 0)
+	os.Chtimes(m, baseline, baseline)
+	for _, name := range []string{"hosts", "passwd", "shadow"} {
+		ioutil.WriteFile(filepath.Join(dir, name), []byte("changed\n"), 0644)
+	}
+
+	// Last successful event wins, exe with space is hex encoded,
+	// enriched fields after group separator are dropped. Records of
+	// concurrent events are interleaved, events before baseline ignored.
+	vim := hex.EncodeToString([]byte("/usr/bin/vim basic"))
+	audit := auditEvent("40", "1709370000.000", `"/usr/bin/cp"`, "yes", "/", dir+"/shadow") +
+		auditEvent("41", "1709376000.100", `"/usr/bin/sed"`, "yes", "/", dir+"/hosts") +
+		interleave(auditEvent("42", "1709376067.250", vim, "yes", dir, "hosts"),
+			auditEvent("43", "1709376067.250", `"/usr/bin/rm"`, "no", "/", dir+"/passwd")) +
+		"type=EOE msg=audit(1709376067.250:42): \n" +
+		strings.TrimSuffix(auditEvent("44", "1709376200.000", `"/usr/bin/tee"`, "yes", "/", dir+"/passwd"), "\n") +
+		"\x1dARCH=x86_64 UID=\"root\"\n" +
+		"type=UNKNOWN garbage\n"
+	auditLog := filepath.Join(dir, "..", filepath.Base(dir)+".audit")
+	ioutil.WriteFile(auditLog, []byte(audit), 0644)
+	defer os.Remove(auditLog)
+
+	out, _, code := runCommand(t, "-verify", m, "-sign", "sha256", "-audit-log", auditLog)
+	for _, want := range []string{
+		dir + "/hosts: changed by /usr/bin/vim basic (pid 4182, uid root, auid unset) at 2024-03-02T10:41:07Z\n",
+		dir + "/passwd: changed by /usr/bin/tee (pid 4182, uid root, auid unset) at 2024-03-02T10:43:20Z\n",
+		dir + "/shadow: no audit event of change\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("AuditLog() FAILED, %q missing, exit %d\n%s", want, code, out)
+		}
+	}
+	if code != 1 {
+		t.Errorf("AuditLog() FAILED, exit %d", code)
+	}
+	t.Logf("AuditLog() PASSED")
+}
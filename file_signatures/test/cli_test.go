@@ -0,0 +1,71 @@
+package manifest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// Command of file_signatures, built once for tests of its options
+var command string
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "file_signatures")
+	if err != nil {
+		panic(err)
+	}
+
+	command = filepath.Join(dir, "file_signatures")
+	build := exec.Command("go", "build", "-o", command, "..")
+	build.Stdout, build.Stderr = os.Stderr, os.Stderr
+	if err := build.Run(); err != nil {
+		os.RemoveAll(dir)
+		panic(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// Runs command with args, returns stdout, stderr and exit status
+func runCommand(t *testing.T, args ...string) (string, string, int) {
+	cmd := exec.Command(command, args...)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	err := cmd.Run()
+	if exit, ok := err.(*exec.ExitError); ok {
+		return stdout.String(), stderr.String(), exit.Sys().(syscall.WaitStatus).ExitStatus()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return stdout.String(), stderr.String(), 0
+}
+
+// Writes files of relative paths and contents under new temporary
+// directory, returns the directory.
+func writeTree(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "file_signatures")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
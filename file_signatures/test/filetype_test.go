@@ -0,0 +1,68 @@
+package manifest
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Files of each type, by name
+var typedFiles = map[string]string{
+	"elf":     "\x7fELF\x02\x01\x01",
+	"script":  "#!/bin/sh\necho\n",
+	"archive": "\x1f\x8b\x08\x00",
+	"tar":     strings.Repeat("\x00", 257) + "ustar\x0000" + strings.Repeat("\x00", 250),
+	"image":   "\x89PNG\r\n\x1a\n\x00\x00",
+	"webp":    "RIFF\x10\x00\x00\x00WEBPVP8 ",
+	"media":   "\x00\x00\x00\x18ftypmp42",
+	"wav":     "RIFF\x10\x00\x00\x00WAVEfmt ",
+	"pdf":     "%PDF-1.7\n",
+	"text":    "plain text\n",
+	"cut":     strings.Repeat("a", 511) + "é",
+	"data":    "\x00\x01\x02\x03",
+	"empty":   "",
+}
+
+// Returns sorted names of files scanned under dir
+func scannedNames(dir, out string) []string {
+	var names []string
+	for path := range scanSums(out) {
+		names = append(names, strings.TrimPrefix(path, dir+"/"))
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func TestFileTypes(t *testing.T) {
+	dir := writeTree(t, typedFiles)
+	defer os.RemoveAll(dir)
+
+	for spec, want := range map[string]string{
+		"elf":                         "elf",
+		"script":                      "script",
+		"archive":                     "archive tar",
+		"image":                       "image webp",
+		"media":                       "media wav",
+		"pdf":                         "pdf",
+		"text":                        "cut text",
+		"data":                        "data",
+		"empty":                       "empty",
+		"elf, script":                 "elf script",
+		"!media,!image":               "archive cut data elf empty pdf script tar text",
+		"!text,!data,!empty,!archive": "elf image media pdf script wav webp",
+	} {
+		out, errs, code := runCommand(t, "-dest", dir, "-type", spec)
+		if got := strings.Join(scannedNames(dir, out), " "); code != 0 || got != want {
+			t.Errorf("FileTypes() FAILED, -type %s scanned %s, exit %d\n%s", spec, got, code, errs)
+		}
+	}
+
+	for _, spec := range []string{"elf,!media", "binary"} {
+		if out, _, _ := runCommand(t, "-dest", dir, "-type", spec); out != "" {
+			t.Errorf("FileTypes() FAILED, -type %s accepted\n%s", spec, out)
+		}
+	}
+	t.Logf("FileTypes() PASSED")
+}
@@ -0,0 +1,192 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/file_signatures/manifest"
+)
+
+const testSum = "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+
+// Manifest of digests only, writable in every format
+func testManifest() *manifest.Manifest {
+	return &manifest.Manifest{
+		Algo: "sha256",
+		Entries: []manifest.Entry{
+			{Path: "/data/app/a", Sum: testSum},
+			{Path: "/data/app/with space", Sum: testSum},
+			{Path: `/data/app/back\slash`, Sum: testSum},
+		},
+	}
+}
+
+// Paths and sums of entries, for comparing read manifests
+func pathSums(entries []manifest.Entry) []manifest.Entry {
+	out := []manifest.Entry{}
+	for _, e := range entries {
+		out = append(out, manifest.Entry{Path: e.Path, Sum: e.Sum})
+	}
+
+	return out
+}
+
+func readBack(t *testing.T, data []byte, pub ed25519.PublicKey) *manifest.Manifest {
+	m, malformed, err := manifest.Read(bytes.NewReader(data), pub)
+	if err != nil || malformed != 0 {
+		t.Fatalf("Read() FAILED, %d malformed, %v\n%s", malformed, err, data)
+	}
+
+	return m
+}
+
+func TestWriteRead(t *testing.T) {
+	want := testManifest()
+
+	for _, format := range []string{manifest.FormatPlain, manifest.FormatCoreutils, manifest.FormatBSD, manifest.FormatJSON} {
+		buf := &bytes.Buffer{}
+		if err := manifest.Write(buf, want, format); err != nil {
+			t.Fatalf("Write() FAILED, %s: %v", format, err)
+		}
+
+		got := readBack(t, buf.Bytes(), nil)
+		if !reflect.DeepEqual(pathSums(got.Entries), pathSums(want.Entries)) {
+			t.Errorf("Write() FAILED, %s read back as %+v", format, got.Entries)
+		}
+
+		// CRLF endings of manifests copied from other hosts
+		crlf := bytes.Replace(buf.Bytes(), []byte("\n"), []byte("\r\n"), -1)
+		got = readBack(t, crlf, nil)
+		if !reflect.DeepEqual(pathSums(got.Entries), pathSums(want.Entries)) {
+			t.Errorf("Read() FAILED, %s with CRLF read as %+v", format, got.Entries)
+		}
+	}
+	t.Logf("WriteRead() PASSED")
+}
+
+func TestCoreutilsEscape(t *testing.T) {
+	m := &manifest.Manifest{Entries: []manifest.Entry{
+		{Path: "/tmp/new\nline", Sum: testSum},
+		{Path: `/tmp/back\slash`, Sum: testSum},
+		{Path: `/tmp/both\` + "\n", Sum: testSum},
+	}}
+
+	buf := &bytes.Buffer{}
+	if err := manifest.Write(buf, m, manifest.FormatCoreutils); err != nil {
+		t.Fatal(err)
+	}
+	want := `\` + testSum + `  /tmp/new\nline` + "\n" +
+		`\` + testSum + `  /tmp/back\\slash` + "\n" +
+		`\` + testSum + `  /tmp/both\\\n` + "\n"
+	if buf.String() != want {
+		t.Errorf("Write() FAILED, coreutils escaped as\n%s", buf.String())
+	}
+
+	got := readBack(t, buf.Bytes(), nil)
+	if !reflect.DeepEqual(pathSums(got.Entries), m.Entries) {
+		t.Errorf("Read() FAILED, escaped names read as %q", got.Entries)
+	}
+
+	// Binary-mode marker of sha256sum -b
+	e, ok := manifest.ParseLine(testSum + " */tmp/a")
+	if !ok || e.Path != "/tmp/a" {
+		t.Errorf("ParseLine() FAILED, binary marker parsed as %+v", e)
+	}
+
+	for _, format := range []string{manifest.FormatPlain, manifest.FormatBSD} {
+		if err := manifest.Write(&bytes.Buffer{}, m, format); err == nil {
+			t.Errorf("Write() FAILED, newline of name written in %s", format)
+		}
+	}
+	t.Logf("CoreutilsEscape() PASSED")
+}
+
+func TestBSDAlgo(t *testing.T) {
+	m := &manifest.Manifest{Algo: "md5", Entries: []manifest.Entry{
+		{Path: "/tmp/a", Sum: "d41d8cd98f00b204e9800998ecf8427e"},
+		{Path: "/tmp/b", Sum: "0a1b2c3d", Algo: "crc"},
+	}}
+
+	buf := &bytes.Buffer{}
+	if err := manifest.Write(buf, m, manifest.FormatBSD); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "MD5 (/tmp/a) = d41d8cd98f00b204e9800998ecf8427e\nCRC32 (/tmp/b) = 0a1b2c3d\n" {
+		t.Errorf("Write() FAILED, bsd written as\n%s", buf.String())
+	}
+
+	got := readBack(t, buf.Bytes(), nil)
+	if got.Entries[0].Algo != "md5" || got.Entries[1].Algo != "crc" {
+		t.Errorf("Read() FAILED, algorithms read as %+v", got.Entries)
+	}
+
+	if err := manifest.Write(&bytes.Buffer{}, &manifest.Manifest{Entries: m.Entries[:1]}, manifest.FormatBSD); err == nil {
+		t.Errorf("Write() FAILED, bsd written without algorithm")
+	}
+	t.Logf("BSDAlgo() PASSED")
+}
+
+func TestTokens(t *testing.T) {
+	m := &manifest.Manifest{Entries: []manifest.Entry{
+		{Path: "/var/log/syslog", Sum: manifest.TokenExists},
+		{Path: "/etc/shadow", Sum: manifest.TokenMode + "0640"},
+	}}
+
+	for _, format := range []string{manifest.FormatPlain, manifest.FormatJSON} {
+		buf := &bytes.Buffer{}
+		if err := manifest.Write(buf, m, format); err != nil {
+			t.Fatal(err)
+		}
+		got := readBack(t, buf.Bytes(), nil)
+		if !reflect.DeepEqual(pathSums(got.Entries), m.Entries) {
+			t.Errorf("Read() FAILED, tokens of %s read as %+v", format, got.Entries)
+		}
+	}
+
+	for _, format := range []string{manifest.FormatCoreutils, manifest.FormatBSD} {
+		if err := manifest.Write(&bytes.Buffer{}, m, format); err == nil {
+			t.Errorf("Write() FAILED, token written in %s", format)
+		}
+	}
+	t.Logf("Tokens() PASSED")
+}
+
+func TestSigned(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	want := testManifest()
+
+	buf := &bytes.Buffer{}
+	if err := manifest.WriteSigned(buf, want, priv); err != nil {
+		t.Fatal(err)
+	}
+
+	got := readBack(t, buf.Bytes(), pub)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WriteSigned() FAILED, read back as %+v", got)
+	}
+
+	if _, _, err := manifest.Read(bytes.NewReader(buf.Bytes()), nil); err == nil {
+		t.Errorf("Read() FAILED, signed manifest read without public key")
+	}
+	if _, _, err := manifest.Read(bytes.NewReader(buf.Bytes()), other); err == nil {
+		t.Errorf("Read() FAILED, signed manifest verified with other key")
+	}
+
+	tampered := strings.Replace(buf.String(), "/data/app/a", "/data/app/b", 1)
+	if _, _, err := manifest.Read(strings.NewReader(tampered), pub); err == nil {
+		t.Errorf("Read() FAILED, changed signed manifest verified")
+	}
+
+	if err := manifest.Write(&bytes.Buffer{}, want, manifest.FormatSigned); err == nil {
+		t.Errorf("Write() FAILED, signed written without key")
+	}
+	t.Logf("Signed() PASSED")
+}
@@ -0,0 +1,67 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	old := strings.Repeat("a", 64)
+	cur := strings.Repeat("b", 64)
+	dir := writeTree(t, map[string]string{"a": "a\n", "b": "b\n"})
+	defer os.RemoveAll(dir)
+
+	full := filepath.Join(dir, "full.sha256")
+	rescan := filepath.Join(dir, "rescan.sha256")
+	ioutil.WriteFile(full, []byte(
+		dir+"/b :: "+old+"\n"+
+			dir+"/a :: "+old+"\n"+
+			dir+"/gone :: "+old+"\n"+
+			dir+"/ :: "+old+"\n"), 0644)
+	ioutil.WriteFile(rescan, []byte(cur+"  "+dir+"/b\n"), 0644)
+
+	// Output replaces first input, later input wins
+	_, errs, code := runCommand(t, "-merge", full, "-sign", "sha256", full, rescan)
+	data, _ := ioutil.ReadFile(full)
+	want := dir + "/a :: " + old + "\n" + dir + "/b :: " + cur + "\n" + dir + "/gone :: " + old + "\n"
+	if code != 0 || string(data) != want {
+		t.Fatalf("Merge() FAILED, exit %d, merged\n%s%s", code, data, errs)
+	}
+	if !strings.Contains(errs, "1 digests of directories dropped") {
+		t.Errorf("Merge() FAILED, rollup not dropped\n%s", errs)
+	}
+
+	_, _, code = runCommand(t, "-merge", full, "-sign", "sha256", "-prune", full)
+	data, _ = ioutil.ReadFile(full)
+	if code != 0 || strings.Contains(string(data), "/gone") || len(strings.Split(string(data), "\n")) != 3 {
+		t.Errorf("Merge() FAILED, pruned to\n%s", data)
+	}
+
+	bsd := filepath.Join(dir, "bsd.md5")
+	ioutil.WriteFile(bsd, []byte("MD5 ("+dir+"/a) = "+strings.Repeat("c", 32)+"\n"), 0644)
+	before, _ := ioutil.ReadFile(full)
+	if _, _, code := runCommand(t, "-merge", full, "-sign", "sha256", full, bsd); code != 1 {
+		t.Errorf("Merge() FAILED, md5 merged into sha256 manifest")
+	}
+	if after, _ := ioutil.ReadFile(full); string(after) != string(before) {
+		t.Errorf("Merge() FAILED, output changed by failed merge")
+	}
+
+	out := filepath.Join(dir, "merged.json")
+	if _, errs, code := runCommand(t, "-merge", out, "-merge-format", "json", "-sign", "sha256", full); code != 0 {
+		t.Fatalf("Merge() FAILED, json not written\n%s", errs)
+	}
+	data, _ = ioutil.ReadFile(out)
+	if !strings.Contains(string(data), `"algorithm": "sha256"`) || !strings.Contains(string(data), `"path": "`+dir+`/b"`) {
+		t.Errorf("Merge() FAILED, json written as\n%s", data)
+	}
+
+	if _, errs, code := runCommand(t, "-merge", out, "-merge-format", "signed", "-sign", "sha256", full); code != 1 ||
+		!strings.Contains(errs, "-signing-key") {
+		t.Errorf("Merge() FAILED, signed written without key\n%s", errs)
+	}
+	t.Logf("Merge() PASSED")
+}
@@ -0,0 +1,85 @@
+package manifest
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/file_signatures/manifest"
+)
+
+// Database of dpkg on host
+const dpkgInfo = "/var/lib/dpkg/info"
+
+// Returns package of host with all files of md5sums unchanged,
+// and its entries.
+func unchangedPackage(t *testing.T) (string, []manifest.Entry) {
+	lists, _ := filepath.Glob(filepath.Join(dpkgInfo, "*.md5sums"))
+	for _, list := range lists {
+		f, err := os.Open(list)
+		if err != nil {
+			continue
+		}
+		entries, malformed, err := manifest.Parse(f)
+		f.Close()
+		if err != nil || malformed > 0 || len(entries) < 2 || len(entries) > 20 {
+			continue
+		}
+
+		unchanged := true
+		for _, e := range entries {
+			data, err := ioutil.ReadFile("/" + e.Path)
+			sum := md5.Sum(data)
+			if err != nil || hex.EncodeToString(sum[:]) != e.Sum {
+				unchanged = false
+				break
+			}
+		}
+		if unchanged {
+			return strings.TrimSuffix(filepath.Base(list), ".md5sums"), entries
+		}
+	}
+
+	t.Skip("No package of dpkg with unchanged files")
+	return "", nil
+}
+
+func TestVerifyPackages(t *testing.T) {
+	if _, err := os.Stat(dpkgInfo); err != nil {
+		t.Skip("Database of dpkg not on host")
+	}
+	pkg, entries := unchangedPackage(t)
+
+	// Image of host with files of one package only
+	root, err := ioutil.TempDir("", "file_signatures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	for _, e := range entries {
+		data, _ := ioutil.ReadFile("/" + e.Path)
+		path := filepath.Join(root, e.Path)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, _, code := runCommand(t, "-verify-packages", "-base-dir", "/="+root)
+	if code != 1 || strings.Contains(out, "(package "+pkg+")") ||
+		!strings.Contains(out, ": FAILED open or read (package ") {
+		t.Fatalf("VerifyPackages() FAILED, exit %d, files of %s failed", code, pkg)
+	}
+
+	changed := "/" + entries[0].Path
+	ioutil.WriteFile(filepath.Join(root, changed), []byte("changed\n"), 0644)
+	out, _, _ = runCommand(t, "-verify-packages", "-base-dir", "/="+root)
+	if !strings.Contains(out, changed+": FAILED (package "+pkg+")\n") {
+		t.Errorf("VerifyPackages() FAILED, changed %s not reported", changed)
+	}
+	t.Logf("VerifyPackages() PASSED, %s", pkg)
+}
@@ -0,0 +1,81 @@
+package manifest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/file_signatures/manifest"
+)
+
+func TestParseLine(t *testing.T) {
+	md5 := "d41d8cd98f00b204e9800998ecf8427e"
+
+	cases := []struct {
+		line string
+		want manifest.Entry
+	}{
+		{"/etc/hosts :: " + md5, manifest.Entry{Path: "/etc/hosts", Sum: md5}},
+		{"/etc/hosts\t::\t" + "D41D8CD98F00B204E9800998ECF8427E", manifest.Entry{Path: "/etc/hosts", Sum: md5}},
+		{"/etc/a::b :: " + md5, manifest.Entry{Path: "/etc/a::b", Sum: md5}},
+		{"/etc/hosts::" + md5, manifest.Entry{Path: "/etc/hosts", Sum: md5}},
+		{"/var/log/syslog :: exists", manifest.Entry{Path: "/var/log/syslog", Sum: manifest.TokenExists}},
+		{"/etc/shadow :: mode=0640", manifest.Entry{Path: "/etc/shadow", Sum: "mode=0640"}},
+
+		// Coreutils names with :: and digest after it
+		{md5 + "  name::abc123", manifest.Entry{Path: "name::abc123", Sum: md5}},
+		{md5 + " *dir/name::" + md5, manifest.Entry{Path: "dir/name::" + md5, Sum: md5}},
+		{md5 + "  name :: x", manifest.Entry{Path: "name :: x", Sum: md5}},
+		{md5 + "  with space", manifest.Entry{Path: "with space", Sum: md5}},
+
+		{"MD5 (a::b) = " + md5, manifest.Entry{Path: "a::b", Sum: md5, Algo: "md5"}},
+		{"SHA256 (with space) = " + md5, manifest.Entry{Path: "with space", Sum: md5, Algo: "sha256"}},
+		{"CRC (a) = 0a1b2c3d", manifest.Entry{Path: "a", Sum: "0a1b2c3d", Algo: "crc"}},
+	}
+	for _, c := range cases {
+		e, ok := manifest.ParseLine(c.line)
+		if !ok || e != c.want {
+			t.Errorf("ParseLine() FAILED, %q parsed as %+v, %v", c.line, e, ok)
+		}
+	}
+
+	for _, line := range []string{
+		":: " + md5,
+		"/etc/hosts :: ",
+		"/etc/hosts :: not-a-sum",
+		"/etc/hosts :: " + md5 + " trailing",
+		"SHA1 (a) = " + md5,
+		"no sum here",
+	} {
+		if e, ok := manifest.ParseLine(line); ok {
+			t.Errorf("ParseLine() FAILED, %q parsed as %+v", line, e)
+		}
+	}
+	t.Logf("ParseLine() PASSED")
+}
+
+func TestParse(t *testing.T) {
+	f, err := os.Open("mixed.manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	entries, malformed, err := manifest.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if malformed != 1 || len(entries) != 4 {
+		t.Fatalf("Parse() FAILED, %d malformed, %+v", malformed, entries)
+	}
+
+	want := []string{"/data/app/a", "b::c", "c", "d e"}
+	for i, e := range entries {
+		if e.Path != want[i] {
+			t.Errorf("Parse() FAILED, entry %d is %q", i, e.Path)
+		}
+	}
+	if entries[0].Line != 3 || entries[3].Line != 7 {
+		t.Errorf("Parse() FAILED, lines %d and %d", entries[0].Line, entries[3].Line)
+	}
+	t.Logf("Parse() PASSED")
+}
@@ -0,0 +1,53 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"app/a":     "a\n",
+		"log/x.log": "log\n",
+		"cache/c":   "cache\n",
+	})
+	defer os.RemoveAll(dir)
+
+	pol := filepath.Join(dir, "policy.yaml")
+	ioutil.WriteFile(pol, []byte("volatile:\n"+
+		"  - path: "+dir+"/log/*.log\n    track: metadata\n"+
+		"  - path: "+dir+"/cache\n    track: ignore\n"+
+		"  - path: "+dir+"/policy.yaml\n    track: exists\n"), 0644)
+
+	out, _, code := runCommand(t, "-dest", dir, "-policy", pol, "-sign", "sha256", "-ordered")
+	want := dir + "/app/a :: 87428fc522803d31065e7bce3cf03fe475096631e5e07bbd7a0fde60c4cf25c7\n" +
+		dir + "/log/x.log :: mode=0644\n" +
+		dir + "/policy.yaml :: exists\n"
+	if code != 0 || out != want {
+		t.Fatalf("Policy() FAILED, exit %d, scanned\n%s", code, out)
+	}
+	m := filepath.Join(os.TempDir(), filepath.Base(dir)+".manifest")
+	ioutil.WriteFile(m, []byte(out), 0644)
+	defer os.Remove(m)
+
+	// Content of volatile file changes, its permissions don't
+	ioutil.WriteFile(filepath.Join(dir, "log/x.log"), []byte("more log\n"), 0644)
+	if out, _, code := runCommand(t, "-verify", m, "-policy", pol, "-sign", "sha256"); code != 0 {
+		t.Errorf("Policy() FAILED, changed log failed verify\n%s", out)
+	}
+
+	os.Chmod(filepath.Join(dir, "log/x.log"), 0600)
+	out, _, code = runCommand(t, "-verify", m, "-policy", pol, "-sign", "sha256")
+	if code != 1 || !strings.Contains(out, "x.log: FAILED permissions 0600, expected 0644") {
+		t.Errorf("Policy() FAILED, changed permissions verified, exit %d\n%s", code, out)
+	}
+
+	ioutil.WriteFile(pol, []byte("volatile:\n  - path: /var/log\n    track: sometimes\n"), 0644)
+	if _, errs, _ := runCommand(t, "-dest", dir, "-policy", pol); !strings.Contains(errs, "Track sometimes of /var/log not supported.") {
+		t.Errorf("Policy() FAILED, invalid track accepted\n%s", errs)
+	}
+	t.Logf("Policy() PASSED")
+}
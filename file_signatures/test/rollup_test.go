@@ -0,0 +1,67 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// Returns sums of scan output by path
+func scanSums(out string) map[string]string {
+	sums := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if i := strings.LastIndex(line, " :: "); i > 0 {
+			sums[line[:i]] = line[i+4:]
+		}
+	}
+
+	return sums
+}
+
+func TestRollup(t *testing.T) {
+	dir := writeTree(t, map[string]string{"a": "a\n", "sub/b": "b\n", "other/c": "c\n"})
+	defer os.RemoveAll(dir)
+
+	out, _, code := runCommand(t, "-dest", dir, "-sign", "sha256", "-rollup")
+	sums := scanSums(out)
+	sub := sha256Hex("b :: " + sha256Hex("b\n") + "\n")
+	other := sha256Hex("c :: " + sha256Hex("c\n") + "\n")
+	root := sha256Hex("a :: " + sha256Hex("a\n") + "\nother/ :: " + other + "\nsub/ :: " + sub + "\n")
+	if code != 0 || len(sums) != 6 || sums[dir+"/sub/"] != sub || sums[dir+"/other/"] != other || sums[dir+"/"] != root {
+		t.Fatalf("Rollup() FAILED, exit %d, scanned\n%s", code, out)
+	}
+
+	// Rollups are last, sorted by path
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if !strings.HasPrefix(lines[3], dir+"/ :: ") || !strings.HasPrefix(lines[5], dir+"/sub/ :: ") {
+		t.Errorf("Rollup() FAILED, order of rollups\n%s", out)
+	}
+
+	m := filepath.Join(dir, "..", filepath.Base(dir)+".sha256")
+	ioutil.WriteFile(m, []byte(out), 0644)
+	defer os.Remove(m)
+	if out, _, code := runCommand(t, "-verify", m, "-sign", "sha256"); code != 0 || strings.Count(out, ": OK") != 3 {
+		t.Errorf("Rollup() FAILED, verify of rollups, exit %d\n%s", code, out)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "sub/b"), []byte("changed\n"), 0644)
+	out, _, _ = runCommand(t, "-dest", dir, "-sign", "sha256", "-rollup")
+	changed := scanSums(out)
+	if changed[dir+"/sub/"] == sub || changed[dir+"/"] == root || changed[dir+"/other/"] != other {
+		t.Errorf("Rollup() FAILED, digests after change\n%s", out)
+	}
+
+	if _, errs, _ := runCommand(t, "-dest", dir, "-rollup", "-sample", "50%"); !strings.Contains(errs, "rollup can't be sampled") {
+		t.Errorf("Rollup() FAILED, sampled rollup\n%s", errs)
+	}
+	t.Logf("Rollup() PASSED")
+}
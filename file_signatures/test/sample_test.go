@@ -0,0 +1,101 @@
+package manifest
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Returns true if path is selected in sample of rate and seed,
+// by fnv-1a of seed, NUL and path.
+func sampled(seed, path string, rate float64) bool {
+	h := fnv.New64a()
+	h.Write([]byte(seed + "\x00" + path))
+	return h.Sum64()%1000000 < uint64(rate*1000000)
+}
+
+func TestSample(t *testing.T) {
+	files := make(map[string]string)
+	for i := 0; i < 200; i++ {
+		files[fmt.Sprintf("f%03d", i)] = fmt.Sprintf("%d\n", i)
+	}
+	dir := writeTree(t, files)
+	defer os.RemoveAll(dir)
+
+	var want []string
+	for name := range files {
+		if sampled("nightly", dir+"/"+name, 0.25) {
+			want = append(want, name)
+		}
+	}
+	sort.Strings(want)
+
+	out, _, code := runCommand(t, "-dest", dir, "-sample", "25%", "-sample-seed", "nightly")
+	got := scannedNames(dir, out)
+	if code != 0 || strings.Join(got, " ") != strings.Join(want, " ") || len(got) < 25 || len(got) > 75 {
+		t.Errorf("Sample() FAILED, sampled %d files, expected %d", len(got), len(want))
+	}
+
+	again, _, _ := runCommand(t, "-dest", dir, "-sample", "0.25", "-sample-seed", "nightly")
+	other, _, _ := runCommand(t, "-dest", dir, "-sample", "25%", "-sample-seed", "weekly")
+	if strings.Join(scannedNames(dir, again), " ") != strings.Join(got, " ") ||
+		strings.Join(scannedNames(dir, other), " ") == strings.Join(got, " ") {
+		t.Errorf("Sample() FAILED, seeds select same or different files")
+	}
+
+	all, _, _ := runCommand(t, "-dest", dir, "-sample", "100%")
+	if len(scanSums(all)) != 200 {
+		t.Errorf("Sample() FAILED, 100%% sampled %d files", len(scanSums(all)))
+	}
+
+	for _, rate := range []string{"0%", "150%", "some"} {
+		if out, errs, _ := runCommand(t, "-dest", dir, "-sample", rate); out != "" || !strings.Contains(errs, "Sample "+rate) {
+			t.Errorf("Sample() FAILED, rate %s accepted\n%s", rate, errs)
+		}
+	}
+
+	// Summary records sample
+	out, _, _ = runCommand(t, "-dest", dir, "-sample", "25%", "-sample-seed", "nightly", "-summary")
+	if !strings.Contains(out, `"sample":"25%","sampleSeed":"nightly"`) {
+		t.Errorf("Sample() FAILED, summary of sample\n%s", out)
+	}
+	t.Logf("Sample() PASSED")
+}
+
+func TestSampleVerify(t *testing.T) {
+	files := make(map[string]string)
+	for i := 0; i < 200; i++ {
+		files[fmt.Sprintf("f%03d", i)] = fmt.Sprintf("%d\n", i)
+	}
+	dir := writeTree(t, files)
+	defer os.RemoveAll(dir)
+
+	out, _, _ := runCommand(t, "-dest", dir)
+	m := filepath.Join(dir, "..", filepath.Base(dir)+".md5")
+	ioutil.WriteFile(m, []byte(out), 0644)
+	defer os.Remove(m)
+
+	selected := 0
+	for name := range files {
+		if sampled("file_signatures", dir+"/"+name, 0.5) {
+			selected++
+		}
+	}
+
+	// Half of files changed, estimate is of whole manifest
+	for i := 0; i < 200; i += 2 {
+		ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("f%03d", i)), []byte("changed\n"), 0644)
+	}
+	out, errs, code := runCommand(t, "-verify", m, "-sample", "50%")
+	checked := strings.Count(out, ": OK") + strings.Count(out, ": FAILED")
+	if code != 1 || checked != selected ||
+		!strings.Contains(errs, fmt.Sprintf("Sampled %d of 200 files at 50%%, estimated ", selected)) {
+		t.Errorf("SampleVerify() FAILED, exit %d, %d of %d checked\n%s", code, checked, selected, errs)
+	}
+	t.Logf("SampleVerify() PASSED")
+}
@@ -0,0 +1,136 @@
+package manifest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Envelope posted to http sink
+type posted struct {
+	Tool    string          `json:"tool"`
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Payload of checksums posted to http sink
+type postedChecksums struct {
+	Root  string `json:"root"`
+	Files []struct {
+		Path string `json:"path"`
+		Size int64  `json:"size"`
+	} `json:"files"`
+}
+
+func TestFileSink(t *testing.T) {
+	dir := writeTree(t, map[string]string{"a": "a\n", "b/c": "c\n"})
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "..", filepath.Base(dir)+".md5")
+	defer os.Remove(file)
+	out, _, code := runCommand(t, "-dest", dir, "-ordered", "-sink", "stdout,file:"+file)
+	data, _ := ioutil.ReadFile(file)
+	if code != 0 || out == "" || string(data) != out {
+		t.Errorf("FileSink() FAILED, exit %d, file\n%s\nstdout\n%s", code, data, out)
+	}
+
+	if _, errs, _ := runCommand(t, "-dest", dir, "-sink", "ftp://host"); !strings.Contains(errs, "Sink ftp://host not supported") {
+		t.Errorf("FileSink() FAILED, ftp sink accepted\n%s", errs)
+	}
+	t.Logf("FileSink() PASSED")
+}
+
+func TestHTTPSink(t *testing.T) {
+	dir := writeTree(t, map[string]string{"a": "a\n", "b": "bb\n", "c": "ccc\n"})
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	var envelopes []posted
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests++
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		// First post fails and is retried
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var e posted
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		envelopes = append(envelopes, e)
+	}))
+	defer server.Close()
+
+	_, errs, code := runCommand(t, "-dest", dir, "-sink", server.URL, "-sink-batch", "2",
+		"-sink-token", "secret", "-summary")
+	if code != 0 {
+		t.Fatalf("HTTPSink() FAILED, exit %d\n%s", code, errs)
+	}
+
+	files := 0
+	kinds := []string{}
+	for _, e := range envelopes {
+		kinds = append(kinds, e.Kind)
+		var sums postedChecksums
+		if e.Kind == "checksums" && e.Tool == "file_signatures" && json.Unmarshal(e.Payload, &sums) == nil && sums.Root == dir {
+			files += len(sums.Files)
+		}
+	}
+	if requests != 4 || files != 3 || strings.Join(kinds, ",") != "checksums,checksums,summary" {
+		t.Errorf("HTTPSink() FAILED, %d requests, %d files, posted %v", requests, files, kinds)
+	}
+
+	// Refused posts are not retried, exit status is 1
+	mu.Lock()
+	requests = 0
+	mu.Unlock()
+	_, _, code = runCommand(t, "-dest", dir, "-sink", server.URL, "-sink-token", "wrong")
+	mu.Lock()
+	defer mu.Unlock()
+	if code != 1 || requests != 1 {
+		t.Errorf("HTTPSink() FAILED, refused post of %d requests exited %d", requests, code)
+	}
+	t.Logf("HTTPSink() PASSED")
+}
+
+func TestSyslogSink(t *testing.T) {
+	dir := writeTree(t, map[string]string{"a": "a\n"})
+	defer os.RemoveAll(dir)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, errs, code := runCommand(t, "-dest", dir, "-sink", "syslog://"+conn.LocalAddr().String()); code != 0 {
+		t.Fatalf("SyslogSink() FAILED, exit %d\n%s", code, errs)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 2048)
+	n, _, err := conn.ReadFrom(buf)
+	msg := string(buf[:n])
+	if err != nil || !strings.Contains(msg, "file_signatures") ||
+		!strings.HasSuffix(strings.TrimSpace(msg), dir+"/a :: 60b725f10c9c85c70d97880dfe8191b3") {
+		t.Errorf("SyslogSink() FAILED, received %q, %v", msg, err)
+	}
+	t.Logf("SyslogSink() PASSED")
+}
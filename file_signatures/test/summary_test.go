@@ -0,0 +1,48 @@
+package manifest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/file_signatures/manifest"
+)
+
+func TestSummaryTrailer(t *testing.T) {
+	dir := writeTree(t, map[string]string{"a": "a\n", "b": "b\n"})
+	defer os.RemoveAll(dir)
+
+	out, _, code := runCommand(t, "-dest", dir, "-summary")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if code != 0 || len(lines) != 3 || !manifest.IsEnvelope(lines[2]) {
+		t.Fatalf("SummaryTrailer() FAILED, exit %d, scanned\n%s", code, out)
+	}
+
+	// Summary line is not a malformed entry
+	m := filepath.Join(dir, "..", filepath.Base(dir)+".md5")
+	ioutil.WriteFile(m, []byte(out), 0644)
+	defer os.Remove(m)
+	vout, errs, code := runCommand(t, "-verify", m)
+	if code != 0 || strings.Count(vout, ": OK") != 2 || strings.Contains(errs, "improperly formatted") {
+		t.Errorf("SummaryTrailer() FAILED, exit %d, verified\n%s%s", code, vout, errs)
+	}
+
+	// Scan of no files is summary only, still a line manifest
+	empty := writeTree(t, nil)
+	defer os.RemoveAll(empty)
+	out, _, _ = runCommand(t, "-dest", empty, "-summary")
+	got, malformed, err := manifest.Read(bytes.NewReader([]byte(out)), nil)
+	if err != nil || malformed != 0 || len(got.Entries) != 0 {
+		t.Errorf("Read() FAILED, summary only read as %+v, %d, %v", got, malformed, err)
+	}
+
+	for _, line := range []string{`{"tool":"x"}`, `{weird} :: ` + strings.Repeat("a", 32), "{"} {
+		if manifest.IsEnvelope(line) {
+			t.Errorf("IsEnvelope() FAILED, %s", line)
+		}
+	}
+	t.Logf("SummaryTrailer() PASSED")
+}
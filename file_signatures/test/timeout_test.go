@@ -0,0 +1,62 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	hasher "github.com/prashant-sb/go-utils/file_signatures/hash"
+)
+
+func TestWithTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	blocked := func(path string) (string, error) {
+		<-release
+		return "sum", nil
+	}
+
+	start := time.Now()
+	sum, err := hasher.WithTimeout(blocked, 50*time.Millisecond)("/mnt/nfs/file")
+	if elapsed := time.Since(start); !errors.Is(err, hasher.ErrTimeout) || sum != "" || elapsed > time.Second {
+		t.Errorf("WithTimeout() FAILED, stuck read returned %q, %v after %s", sum, err, elapsed)
+	}
+
+	// Completed reads and disabled timeout pass through
+	read := func(path string) (string, error) { return "sum of " + path, nil }
+	for _, timeout := range []time.Duration{time.Second, 0} {
+		if sum, err := hasher.WithTimeout(read, timeout)("a"); err != nil || sum != "sum of a" {
+			t.Errorf("WithTimeout() FAILED, timeout %s returned %q, %v", timeout, sum, err)
+		}
+	}
+	t.Logf("WithTimeout() PASSED")
+}
+
+func TestFileTimeout(t *testing.T) {
+	dir := writeTree(t, map[string]string{"a": "a\n", "b": "b\n"})
+	defer os.RemoveAll(dir)
+
+	// Open of fifo blocks until writer connects
+	if err := syscall.Mkfifo(filepath.Join(dir, "fifo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	out, errs, _ := runCommand(t, "-dest", dir, "-file-timeout", "100ms", "-summary")
+	sums := scanSums(out)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("FileTimeout() FAILED, scan took %s", elapsed)
+	}
+	if len(sums) != 2 || sums[dir+"/a"] == "" || sums[dir+"/b"] == "" || sums[dir+"/fifo"] != "" {
+		t.Errorf("FileTimeout() FAILED, scanned\n%s", out)
+	}
+	if !strings.Contains(errs, "Read of "+dir+"/fifo is stuck, timed out after 100ms") ||
+		!strings.Contains(out, `"timeout":1`) {
+		t.Errorf("FileTimeout() FAILED, fifo not failed\n%s\n%s", errs, out)
+	}
+	t.Logf("FileTimeout() PASSED")
+}
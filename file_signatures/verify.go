@@ -0,0 +1,200 @@
+package main
+
+// Verification of files with checksum manifests
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	hasher "github.com/prashant-sb/go-utils/file_signatures/hash"
+	"github.com/prashant-sb/go-utils/file_signatures/manifest"
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/pool"
+)
+
+// Result of verified entry
+type verifyResult struct {
+	entry  manifest.Entry
+	path   string // Local file of entry
+	ok     bool
+	detail string // Reason of failure
+}
+
+// Returns function mapping manifest paths to local files. Base is either
+// the directory relative paths are resolved against, or old=new which
+// also rewrites absolute paths under old to new.
+func remapper(base string) func(string) string {
+	oldPrefix, newBase := "", base
+	if i := strings.Index(base, "="); i >= 0 {
+		oldPrefix, newBase = filepath.Clean(base[:i]), base[i+1:]
+	}
+
+	return func(path string) string {
+		path = filepath.FromSlash(path)
+		switch {
+		case filepath.IsAbs(path):
+			if oldPrefix == "" {
+				return path
+			}
+			rel, err := filepath.Rel(oldPrefix, path)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				return path
+			}
+			return filepath.Join(newBase, rel)
+
+		case newBase != "":
+			return filepath.Join(newBase, path)
+		}
+
+		return path
+	}
+}
+
+// Verifies files of manifest, prints OK / FAILED for each file
+// as coreutils does. Volatile files of policies are verified by
+// existence and permissions. Changes of failed files are attributed
+// with events of auditLog when set, since the manifest was written.
+// Returns false if any file failed.
+func verifyManifest(file, base, algo string, workers int, timeout time.Duration,
+	pol *policies, keys *manifestKeys, sample *sampler, auditLog string) bool {
+	entries, err := readManifest(file, keys)
+	if err != nil {
+		log.Error("Error in reading manifest: ", err)
+		return false
+	}
+
+	var src *auditSource
+	if auditLog != "" {
+		src = &auditSource{file: auditLog}
+		if info, err := os.Stat(file); err == nil {
+			src.since = info.ModTime()
+		}
+	}
+
+	return verifyEntries(entries, remapper(base), algo, workers, timeout, pol, sample, false, src)
+}
+
+// Verifies files of entries with algorithm of entry or algo, prints
+// OK / FAILED for each file, failures only when quiet. Only files of
+// sample are verified when set, with estimate of changed files. Failed
+// files are attributed with events of audit when set. Returns false
+// if any file failed.
+func verifyEntries(entries []manifest.Entry, remap func(string) string, algo string,
+	workers int, timeout time.Duration, pol *policies, sample *sampler, quiet bool, audit *auditSource) bool {
+
+	var failed []string
+	p := pool.NewPool(context.Background(), pool.Options{
+		Workers: workers,
+		Ordered: true,
+		OnResult: func(r pool.Result) {
+			vr, _ := r.Value.(verifyResult)
+			owner := ""
+			if vr.entry.Package != "" {
+				owner = " (package " + vr.entry.Package + ")"
+			}
+			if r.Err != nil {
+				failed = append(failed, vr.path)
+				fmt.Printf("%s: FAILED open or read%s\n", vr.entry.Path, owner)
+				log.Debug("Error in verifying ", vr.entry.Path, ": ", r.Err)
+				return
+			}
+			if !vr.ok {
+				failed = append(failed, vr.path)
+				fmt.Printf("%s: FAILED%s%s\n", vr.entry.Path, vr.detail, owner)
+				return
+			}
+			if !quiet {
+				fmt.Printf("%s: OK\n", vr.entry.Path)
+			}
+		},
+	})
+
+	verified, population := 0, 0
+	for _, e := range entries {
+		e := e
+		// Directories are verified through their files
+		if isRollup(e) {
+			continue
+		}
+		entryAlgo := algo
+		if e.Algo != "" {
+			entryAlgo = e.Algo
+		}
+
+		path := remap(e.Path)
+		track := pol.track(path)
+		if track == trackIgnore {
+			continue
+		}
+		population++
+		if !sample.selects(e.Path) {
+			continue
+		}
+		verified++
+
+		err := p.Submit(func(ctx context.Context) (interface{}, error) {
+			vr := verifyResult{entry: e, path: path}
+
+			if track != trackDigest || !manifest.IsDigest(e.Sum) {
+				return verifyMetadata(vr, path, track)
+			}
+
+			filehash, err := hasherFor(entryAlgo)
+			if err != nil {
+				return vr, err
+			}
+			sum, err := hasher.WithTimeout(filehash, timeout)(path)
+			if err != nil {
+				return vr, err
+			}
+
+			vr.ok = strings.EqualFold(sum, e.Sum)
+			return vr, nil
+		})
+		if err != nil {
+			break
+		}
+	}
+	p.Wait()
+
+	if sample != nil {
+		changed, margin := sample.estimate(len(failed), verified, population)
+		log.Info("Sampled ", verified, " of ", population, " files at ", sample, ", estimated ",
+			math.Round(changed), " ± ", math.Ceil(margin), " files changed")
+	}
+
+	if verified == 0 {
+		log.Warn("No files of manifest verified")
+	}
+	if len(failed) > 0 {
+		log.Warn(len(failed), " of ", verified, " files did NOT match")
+		if audit != nil {
+			attributeChanges(audit, failed)
+		}
+	}
+
+	return len(failed) == 0 && verified > 0
+}
+
+// Verifies existence of volatile file, and permissions when
+// recorded in manifest and tracked by policy.
+func verifyMetadata(vr verifyResult, path, track string) (interface{}, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return vr, err
+	}
+
+	mode := strings.TrimPrefix(vr.entry.Sum, tokenMode)
+	if track != trackExists && strings.HasPrefix(vr.entry.Sum, tokenMode) && permissions(info) != mode {
+		vr.detail = " permissions " + permissions(info) + ", expected " + mode
+		return vr, nil
+	}
+
+	vr.ok = true
+	return vr, nil
+}
@@ -0,0 +1,148 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Exit code when second signal interrupts the shutdown
+const exitInterrupted = 130
+
+// Default timeout of shutdown hooks
+const defaultTimeout = 10 * time.Second
+
+// Hook releases resource on shutdown, should return
+// early when context is done.
+type Hook func(ctx context.Context) error
+
+// Shutdown runs hooks when long running mode terminates
+type Shutdown interface {
+	// OnShutdown registers hook, hooks run in reverse order of registration.
+	OnShutdown(name string, hook Hook)
+
+	// Shutdown runs the hooks once, each within timeout,
+	// returns errors of hooks aggregated.
+	Shutdown() error
+}
+
+type shutdown struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	names   []string
+	hooks   []Hook
+	done    bool
+}
+
+// SignalContext returns context canceled on SIGINT or SIGTERM,
+// or signals given. Second signal exits the process immediately,
+// until the returned cancel is called.
+func SignalContext(parent context.Context, signals ...os.Signal) (context.Context, context.CancelFunc) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	stop := make(chan struct{})
+	var once sync.Once
+
+	ch := make(chan os.Signal, 2)
+	signal.Notify(ch, signals...)
+
+	go func() {
+		defer signal.Stop(ch)
+
+		select {
+		case <-ch:
+			cancel()
+		case <-ctx.Done():
+		}
+
+		select {
+		case <-ch:
+			os.Exit(exitInterrupted)
+		case <-stop:
+		}
+	}()
+
+	return ctx, func() {
+		once.Do(func() { close(stop) })
+		cancel()
+	}
+}
+
+// NewShutdown inits shutdown with timeout of each hook,
+// 10s will be default.
+func NewShutdown(timeout time.Duration) Shutdown {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &shutdown{timeout: timeout}
+}
+
+func (s *shutdown) OnShutdown(name string, hook Hook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.names = append(s.names, name)
+	s.hooks = append(s.hooks, hook)
+}
+
+func (s *shutdown) Shutdown() error {
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		return nil
+	}
+	s.done = true
+	names, hooks := s.names, s.hooks
+	s.mu.Unlock()
+
+	var failed []string
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := s.run(hooks[i]); err != nil {
+			failed = append(failed, names[i]+": "+err.Error())
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.New("Shutdown hooks failed, " + strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+// Runs hook within timeout, hook left running is abandoned
+func (s *shutdown) run(hook Hook) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- hook(ctx)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IsTerminal returns true if file is a terminal
+func IsTerminal(f *os.File) bool {
+	var termios syscall.Termios
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(),
+		uintptr(syscall.TCGETS), uintptr(unsafe.Pointer(&termios)))
+
+	return errno == 0
+}
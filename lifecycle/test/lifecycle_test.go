@@ -0,0 +1,76 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/prashant-sb/go-utils/lifecycle"
+)
+
+func TestSignalContext(t *testing.T) {
+	ctx, cancel := lifecycle.SignalContext(context.Background(), syscall.SIGUSR1)
+	defer cancel()
+
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Errorf("SignalContext() FAILED, context not canceled on signal")
+	}
+	t.Logf("SignalContext() PASSED")
+}
+
+func TestShutdown(t *testing.T) {
+	var order []string
+
+	s := lifecycle.NewShutdown(50 * time.Millisecond)
+	s.OnShutdown("first", func(ctx context.Context) error {
+		order = append(order, "first")
+		return nil
+	})
+	s.OnShutdown("failing", func(ctx context.Context) error {
+		order = append(order, "failing")
+		return errors.New("flush failed")
+	})
+	s.OnShutdown("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	err := s.Shutdown()
+	if err == nil {
+		t.Errorf("Shutdown() FAILED, errors of hooks not returned")
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Shutdown() FAILED, timeout not honoured")
+	}
+	if len(order) != 2 || order[0] != "failing" || order[1] != "first" {
+		t.Errorf("Shutdown() FAILED, hooks ran in order %v", order)
+	}
+
+	if err := s.Shutdown(); err != nil {
+		t.Errorf("Shutdown() FAILED, hooks ran twice")
+	}
+	t.Logf("Shutdown() PASSED")
+}
+
+func TestIsTerminal(t *testing.T) {
+	f, err := ioutil.TempFile("", "lifecycle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if lifecycle.IsTerminal(f) {
+		t.Errorf("IsTerminal() FAILED, regular file is terminal")
+	}
+	t.Logf("IsTerminal() PASSED")
+}
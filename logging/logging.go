@@ -0,0 +1,264 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level of the log message
+type Level int
+
+// Format of the log output
+type Format int
+
+const (
+	DebugLevel Level = iota // Verbose messages for debugging
+	InfoLevel               // Informational messages
+	WarnLevel               // Recoverable problems
+	ErrorLevel              // Failed operations
+)
+
+const (
+	TextFormat Format = iota // key=value formatted lines
+	JSONFormat               // One json object per line
+)
+
+// Field key for correlation id of request
+const CorrelationKey = "correlationId"
+
+var levelNames = map[Level]string{
+	DebugLevel: "debug",
+	InfoLevel:  "info",
+	WarnLevel:  "warn",
+	ErrorLevel: "error",
+}
+
+// Logger writes leveled messages with fields to io.Writer sink
+type Logger struct {
+	mu     *sync.Mutex            // Serializes writes to sink
+	out    io.Writer              // Sink for log messages
+	level  Level                  // Minimum level of logged messages
+	format Format                 // Text or json output
+	fields map[string]interface{} // Fields added to each message
+}
+
+type ctxKey struct{}
+
+// Default logger used by package level functions
+var std = New(os.Stderr, InfoLevel, TextFormat)
+
+// New returns logger writing to out
+func New(out io.Writer, level Level, format Format) *Logger {
+	return &Logger{
+		mu:     &sync.Mutex{},
+		out:    out,
+		level:  level,
+		format: format,
+		fields: map[string]interface{}{},
+	}
+}
+
+// SetDefault replaces the default logger
+func SetDefault(l *Logger) {
+	std = l
+}
+
+// Setup replaces the default logger writing to stderr,
+// with level and format names from tool flags.
+func Setup(levelName, formatName string) error {
+	level, err := ParseLevel(levelName)
+	if err != nil {
+		return err
+	}
+
+	format, err := ParseFormat(formatName)
+	if err != nil {
+		return err
+	}
+
+	SetDefault(New(os.Stderr, level, format))
+	return nil
+}
+
+// Default returns the default logger
+func Default() *Logger {
+	return std
+}
+
+// ParseLevel returns level by name, debug | info | warn | error
+func ParseLevel(name string) (Level, error) {
+	for l, n := range levelNames {
+		if n == strings.ToLower(name) {
+			return l, nil
+		}
+	}
+
+	return InfoLevel, errors.New("Log level " + name + " not supported.")
+}
+
+// ParseFormat returns format by name, text | json
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "text":
+		return TextFormat, nil
+	case "json":
+		return JSONFormat, nil
+	}
+
+	return TextFormat, errors.New("Log format " + name + " not supported.")
+}
+
+// String returns name of level
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// With returns logger that adds field to each message
+func (l *Logger) With(key string, value interface{}) *Logger {
+	fields := make(map[string]interface{}, len(l.fields)+1)
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	fields[key] = value
+
+	return &Logger{
+		mu:     l.mu,
+		out:    l.out,
+		level:  l.level,
+		format: l.format,
+		fields: fields,
+	}
+}
+
+// WithCorrelationID returns logger that tags messages with id
+func (l *Logger) WithCorrelationID(id string) *Logger {
+	return l.With(CorrelationKey, id)
+}
+
+// NewCorrelationID generates random id for tracing a request
+func NewCorrelationID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// NewContext returns context carrying the logger
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns logger from context, or default logger
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*Logger); ok {
+		return l
+	}
+
+	return std
+}
+
+// Debug logs the arguments at debug level
+func (l *Logger) Debug(args ...interface{}) { l.log(DebugLevel, fmt.Sprint(args...)) }
+
+// Info logs the arguments at info level
+func (l *Logger) Info(args ...interface{}) { l.log(InfoLevel, fmt.Sprint(args...)) }
+
+// Warn logs the arguments at warn level
+func (l *Logger) Warn(args ...interface{}) { l.log(WarnLevel, fmt.Sprint(args...)) }
+
+// Error logs the arguments at error level
+func (l *Logger) Error(args ...interface{}) { l.log(ErrorLevel, fmt.Sprint(args...)) }
+
+// Debugf logs the formatted message at debug level
+func (l *Logger) Debugf(f string, args ...interface{}) { l.log(DebugLevel, fmt.Sprintf(f, args...)) }
+
+// Infof logs the formatted message at info level
+func (l *Logger) Infof(f string, args ...interface{}) { l.log(InfoLevel, fmt.Sprintf(f, args...)) }
+
+// Warnf logs the formatted message at warn level
+func (l *Logger) Warnf(f string, args ...interface{}) { l.log(WarnLevel, fmt.Sprintf(f, args...)) }
+
+// Errorf logs the formatted message at error level
+func (l *Logger) Errorf(f string, args ...interface{}) { l.log(ErrorLevel, fmt.Sprintf(f, args...)) }
+
+// Formats and writes the message to sink
+func (l *Logger) log(level Level, msg string) {
+	if level < l.level {
+		return
+	}
+
+	ts := time.Now().UTC().Format(time.RFC3339)
+
+	var line []byte
+	switch l.format {
+	case JSONFormat:
+		entry := make(map[string]interface{}, len(l.fields)+3)
+		for k, v := range l.fields {
+			entry[k] = v
+		}
+		entry["time"] = ts
+		entry["level"] = level.String()
+		entry["msg"] = msg
+
+		b, err := json.Marshal(entry)
+		if err != nil {
+			b = []byte(fmt.Sprintf(`{"level":"error","msg":%q}`, err.Error()))
+		}
+		line = append(b, '\n')
+
+	default:
+		var sb strings.Builder
+		sb.WriteString(ts + " " + strings.ToUpper(level.String()) + " " + msg)
+
+		keys := make([]string, 0, len(l.fields))
+		for k := range l.fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sb.WriteString(fmt.Sprintf(" %s=%v", k, l.fields[k]))
+		}
+		sb.WriteString("\n")
+		line = []byte(sb.String())
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out.Write(line)
+}
+
+// Debug logs with default logger at debug level
+func Debug(args ...interface{}) { std.log(DebugLevel, fmt.Sprint(args...)) }
+
+// Info logs with default logger at info level
+func Info(args ...interface{}) { std.log(InfoLevel, fmt.Sprint(args...)) }
+
+// Warn logs with default logger at warn level
+func Warn(args ...interface{}) { std.log(WarnLevel, fmt.Sprint(args...)) }
+
+// Error logs with default logger at error level
+func Error(args ...interface{}) { std.log(ErrorLevel, fmt.Sprint(args...)) }
+
+// Debugf logs formatted message with default logger at debug level
+func Debugf(f string, args ...interface{}) { std.log(DebugLevel, fmt.Sprintf(f, args...)) }
+
+// Infof logs formatted message with default logger at info level
+func Infof(f string, args ...interface{}) { std.log(InfoLevel, fmt.Sprintf(f, args...)) }
+
+// Warnf logs formatted message with default logger at warn level
+func Warnf(f string, args ...interface{}) { std.log(WarnLevel, fmt.Sprintf(f, args...)) }
+
+// Errorf logs formatted message with default logger at error level
+func Errorf(f string, args ...interface{}) { std.log(ErrorLevel, fmt.Sprintf(f, args...)) }
@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	log "github.com/prashant-sb/go-utils/logging"
+)
+
+func TestLevel(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := log.New(&buf, log.WarnLevel, log.TextFormat)
+	l.Info("skipped")
+	l.Error("Error in reading ", "file")
+
+	out := buf.String()
+	if strings.Contains(out, "skipped") || !strings.Contains(out, "ERROR Error in reading file") {
+		t.Errorf("Level FAILED, got %v", out)
+		return
+	}
+	t.Logf("Level PASSED")
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := log.New(&buf, log.DebugLevel, log.JSONFormat).WithCorrelationID("abc")
+	l.Infof("user %s added", "test")
+
+	entry := make(map[string]interface{})
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Errorf("JSON FAILED with %v", err.Error())
+		return
+	}
+
+	if entry["msg"] != "user test added" || entry["level"] != "info" || entry[log.CorrelationKey] != "abc" {
+		t.Errorf("JSON FAILED, got %v", entry)
+		return
+	}
+	t.Logf("JSON PASSED")
+}
@@ -0,0 +1,78 @@
+package main
+
+// CLI to rotate log files of other processes, run from cron
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/logrotate"
+)
+
+// CLI Flags:
+//
+// -files <f1,f2>      : Log files to rotate
+// -max-size <size>    : Rotates log larger than size, K | M | G suffix
+// -max-age <duration> : Rotates log when last rotation is older
+// -keep <n>           : Number of backups kept, 0 keeps all
+// -compress           : Gzips the rotated logs
+// -force              : Rotates logs regardless of limits
+var (
+	files    = flag.String("files", "", "Comma separated log files to rotate")
+	maxSize  = flag.String("max-size", "0", "Rotates log larger than size, K | M | G suffix, 0 disables")
+	maxAge   = flag.Duration("max-age", 0, "Rotates log when last rotation is older, 0 disables")
+	keep     = flag.Int("keep", 7, "Number of rotated logs kept, 0 keeps all")
+	compress = flag.Bool("compress", false, "Gzips the rotated logs")
+	force    = flag.Bool("force", false, "Rotates logs regardless of limits")
+)
+
+func main() {
+	flag.Parse()
+
+	if *files == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	size, err := logrotate.ParseSize(*maxSize)
+	if err != nil {
+		log.Error(err.Error())
+		os.Exit(1)
+	}
+
+	opts := logrotate.Options{
+		MaxSize:    size,
+		MaxAge:     *maxAge,
+		MaxBackups: *keep,
+		Compress:   *compress,
+	}
+
+	failed := false
+	for _, file := range strings.Split(*files, ",") {
+		r := logrotate.NewRotator(file, opts)
+
+		rotated := true
+		if *force {
+			err = r.Rotate()
+		} else {
+			rotated, err = r.RotateIfNeeded()
+		}
+		r.Close()
+
+		if err != nil {
+			log.Error("Error in rotating ", file, ": ", err)
+			failed = true
+			continue
+		}
+		if rotated {
+			fmt.Printf("%s rotated\n", file)
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
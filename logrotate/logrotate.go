@@ -0,0 +1,315 @@
+package logrotate
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	gzExt    string      = ".gz" // Extension of compressed backups
+	fileMode os.FileMode = 0644  // Mode of created log files
+)
+
+// Options for rotation and retention of log file
+type Options struct {
+	// MaxSize rotates the log when it grows beyond bytes, 0 disables.
+	MaxSize int64
+
+	// MaxAge rotates the log when last rotation is older, 0 disables.
+	MaxAge time.Duration
+
+	// MaxBackups is the number of rotated files kept, 0 keeps all.
+	MaxBackups int
+
+	// Compress gzips the rotated files.
+	Compress bool
+}
+
+// Rotator writes to log file and rotates it by size or age.
+// Backups are named <log>.1, <log>.2 ... newest first.
+// Rotator is safe for concurrent use as io.Writer of logger.
+type Rotator struct {
+	path string
+	opts Options
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	rotated time.Time // Time of last rotation
+}
+
+type backup struct {
+	index int
+	name  string
+}
+
+// NewRotator returns rotator for log file at path.
+// File is opened for append on first write.
+func NewRotator(path string, opts Options) *Rotator {
+	return &Rotator{path: path, opts: opts}
+}
+
+// Write appends p to log file, rotates it first when
+// size or age of the log is over the limits.
+func (r *Rotator) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if r.due(int64(len(p))) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+
+	return n, err
+}
+
+// Rotate rotates the log file regardless of limits.
+func (r *Rotator) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rotate()
+}
+
+// RotateIfNeeded rotates the log file when it is over the limits,
+// returns true when log was rotated. Used for logs of other processes.
+func (r *Rotator) RotateIfNeeded() (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return false, err
+	}
+	if info.Size() == 0 {
+		return false, nil
+	}
+
+	if r.file == nil {
+		r.size = info.Size()
+		r.rotated = lastRotation(r.path, info)
+	}
+	if !r.due(0) {
+		return false, nil
+	}
+
+	return true, r.rotate()
+}
+
+// Close closes the log file.
+func (r *Rotator) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+
+	return err
+}
+
+// Opens log file for append, creates it with parent directories
+func (r *Rotator) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, fileMode)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	r.file = f
+	r.size = info.Size()
+	r.rotated = lastRotation(r.path, info)
+
+	return nil
+}
+
+// Checks size and age of the log before writing n bytes
+func (r *Rotator) due(n int64) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.opts.MaxSize > 0 && r.size+n > r.opts.MaxSize {
+		return true
+	}
+
+	return r.opts.MaxAge > 0 && time.Since(r.rotated) >= r.opts.MaxAge
+}
+
+// Shifts backups, renames log to first backup and reopens the log
+func (r *Rotator) rotate() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return err
+		}
+		r.file = nil
+	}
+
+	files, err := backups(r.path)
+	if err != nil {
+		return err
+	}
+
+	// Shifted from oldest, so names are not overwritten
+	for i := len(files) - 1; i >= 0; i-- {
+		b := files[i]
+		if r.opts.MaxBackups > 0 && b.index >= r.opts.MaxBackups {
+			if err := os.Remove(b.name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		ext := ""
+		if strings.HasSuffix(b.name, gzExt) {
+			ext = gzExt
+		}
+		if err := os.Rename(b.name, backupName(r.path, b.index+1)+ext); err != nil {
+			return err
+		}
+	}
+
+	first := backupName(r.path, 1)
+	if err := os.Rename(r.path, first); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if r.opts.Compress {
+		if err := compress(first); err != nil {
+			return err
+		}
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.rotated = time.Now()
+
+	return nil
+}
+
+// Returns name of backup with index
+func backupName(path string, index int) string {
+	return path + "." + strconv.Itoa(index)
+}
+
+// Returns backups of log sorted by index
+func backups(path string) ([]backup, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	files := []backup{}
+	for _, m := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(m, path+"."), gzExt)
+		index, err := strconv.Atoi(suffix)
+		if err != nil || index < 1 {
+			continue
+		}
+		files = append(files, backup{index: index, name: m})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].index < files[j].index
+	})
+
+	return files, nil
+}
+
+// Returns time of last rotation from newest backup,
+// modification time of the log if it is never rotated.
+func lastRotation(path string, info os.FileInfo) time.Time {
+	for _, name := range []string{backupName(path, 1), backupName(path, 1) + gzExt} {
+		if b, err := os.Stat(name); err == nil {
+			return b.ModTime()
+		}
+	}
+
+	return info.ModTime()
+}
+
+// Gzips the file to <file>.gz and removes the file
+func compress(name string) error {
+	in, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(name+gzExt, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fileMode)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(name)
+}
+
+// ParseSize parses size with optional K, M or G suffix to bytes
+func ParseSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+
+	mult := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		mult = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		mult = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		mult = 1 << 30
+	}
+	if mult > 1 {
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, errors.New("Invalid size " + size)
+	}
+
+	return n * mult, nil
+}
@@ -0,0 +1,89 @@
+package logrotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/logrotate"
+)
+
+func TestRotateBySize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	r := logrotate.NewRotator(path, logrotate.Options{MaxSize: 10, MaxBackups: 2})
+	defer r.Close()
+
+	for _, line := range []string{"line one\n", "line two\n", "line three\n", "line four\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Errorf("Write() FAILED: %v", err)
+		}
+	}
+
+	b, _ := ioutil.ReadFile(path)
+	if string(b) != "line four\n" {
+		t.Errorf("Write() FAILED, log has %q", string(b))
+	}
+
+	b, _ = ioutil.ReadFile(path + ".1")
+	if string(b) != "line three\n" {
+		t.Errorf("Write() FAILED, first backup has %q", string(b))
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Write() FAILED, backups over MaxBackups are kept")
+	}
+	t.Logf("Write() PASSED")
+}
+
+func TestRotateCompress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	if err := ioutil.WriteFile(path, []byte("old entries\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := logrotate.NewRotator(path, logrotate.Options{Compress: true})
+	rotated, err := r.RotateIfNeeded()
+	if err != nil || rotated {
+		t.Errorf("RotateIfNeeded() FAILED, rotated log within limits")
+	}
+
+	if err := r.Rotate(); err != nil {
+		t.Errorf("Rotate() FAILED: %v", err)
+	}
+	r.Close()
+
+	if _, err := os.Stat(path + ".1.gz"); err != nil {
+		t.Errorf("Rotate() FAILED, compressed backup missing: %v", err)
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Errorf("Rotate() FAILED, uncompressed backup is kept")
+	}
+	t.Logf("Rotate() PASSED")
+}
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string]int64{"0": 0, "512": 512, "10K": 10 << 10, "5m": 5 << 20, "1G": 1 << 30} {
+		got, err := logrotate.ParseSize(in)
+		if err != nil || got != want {
+			t.Errorf("ParseSize(%s) FAILED, got %d, %v", in, got, err)
+		}
+	}
+
+	if _, err := logrotate.ParseSize("10X"); err == nil {
+		t.Errorf("ParseSize() FAILED, accepted invalid size")
+	}
+	t.Logf("ParseSize() PASSED")
+}
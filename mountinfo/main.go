@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/mountinfo/mounts"
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+)
+
+// Exit codes for monitoring probes
+const (
+	exitOK       = 0
+	exitWarning  = 1
+	exitCritical = 2
+	exitUnknown  = 3
+)
+
+// CLI Flags:
+//
+// -all              : Include pseudo filesystems without blocks
+// -warn <percent>   : Exit with 1 when any mount is used above percent
+// -crit <percent>   : Exit with 2 when any mount is used above percent
+var (
+	all  = flag.Bool("all", false, "Includes pseudo filesystems without blocks")
+	warn = flag.Float64("warn", 0, "Warning threshold of used percent, 0 disables")
+	crit = flag.Float64("crit", 0, "Critical threshold of used percent, 0 disables")
+)
+
+func main() {
+	flag.Parse()
+
+	r := mounts.NewReporter(mounts.Thresholds{Warning: *warn, Critical: *crit})
+
+	list, err := r.List(*all)
+	if err != nil {
+		log.Error("Error in listing mounts: ", err)
+		os.Exit(exitUnknown)
+	}
+
+	jsonList, err := uinfo.Decode(list)
+	if err != nil {
+		log.Error("Error in decode: ", err)
+		os.Exit(exitUnknown)
+	}
+	fmt.Printf("%v\n", jsonList)
+
+	switch mounts.Worst(list) {
+	case mounts.StatusCritical:
+		os.Exit(exitCritical)
+	case mounts.StatusWarning:
+		os.Exit(exitWarning)
+	}
+	os.Exit(exitOK)
+}
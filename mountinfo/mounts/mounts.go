@@ -0,0 +1,216 @@
+package mounts
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Mount table of current process
+const mountInfo = "/proc/self/mountinfo"
+
+// Status of mount against usage thresholds
+const (
+	StatusOK       = "ok"
+	StatusWarning  = "warning"
+	StatusCritical = "critical"
+)
+
+// Mount point with capacity from statfs
+type Mount struct {
+	// Source is the mounted device or remote path.
+	Source string `json:"source"`
+
+	// Point is the mount point.
+	Point string `json:"mountPoint"`
+
+	// Type is the filesystem type.
+	Type string `json:"fsType"`
+
+	// Options are the per-mount options.
+	Options []string `json:"options"`
+
+	// SuperOptions are the superblock options.
+	SuperOptions []string `json:"superOptions,omitempty"`
+
+	TotalBytes  uint64  `json:"totalBytes"`
+	UsedBytes   uint64  `json:"usedBytes"`
+	AvailBytes  uint64  `json:"availBytes"`
+	UsedPercent float64 `json:"usedPercent"`
+
+	Inodes            uint64  `json:"inodes"`
+	InodesUsedPercent float64 `json:"inodesUsedPercent"`
+
+	// Status is usage against thresholds, ok | warning | critical.
+	Status string `json:"status"`
+}
+
+// Thresholds of used percent for warning and critical status,
+// zero disables the threshold.
+type Thresholds struct {
+	Warning  float64
+	Critical float64
+}
+
+// Reporter interface lists mounts with capacity
+type Reporter interface {
+	List(all bool) ([]Mount, error)
+}
+
+type reporter struct {
+	table      string     // Path of mountinfo table
+	thresholds Thresholds // Usage thresholds
+}
+
+// NewReporter inits the interface for mount capacity
+func NewReporter(t Thresholds) Reporter {
+	return &reporter{
+		table:      mountInfo,
+		thresholds: t,
+	}
+}
+
+// List returns mounts with capacity, pseudo filesystems
+// without blocks are included only when all is set.
+func (r *reporter) List(all bool) ([]Mount, error) {
+
+	file, err := os.Open(r.table)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	mounts, err := Parse(file)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []Mount{}
+	for _, m := range mounts {
+		st := syscall.Statfs_t{}
+		if err := syscall.Statfs(m.Point, &st); err != nil && !all {
+			continue
+		}
+		if st.Blocks == 0 && !all {
+			continue
+		}
+
+		bsize := uint64(st.Bsize)
+		m.TotalBytes = st.Blocks * bsize
+		m.UsedBytes = (st.Blocks - st.Bfree) * bsize
+		m.AvailBytes = st.Bavail * bsize
+		m.UsedPercent = percent(m.UsedBytes, m.UsedBytes+m.AvailBytes)
+		m.Inodes = st.Files
+		m.InodesUsedPercent = percent(st.Files-st.Ffree, st.Files)
+		m.Status = r.status(m)
+
+		list = append(list, m)
+	}
+
+	return list, nil
+}
+
+// Parse reads mounts from mountinfo formatted table
+func Parse(rd io.Reader) ([]Mount, error) {
+	mounts := []Mount{}
+
+	s := bufio.NewScanner(rd)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		// Optional fields are terminated by single hyphen
+		sep := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				sep = i
+				break
+			}
+		}
+		if sep < 0 || len(fields) < sep+3 {
+			return nil, errors.New("Invalid mountinfo line: " + s.Text())
+		}
+
+		m := Mount{
+			Point:   unescape(fields[4]),
+			Type:    fields[sep+1],
+			Source:  unescape(fields[sep+2]),
+			Options: strings.Split(fields[5], ","),
+		}
+		if len(fields) > sep+3 {
+			m.SuperOptions = strings.Split(fields[sep+3], ",")
+		}
+		mounts = append(mounts, m)
+	}
+
+	return mounts, s.Err()
+}
+
+// Worst returns highest status of mounts, critical > warning > ok
+func Worst(mounts []Mount) string {
+	worst := StatusOK
+
+	for _, m := range mounts {
+		switch {
+		case m.Status == StatusCritical:
+			return StatusCritical
+		case m.Status == StatusWarning:
+			worst = StatusWarning
+		}
+	}
+
+	return worst
+}
+
+// Compares usage of blocks and inodes with thresholds
+func (r *reporter) status(m Mount) string {
+	used := m.UsedPercent
+	if m.InodesUsedPercent > used {
+		used = m.InodesUsedPercent
+	}
+
+	switch {
+	case r.thresholds.Critical > 0 && used >= r.thresholds.Critical:
+		return StatusCritical
+	case r.thresholds.Warning > 0 && used >= r.thresholds.Warning:
+		return StatusWarning
+	}
+
+	return StatusOK
+}
+
+// Returns percent rounded to two decimals
+func percent(part, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(uint64(float64(part)/float64(total)*10000+0.5)) / 100
+}
+
+// Replaces octal escapes of space, tab, newline and backslash
+func unescape(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				sb.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,32 @@
+package mounts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/mountinfo/mounts"
+)
+
+const testTable = `36 35 98:0 /mnt1 /mnt/my\040disk rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+23 28 0:22 / /proc rw,relatime - proc proc rw
+`
+
+func TestParse(t *testing.T) {
+	list, err := mounts.Parse(strings.NewReader(testTable))
+	if err != nil {
+		t.Errorf("Parse() FAILED with %v", err.Error())
+		return
+	}
+
+	if len(list) != 2 {
+		t.Errorf("Parse() FAILED, expected 2 mounts got %v", len(list))
+		return
+	}
+
+	m := list[0]
+	if m.Point != "/mnt/my disk" || m.Type != "ext3" || m.Source != "/dev/root" || len(m.Options) != 2 || len(m.SuperOptions) != 2 {
+		t.Errorf("Parse() FAILED, got %+v", m)
+		return
+	}
+	t.Logf("Parse() PASSED")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/netinfo/network"
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+)
+
+// CLI Flags, complete inventory is listed without flags:
+//
+// -interfaces : List interfaces with addresses
+// -routes     : List ipv4 and ipv6 routes
+// -sockets    : List listening sockets with owning process
+var (
+	interfaces = flag.Bool("interfaces", false, "Lists network interfaces")
+	routes     = flag.Bool("routes", false, "Lists routes")
+	sockets    = flag.Bool("sockets", false, "Lists listening sockets")
+)
+
+// Collects inventory sections selected by flags
+func collect(n network.Inventorier) (*network.Inventory, error) {
+	var err error
+
+	if !*interfaces && !*routes && !*sockets {
+		return n.Inventory()
+	}
+
+	inv := &network.Inventory{}
+	if *interfaces {
+		if inv.Interfaces, err = n.Interfaces(); err != nil {
+			return nil, err
+		}
+	}
+	if *routes {
+		if inv.Routes, err = n.Routes(); err != nil {
+			return nil, err
+		}
+	}
+	if *sockets {
+		if inv.Sockets, err = n.Sockets(); err != nil {
+			return nil, err
+		}
+	}
+
+	return inv, nil
+}
+
+func main() {
+	flag.Parse()
+
+	inv, err := collect(network.NewInventorier())
+	if err != nil {
+		log.Error("Error in collecting network inventory: ", err)
+		return
+	}
+
+	jsonInv, err := uinfo.Decode(inv)
+	if err != nil {
+		log.Error("Error in decode: ", err)
+		return
+	}
+
+	fmt.Printf("%v\n", jsonInv)
+}
@@ -0,0 +1,136 @@
+package network
+
+import (
+	"net"
+)
+
+// Network inventory parsed from /proc/net
+const (
+	procDir     = "/proc"
+	routeFile   = "net/route"
+	route6File  = "net/ipv6_route"
+	socketLink  = "socket:["
+	tcpListen   = "0A" // TCP_LISTEN state
+	udpUnconned = "07" // TCP_CLOSE state of unconnected udp socket
+)
+
+// Interface with its addresses
+type Interface struct {
+	Name      string   `json:"name"`
+	Index     int      `json:"index"`
+	MTU       int      `json:"mtu"`
+	MAC       string   `json:"mac,omitempty"`
+	Flags     string   `json:"flags"`
+	Addresses []string `json:"addresses"`
+}
+
+// Route from kernel routing table
+type Route struct {
+	Interface   string `json:"interface"`
+	Destination string `json:"destination"`
+	Gateway     string `json:"gateway,omitempty"`
+	Metric      int    `json:"metric"`
+}
+
+// Socket listening for connections or datagrams
+type Socket struct {
+	// Protocol is one of tcp | tcp6 | udp | udp6.
+	Protocol string `json:"protocol"`
+
+	// Address and Port of local endpoint.
+	Address string `json:"address"`
+	Port    int    `json:"port"`
+
+	// Uid and Username of socket owner.
+	Uid      string `json:"uid"`
+	Username string `json:"userName,omitempty"`
+
+	// Pid and Process holding the socket, zero when not
+	// permitted to read fds of owning process.
+	Pid     int    `json:"pid,omitempty"`
+	Process string `json:"process,omitempty"`
+
+	inode string
+}
+
+// Inventory of host network
+type Inventory struct {
+	Interfaces []Interface `json:"interfaces,omitempty"`
+	Routes     []Route     `json:"routes,omitempty"`
+	Sockets    []Socket    `json:"sockets,omitempty"`
+}
+
+// Inventorier interface collects network inventory
+type Inventorier interface {
+	Interfaces() ([]Interface, error)
+	Routes() ([]Route, error)
+	Sockets() ([]Socket, error)
+	Inventory() (*Inventory, error)
+}
+
+type inventorier struct {
+	root string // Mount point of procfs
+}
+
+// NewInventorier inits the interface for network inventory
+func NewInventorier() Inventorier {
+	return NewInventorierWithRoot(procDir)
+}
+
+// NewInventorierWithRoot inits network inventory of procfs mounted
+// at root, as /proc of container or copy of it.
+func NewInventorierWithRoot(root string) Inventorier {
+	return &inventorier{
+		root: root,
+	}
+}
+
+// Inventory collects interfaces, routes and listening sockets
+func (n *inventorier) Inventory() (*Inventory, error) {
+	var err error
+	inv := &Inventory{}
+
+	if inv.Interfaces, err = n.Interfaces(); err != nil {
+		return nil, err
+	}
+	if inv.Routes, err = n.Routes(); err != nil {
+		return nil, err
+	}
+	if inv.Sockets, err = n.Sockets(); err != nil {
+		return nil, err
+	}
+
+	return inv, nil
+}
+
+// Interfaces lists network interfaces with addresses
+func (n *inventorier) Interfaces() ([]Interface, error) {
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	list := []Interface{}
+	for _, i := range ifaces {
+		iface := Interface{
+			Name:      i.Name,
+			Index:     i.Index,
+			MTU:       i.MTU,
+			MAC:       i.HardwareAddr.String(),
+			Flags:     i.Flags.String(),
+			Addresses: []string{},
+		}
+
+		addrs, err := i.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range addrs {
+			iface.Addresses = append(iface.Addresses, a.String())
+		}
+		list = append(list, iface)
+	}
+
+	return list, nil
+}
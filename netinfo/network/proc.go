@@ -0,0 +1,242 @@
+package network
+
+import (
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	prc "github.com/prashant-sb/go-utils/procinfo/procs"
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+)
+
+// Routes parses ipv4 and ipv6 routing tables
+func (n *inventorier) Routes() ([]Route, error) {
+	routes := []Route{}
+
+	lines, err := n.readTable(routeFile, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range lines {
+		if len(f) < 8 {
+			continue
+		}
+		dest, err := parseIPv4(f[1])
+		if err != nil {
+			return nil, err
+		}
+		gw, err := parseIPv4(f[2])
+		if err != nil {
+			return nil, err
+		}
+		mask, err := parseIPv4(f[7])
+		if err != nil {
+			return nil, err
+		}
+		ones, _ := net.IPMask(mask.To4()).Size()
+		metric, _ := strconv.Atoi(f[6])
+
+		r := Route{
+			Interface:   f[0],
+			Destination: dest.String() + "/" + strconv.Itoa(ones),
+			Metric:      metric,
+		}
+		if !gw.IsUnspecified() {
+			r.Gateway = gw.String()
+		}
+		routes = append(routes, r)
+	}
+
+	lines, err = n.readTable(route6File, false)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, f := range lines {
+		if len(f) < 10 {
+			continue
+		}
+		dest, err := hex.DecodeString(f[0])
+		if err != nil {
+			return nil, err
+		}
+		gw, err := hex.DecodeString(f[4])
+		if err != nil {
+			return nil, err
+		}
+		plen, _ := strconv.ParseInt(f[1], 16, 32)
+		metric, _ := strconv.ParseInt(f[5], 16, 32)
+
+		r := Route{
+			Interface:   f[9],
+			Destination: net.IP(dest).String() + "/" + strconv.Itoa(int(plen)),
+			Metric:      int(metric),
+		}
+		if !net.IP(gw).IsUnspecified() {
+			r.Gateway = net.IP(gw).String()
+		}
+		routes = append(routes, r)
+	}
+
+	return routes, nil
+}
+
+// Sockets lists listening tcp and udp sockets with owning process
+func (n *inventorier) Sockets() ([]Socket, error) {
+	sockets := []Socket{}
+
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		lines, err := n.readTable(filepath.Join("net", proto), true)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, f := range lines {
+			if len(f) < 10 || !listening(proto, f[3], f[2]) {
+				continue
+			}
+
+			addr, port, err := parseEndpoint(f[1])
+			if err != nil {
+				return nil, err
+			}
+			sockets = append(sockets, Socket{
+				Protocol: proto,
+				Address:  addr,
+				Port:     port,
+				Uid:      f[7],
+				inode:    f[9],
+			})
+		}
+	}
+
+	n.mapProcesses(sockets)
+	return sockets, nil
+}
+
+// Finds processes holding the socket inodes,
+// resolves owner of sockets without process
+func (n *inventorier) mapProcesses(sockets []Socket) {
+	inodes := make(map[string]int)
+
+	fds, _ := filepath.Glob(filepath.Join(n.root, "[0-9]*", "fd", "*"))
+	for _, fd := range fds {
+		link, err := os.Readlink(fd)
+		if err != nil || !strings.HasPrefix(link, socketLink) {
+			continue
+		}
+
+		pid, err := strconv.Atoi(filepath.Base(filepath.Dir(filepath.Dir(fd))))
+		if err != nil {
+			continue
+		}
+		inodes[strings.TrimSuffix(strings.TrimPrefix(link, socketLink), "]")] = pid
+	}
+
+	pl := prc.NewProcListerWithRoot(n.root)
+	ui := uinfo.NewUserOps()
+	for i := range sockets {
+		pid, ok := inodes[sockets[i].inode]
+		if !ok {
+			if u, err := ui.GetByUid(sockets[i].Uid); err == nil {
+				sockets[i].Username = u.Username
+			}
+			continue
+		}
+
+		p, err := pl.Get(pid)
+		if err != nil {
+			log.Warn("Error in reading process ", pid, ": ", err)
+			continue
+		}
+		sockets[i].Pid = p.Pid
+		sockets[i].Process = p.Name
+		sockets[i].Username = p.Username
+	}
+}
+
+// Reads whitespace separated table from procfs, skipping header
+func (n *inventorier) readTable(name string, header bool) ([][]string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(n.root, name))
+	if err != nil {
+		return nil, err
+	}
+
+	var lines [][]string
+	for i, line := range strings.Split(string(content), "\n") {
+		f := strings.Fields(line)
+		if len(f) == 0 || header && i == 0 {
+			continue
+		}
+		lines = append(lines, f)
+	}
+
+	return lines, nil
+}
+
+// Returns true for listening tcp, or unconnected udp socket
+func listening(proto, state, remote string) bool {
+	if strings.HasPrefix(proto, "tcp") {
+		return state == tcpListen
+	}
+
+	return state == udpUnconned && strings.Trim(remote, "0:") == ""
+}
+
+// Parses hex address:port from /proc/net sockets
+func parseEndpoint(endpoint string) (string, int, error) {
+	parts := strings.Split(endpoint, ":")
+	if len(parts) != 2 {
+		return "", 0, errors.New("Invalid endpoint " + endpoint)
+	}
+
+	port, err := strconv.ParseInt(parts[1], 16, 32)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var ip net.IP
+	if len(parts[0]) == 8 {
+		ip, err = parseIPv4(parts[0])
+	} else {
+		ip, err = parseIPv6(parts[0])
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	return ip.String(), int(port), nil
+}
+
+// Parses ipv4 address in host (little endian) byte order
+func parseIPv4(s string) (net.IP, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != net.IPv4len {
+		return nil, errors.New("Invalid address " + s)
+	}
+
+	return net.IPv4(b[3], b[2], b[1], b[0]), nil
+}
+
+// Parses ipv6 address stored as four little endian words
+func parseIPv6(s string) (net.IP, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != net.IPv6len {
+		return nil, errors.New("Invalid address " + s)
+	}
+
+	ip := make(net.IP, net.IPv6len)
+	for w := 0; w < net.IPv6len; w += 4 {
+		ip[w], ip[w+1], ip[w+2], ip[w+3] = b[w+3], b[w+2], b[w+1], b[w]
+	}
+
+	return ip, nil
+}
@@ -0,0 +1,103 @@
+package network
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/netinfo/network"
+)
+
+// Returns procfs with net tables of fixtures, and process 4182 holding
+// socket of inode 842
+func writeProc(t *testing.T) string {
+	root, err := ioutil.TempDir("", "proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"4182/status":  "Name:\tcupsd\nUid:\t65534\t65534\t65534\t65534\n",
+		"4182/cmdline": "/usr/sbin/cupsd\x00-l\x00",
+		"uptime":       "1.0 1.0\n",
+	}
+	for _, name := range []string{"tcp", "tcp6", "route"} {
+		content, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files["net/"+name] = string(content)
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.MkdirAll(filepath.Join(root, "4182/fd"), 0755)
+	if err := os.Symlink("socket:[842]", filepath.Join(root, "4182/fd/3")); err != nil {
+		t.Fatal(err)
+	}
+
+	return root
+}
+
+func TestSockets(t *testing.T) {
+	root := writeProc(t)
+	defer os.RemoveAll(root)
+
+	sockets, err := network.NewInventorierWithRoot(root).Sockets()
+	if err != nil {
+		t.Fatalf("Sockets() FAILED, %v", err)
+	}
+
+	// Established connections are not listed, udp tables are missing
+	want := []network.Socket{
+		{Protocol: "tcp", Address: "0.0.0.0", Port: 2024, Uid: "0", Username: "root"},
+		{Protocol: "tcp", Address: "127.0.0.1", Port: 48271, Uid: "65534", Username: "nobody", Pid: 4182, Process: "cupsd"},
+		{Protocol: "tcp6", Address: "::", Port: 22, Uid: "0", Username: "root"},
+		{Protocol: "tcp6", Address: "::1", Port: 631, Uid: "65534", Username: "nobody"},
+	}
+	if len(sockets) != len(want) {
+		t.Fatalf("Sockets() FAILED, expected: %+v got: %+v", want, sockets)
+	}
+	for i, w := range want {
+		s := sockets[i]
+		if s.Protocol != w.Protocol || s.Address != w.Address || s.Port != w.Port || s.Uid != w.Uid ||
+			s.Username != w.Username || s.Pid != w.Pid || s.Process != w.Process {
+			t.Errorf("Sockets() FAILED, expected: %+v got: %+v", w, s)
+		}
+	}
+	t.Logf("Sockets() PASSED")
+}
+
+func TestRoutes(t *testing.T) {
+	root := writeProc(t)
+	defer os.RemoveAll(root)
+
+	routes, err := network.NewInventorierWithRoot(root).Routes()
+	if err != nil || len(routes) != 2 {
+		t.Fatalf("Routes() FAILED, %+v, %v", routes, err)
+	}
+	if r := routes[0]; r.Interface != "eth0" || r.Destination != "0.0.0.0/0" || r.Gateway != "192.0.2.1" || r.Metric != 100 {
+		t.Errorf("Routes() FAILED, default route %+v", r)
+	}
+	if r := routes[1]; r.Destination != "192.0.2.0/24" || r.Gateway != "" {
+		t.Errorf("Routes() FAILED, network route %+v", r)
+	}
+	t.Logf("Routes() PASSED")
+}
+
+func TestMalformedTable(t *testing.T) {
+	root := writeProc(t)
+	defer os.RemoveAll(root)
+
+	table := "  sl  local_address rem_address   st\n   0: 0100007F07E8 00000000:0000 0A 0 0 0 0 0 662 1\n"
+	ioutil.WriteFile(filepath.Join(root, "net/tcp"), []byte(table), 0644)
+	if _, err := network.NewInventorierWithRoot(root).Sockets(); err == nil {
+		t.Errorf("Sockets() FAILED, malformed endpoint accepted")
+	}
+	t.Logf("MalformedTable() PASSED")
+}
@@ -0,0 +1,71 @@
+package output
+
+import (
+	"io"
+	"os"
+
+	"github.com/prashant-sb/go-utils/lifecycle"
+)
+
+// Escape sequences of colors
+const (
+	reset  string = "\x1b[0m"
+	bold   string = "\x1b[1m"
+	red    string = "\x1b[31m"
+	green  string = "\x1b[32m"
+	yellow string = "\x1b[33m"
+)
+
+// Color wraps text in escape sequences when enabled
+type Color struct {
+	enabled bool
+}
+
+// NewColor enables colors when w is a terminal and NO_COLOR is not set
+func NewColor(w io.Writer) *Color {
+	f, ok := w.(*os.File)
+	if !ok {
+		return &Color{}
+	}
+
+	_, noColor := os.LookupEnv("NO_COLOR")
+	return &Color{enabled: !noColor && lifecycle.IsTerminal(f)}
+}
+
+// SetEnabled overrides detection, for -color flags of tools
+func (c *Color) SetEnabled(enabled bool) {
+	c.enabled = enabled
+}
+
+// Enabled returns true if colors are written
+func (c *Color) Enabled() bool {
+	return c.enabled
+}
+
+// Bold returns s in bold
+func (c *Color) Bold(s string) string {
+	return c.wrap(bold, s)
+}
+
+// Red returns s in red, for errors and critical values
+func (c *Color) Red(s string) string {
+	return c.wrap(red, s)
+}
+
+// Yellow returns s in yellow, for warnings
+func (c *Color) Yellow(s string) string {
+	return c.wrap(yellow, s)
+}
+
+// Green returns s in green, for healthy values
+func (c *Color) Green(s string) string {
+	return c.wrap(green, s)
+}
+
+func (c *Color) wrap(code, s string) string {
+	if !c.enabled || s == "" {
+		return s
+	}
+
+	return code + s + reset
+}
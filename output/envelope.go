@@ -0,0 +1,114 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ErrNotEnvelope is returned decoding json document not wrapped in envelope
+var ErrNotEnvelope = errors.New("Document is not an envelope.")
+
+// Envelope wraps json output of tools with its origin, so documents of any
+// tool are routed by tool and kind, and payload is validated against schema
+// version of kind. Version changes only on incompatible change of payload.
+type Envelope struct {
+	Tool          string      `json:"tool"`
+	Kind          string      `json:"kind"`
+	SchemaVersion int         `json:"schemaVersion"`
+	Host          string      `json:"host"`
+	Timestamp     time.Time   `json:"timestamp"`
+	Payload       interface{} `json:"payload"`
+}
+
+// Envelope as read, payload is decoded once kind is known
+type rawEnvelope struct {
+	Tool          string          `json:"tool"`
+	Kind          string          `json:"kind"`
+	SchemaVersion int             `json:"schemaVersion"`
+	Host          string          `json:"host"`
+	Timestamp     time.Time       `json:"timestamp"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// NewEnvelope wraps payload of kind of tool, with hostname and current time
+func NewEnvelope(tool, kind string, version int, payload interface{}) *Envelope {
+	host, _ := os.Hostname()
+
+	return &Envelope{
+		Tool:          tool,
+		Kind:          kind,
+		SchemaVersion: version,
+		Host:          host,
+		Timestamp:     time.Now().UTC(),
+		Payload:       payload,
+	}
+}
+
+// Write writes envelope as json to w, on one line when indent is blank
+func (e *Envelope) Write(w io.Writer, indent string) error {
+	var data []byte
+	var err error
+	if indent == "" {
+		data, err = json.Marshal(e)
+	} else {
+		data, err = json.MarshalIndent(e, "", indent)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Check returns error if envelope is not of tool and kind, or schema
+// version is newer than version known to reader.
+func (e *Envelope) Check(tool, kind string, version int) error {
+	if e.Tool != tool || e.Kind != kind {
+		return errors.New("Document is " + e.Kind + " of " + e.Tool + ", expected " + kind + " of " + tool + ".")
+	}
+	if e.SchemaVersion > version {
+		return errors.New("Schema version " + strconv.Itoa(e.SchemaVersion) + " of " + kind +
+			" not supported, expected up to " + strconv.Itoa(version) + ".")
+	}
+
+	return nil
+}
+
+// DecodeEnvelope decodes envelope of data with payload into payload, which
+// is usually pointer to type of kind. ErrNotEnvelope is returned for json
+// documents without tool, kind and payload.
+func DecodeEnvelope(data []byte, payload interface{}) (*Envelope, error) {
+	if data = bytes.TrimSpace(data); len(data) == 0 || data[0] != '{' {
+		return nil, ErrNotEnvelope
+	}
+
+	raw := &rawEnvelope{}
+	if err := json.Unmarshal(data, raw); err != nil {
+		return nil, err
+	}
+	if raw.Tool == "" || raw.Kind == "" || len(raw.Payload) == 0 {
+		return nil, ErrNotEnvelope
+	}
+	if raw.SchemaVersion < 1 {
+		return nil, errors.New("Schema version of " + raw.Kind + " of " + raw.Tool + " is missing.")
+	}
+
+	if err := json.Unmarshal(raw.Payload, payload); err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		Tool:          raw.Tool,
+		Kind:          raw.Kind,
+		SchemaVersion: raw.SchemaVersion,
+		Host:          raw.Host,
+		Timestamp:     raw.Timestamp,
+		Payload:       payload,
+	}, nil
+}
@@ -0,0 +1,35 @@
+package output
+
+import (
+	"strconv"
+)
+
+// Units of sizes in powers of 1024
+var units = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// HumanBytes returns size with binary unit, as 1.5 KiB.
+// Sizes below 10 units keep one decimal.
+func HumanBytes(n int64) string {
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	if n < 1024 {
+		return sign + strconv.FormatInt(n, 10) + " B"
+	}
+
+	size := float64(n)
+	unit := 0
+	for size >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+
+	prec := 0
+	if size < 10 {
+		prec = 1
+	}
+
+	return sign + strconv.FormatFloat(size, 'f', prec, 64) + " " + units[unit]
+}
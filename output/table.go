@@ -0,0 +1,125 @@
+package output
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Spaces between columns
+const columnGap = 2
+
+// Table writes rows aligned in columns, header is printed in bold
+// when writer is a terminal.
+type Table struct {
+	w      io.Writer
+	color  *Color
+	header []string
+	rows   [][]string
+}
+
+// NewTable inits table with header written to w
+func NewTable(w io.Writer, header ...string) *Table {
+	return &Table{
+		w:      w,
+		color:  NewColor(w),
+		header: header,
+	}
+}
+
+// Color returns colorizer of table writer, for coloring cells
+func (t *Table) Color() *Color {
+	return t.color
+}
+
+// Append adds row, missing cells are left blank
+func (t *Table) Append(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// Render writes header and rows, columns are padded to widest cell
+func (t *Table) Render() error {
+	cols := len(t.header)
+	for _, r := range t.rows {
+		if len(r) > cols {
+			cols = len(r)
+		}
+	}
+
+	widths := make([]int, cols)
+	for _, r := range append([][]string{t.header}, t.rows...) {
+		for i, c := range r {
+			if n := width(c); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	if len(t.header) > 0 {
+		header := make([]string, len(t.header))
+		for i, h := range t.header {
+			header[i] = t.color.Bold(h)
+		}
+		if err := t.writeRow(header, widths); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range t.rows {
+		if err := t.writeRow(r, widths); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Writes cells padded to widths, last column is not padded
+func (t *Table) writeRow(cells []string, widths []int) error {
+	var b strings.Builder
+	for i := range widths {
+		cell := ""
+		if i < len(cells) {
+			cell = cells[i]
+		}
+
+		if i == len(widths)-1 {
+			b.WriteString(cell)
+			break
+		}
+		b.WriteString(cell)
+		b.WriteString(strings.Repeat(" ", widths[i]-width(cell)+columnGap))
+	}
+
+	line := strings.TrimRight(b.String(), " ") + "\n"
+	_, err := io.WriteString(t.w, line)
+
+	return err
+}
+
+// Returns printed width of cell, escape sequences of colors are skipped
+func width(s string) int {
+	return utf8.RuneCountInString(stripEscapes(s))
+}
+
+// Removes escape sequences of colors
+func stripEscapes(s string) string {
+	if !strings.Contains(s, "\x1b[") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '[' {
+			j := i + 2
+			for j < len(s) && s[j] != 'm' {
+				j++
+			}
+			i = j
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
@@ -0,0 +1,107 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/output"
+)
+
+func TestTable(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	tbl := output.NewTable(buf, "NAME", "SIZE")
+	tbl.Append("a", "1 B")
+	tbl.Append("longer", "2.0 KiB")
+	tbl.Append("b")
+	if err := tbl.Render(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "NAME    SIZE\n" +
+		"a       1 B\n" +
+		"longer  2.0 KiB\n" +
+		"b\n"
+	if buf.String() != want {
+		t.Errorf("Table() FAILED, got:\n%s", buf.String())
+	}
+	t.Logf("Table() PASSED")
+}
+
+func TestTableColor(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	tbl := output.NewTable(buf, "NAME", "STATE")
+	if tbl.Color().Enabled() {
+		t.Errorf("TableColor() FAILED, colors enabled for buffer")
+	}
+
+	tbl.Color().SetEnabled(true)
+	tbl.Append(tbl.Color().Red("failed"), "x")
+	tbl.Append("ok", "y")
+	if err := tbl.Render(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "\x1b[1mNAME\x1b[0m    \x1b[1mSTATE\x1b[0m\n" +
+		"\x1b[31mfailed\x1b[0m  x\n" +
+		"ok      y\n"
+	if buf.String() != want {
+		t.Errorf("TableColor() FAILED, got:\n%q", buf.String())
+	}
+	t.Logf("TableColor() PASSED")
+}
+
+func TestHumanBytes(t *testing.T) {
+	cases := map[int64]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1536:            "1.5 KiB",
+		10 * 1024:       "10 KiB",
+		5 * 1024 * 1024: "5.0 MiB",
+		3 << 40:         "3.0 TiB",
+		-2048:           "-2.0 KiB",
+	}
+
+	for n, want := range cases {
+		if got := output.HumanBytes(n); got != want {
+			t.Errorf("HumanBytes(%d) FAILED, got %s, want %s", n, got, want)
+		}
+	}
+	t.Logf("HumanBytes() PASSED")
+}
+
+func TestEnvelope(t *testing.T) {
+	type report struct {
+		Files int `json:"files"`
+	}
+
+	buf := &bytes.Buffer{}
+	if err := output.NewEnvelope("file_signatures", "summary", 1, &report{Files: 3}).Write(buf, ""); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("Envelope() FAILED, not one line: %q", buf.String())
+	}
+
+	got := &report{}
+	e, err := output.DecodeEnvelope(buf.Bytes(), got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Files != 3 || e.Host == "" || e.Timestamp.IsZero() {
+		t.Errorf("Envelope() FAILED, decoded %+v of %+v", got, e)
+	}
+	if err := e.Check("file_signatures", "summary", 1); err != nil {
+		t.Errorf("Envelope() FAILED, %v", err)
+	}
+	if e.Check("file_signatures", "summary", 0) == nil || e.Check("snapshot", "summary", 1) == nil {
+		t.Errorf("Envelope() FAILED, check passed newer version or other tool")
+	}
+
+	if _, err := output.DecodeEnvelope([]byte(`{"files": 3}`), got); err != output.ErrNotEnvelope {
+		t.Errorf("Envelope() FAILED, plain document decoded: %v", err)
+	}
+	t.Logf("Envelope() PASSED")
+}
@@ -0,0 +1,396 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/mountinfo/mounts"
+	"github.com/prashant-sb/go-utils/pool"
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+	"github.com/prashant-sb/go-utils/walker"
+)
+
+// Kinds of findings
+const (
+	KindWorldWritable string = "world-writable"
+	KindSetuid        string = "setuid"
+	KindSetgid        string = "setgid"
+	KindUnknownOwner  string = "unknown-owner"
+	KindUnknownGroup  string = "unknown-group"
+	KindCapabilities  string = "capabilities"
+)
+
+// Options for auditing permissions
+type Options struct {
+	// Workers is the number of concurrent workers.
+	Workers int
+
+	// Exclude lists glob patterns of skipped paths.
+	Exclude []string
+
+	// AllowedCaps lists capabilities which are not reported.
+	AllowedCaps []string
+
+	// PseudoFS walks pseudo filesystems mounted below root, as proc,
+	// sysfs, devtmpfs and tmpfs of /run, skipped otherwise.
+	PseudoFS bool
+}
+
+// Finding is the insecure attribute of file
+type Finding struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+
+	// Mode is the octal permission bits with setuid, setgid and sticky.
+	Mode string `json:"mode"`
+
+	Uid string `json:"uid"`
+	Gid string `json:"gid"`
+
+	// Username is blank if uid is not known to system.
+	Username string `json:"userName,omitempty"`
+
+	// Detail lists the capabilities of file.
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report of findings under root
+type Report struct {
+	Root     string    `json:"root"`
+	Files    int       `json:"files"`
+	Errors   int       `json:"errors"`
+	Findings []Finding `json:"findings"`
+}
+
+// Diff of report with baseline
+type Diff struct {
+	Added   []Finding `json:"added"`
+	Removed []Finding `json:"removed"`
+}
+
+// Scanner interface for auditing permissions
+type Scanner interface {
+	Scan(string) (*Report, error)
+}
+
+// File attributes collected by walker
+type fileStat struct {
+	path string
+	mode os.FileMode
+	perm uint32 // Permission bits from stat
+	uid  uint32
+	gid  uint32
+	caps []string
+}
+
+type scanner struct {
+	opts    Options         // Scan options
+	users   uinfo.UserOps   // Resolves owner uid to username
+	allowed map[string]bool // Capabilities not reported
+}
+
+// NewScanner inits the interface for auditing permissions
+func NewScanner(opts Options) Scanner {
+	allowed := make(map[string]bool)
+	for _, c := range opts.AllowedCaps {
+		allowed[strings.ToLower(c)] = true
+	}
+
+	return &scanner{
+		opts:    opts,
+		users:   uinfo.NewUserOps(),
+		allowed: allowed,
+	}
+}
+
+// Scan walks root and reports world-writable files,
+// setuid / setgid files, files of unknown owners and capabilities.
+func (s *scanner) Scan(root string) (*Report, error) {
+
+	root = filepath.Clean(root)
+	owners := make(map[uint32]string)
+	unknownUids := make(map[uint32]bool)
+	unknownGids := make(map[uint32]bool)
+	r := &Report{
+		Root:     root,
+		Findings: []Finding{},
+	}
+
+	onResult := func(res pool.Result) {
+		if res.Err != nil {
+			log.Warn("Error in reading file: ", res.Err)
+			r.Errors++
+			return
+		}
+
+		fs := res.Value.(fileStat)
+		r.Files++
+
+		if _, ok := owners[fs.uid]; !ok && !unknownUids[fs.uid] {
+			u, err := s.users.GetByUid(strconv.FormatUint(uint64(fs.uid), 10))
+			if err != nil {
+				unknownUids[fs.uid] = true
+			} else {
+				owners[fs.uid] = u.Username
+			}
+		}
+		if _, ok := unknownGids[fs.gid]; !ok {
+			_, err := user.LookupGroupId(strconv.FormatUint(uint64(fs.gid), 10))
+			unknownGids[fs.gid] = err != nil
+		}
+
+		newFinding := func(kind, detail string) Finding {
+			return Finding{
+				Path:     fs.path,
+				Kind:     kind,
+				Mode:     fmt.Sprintf("%04o", fs.perm),
+				Uid:      strconv.FormatUint(uint64(fs.uid), 10),
+				Gid:      strconv.FormatUint(uint64(fs.gid), 10),
+				Username: owners[fs.uid],
+				Detail:   detail,
+			}
+		}
+
+		for _, kind := range s.check(fs, unknownUids[fs.uid], unknownGids[fs.gid]) {
+			r.Findings = append(r.Findings, newFinding(kind, ""))
+		}
+		if caps := s.unexpected(fs.caps); len(caps) > 0 {
+			r.Findings = append(r.Findings, newFinding(KindCapabilities, strings.Join(caps, ",")))
+		}
+	}
+
+	exclude := s.opts.Exclude
+	if !s.opts.PseudoFS {
+		// Mount points are absolute, walked paths are of root as given
+		abs, _ := filepath.Abs(root)
+		pseudo, err := localPseudoMounts(abs)
+		if err != nil {
+			log.Warn("Error in reading mounts, pseudo filesystems are walked: ", err)
+		}
+		for _, point := range pseudo {
+			log.Debug("Skipping pseudo filesystem ", point)
+			rel, _ := filepath.Rel(abs, point)
+			exclude = append(exclude, escapeGlob(filepath.Join(root, rel)))
+		}
+	}
+
+	err := walker.Walk(context.Background(), root, walker.Options{
+		Workers:  s.opts.Workers,
+		Exclude:  exclude,
+		Dirs:     true,
+		OnResult: onResult,
+	}, s.stat)
+	if err != nil && r.Errors == 0 {
+		return nil, err
+	}
+
+	sortFindings(r.Findings)
+
+	return r, nil
+}
+
+// Visitor collecting mode, owner and capabilities of file
+func (s *scanner) stat(ctx context.Context, path string, info os.FileInfo) (interface{}, error) {
+	fs := fileStat{path: path, mode: info.Mode()}
+
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		fs.perm = st.Mode & 07777
+		fs.uid = st.Uid
+		fs.gid = st.Gid
+	}
+
+	if info.Mode().IsRegular() {
+		caps, err := fileCapabilities(path)
+		if err != nil {
+			log.Debug("Error in reading capabilities of ", path, ": ", err)
+		}
+		fs.caps = caps
+	}
+
+	return fs, nil
+}
+
+// Returns kinds of findings for mode and owner of file. Modes are checked
+// of regular files and directories only, world-writable directories with
+// sticky bit are not reported. Symlinks, devices, sockets and fifos are
+// checked for owner only.
+func (s *scanner) check(fs fileStat, unknownUid, unknownGid bool) []string {
+	var kinds []string
+
+	switch {
+	case fs.mode.IsDir():
+		if fs.perm&syscall.S_IWOTH != 0 && fs.perm&syscall.S_ISVTX == 0 {
+			kinds = append(kinds, KindWorldWritable)
+		}
+	case fs.mode.IsRegular():
+		if fs.perm&syscall.S_IWOTH != 0 {
+			kinds = append(kinds, KindWorldWritable)
+		}
+		if fs.perm&syscall.S_ISUID != 0 {
+			kinds = append(kinds, KindSetuid)
+		}
+		if fs.perm&syscall.S_ISGID != 0 {
+			kinds = append(kinds, KindSetgid)
+		}
+	}
+
+	if unknownUid {
+		kinds = append(kinds, KindUnknownOwner)
+	}
+	if unknownGid {
+		kinds = append(kinds, KindUnknownGroup)
+	}
+
+	return kinds
+}
+
+// Mount table of current process
+const mountInfo = "/proc/self/mountinfo"
+
+// Types of pseudo filesystems, tmpfs is pseudo below /run only
+var pseudoTypes = map[string]bool{
+	"proc": true, "sysfs": true, "devtmpfs": true, "devpts": true,
+	"cgroup": true, "cgroup2": true, "securityfs": true, "debugfs": true,
+	"tracefs": true, "pstore": true, "bpf": true, "configfs": true,
+	"fusectl": true, "mqueue": true, "hugetlbfs": true, "binfmt_misc": true,
+	"efivarfs": true, "autofs": true,
+}
+
+// PseudoMounts returns mount points of pseudo filesystems below root.
+// Mounts containing root are not returned, root inside of pseudo
+// filesystem is audited as asked.
+func PseudoMounts(list []mounts.Mount, root string) []string {
+	var points []string
+	for _, m := range list {
+		point := filepath.Clean(m.Point)
+		pseudo := pseudoTypes[m.Type] ||
+			(m.Type == "tmpfs" && (point == "/run" || strings.HasPrefix(point, "/run/")))
+		if !pseudo || !below(point, root) {
+			continue
+		}
+		points = append(points, point)
+	}
+
+	return points
+}
+
+// Returns pseudo mounts of local mount table below root
+func localPseudoMounts(root string) ([]string, error) {
+	file, err := os.Open(mountInfo)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	list, err := mounts.Parse(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return PseudoMounts(list, root), nil
+}
+
+// Returns true if path is strictly below dir
+func below(path, dir string) bool {
+	if dir == "/" {
+		return path != "/"
+	}
+
+	return strings.HasPrefix(path, dir+"/")
+}
+
+// Escapes glob characters of path, excluded as literal pattern
+func escapeGlob(path string) string {
+	var sb strings.Builder
+	for _, c := range path {
+		if strings.ContainsRune(`*?[\`, c) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(c)
+	}
+
+	return sb.String()
+}
+
+// Returns capabilities not allowed by options
+func (s *scanner) unexpected(caps []string) []string {
+	var names []string
+	for _, c := range caps {
+		if !s.allowed[c] {
+			names = append(names, c)
+		}
+	}
+
+	return names
+}
+
+// Load reads report saved in json as baseline
+func Load(file string) (*Report, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Compare returns findings added and removed since baseline
+func Compare(baseline, current *Report) *Diff {
+	d := &Diff{
+		Added:   []Finding{},
+		Removed: []Finding{},
+	}
+
+	before := make(map[string]Finding)
+	for _, f := range baseline.Findings {
+		before[findingKey(f)] = f
+	}
+
+	for _, f := range current.Findings {
+		key := findingKey(f)
+		if _, ok := before[key]; ok {
+			delete(before, key)
+			continue
+		}
+		d.Added = append(d.Added, f)
+	}
+
+	for _, f := range before {
+		d.Removed = append(d.Removed, f)
+	}
+
+	sortFindings(d.Added)
+	sortFindings(d.Removed)
+
+	return d
+}
+
+// Findings are same when path, kind and detail match
+func findingKey(f Finding) string {
+	return f.Path + "\x00" + f.Kind + "\x00" + f.Detail
+}
+
+// Sorts findings by path and kind
+func sortFindings(findings []Finding) {
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Path == findings[j].Path {
+			return findings[i].Kind < findings[j].Kind
+		}
+		return findings[i].Path < findings[j].Path
+	})
+}
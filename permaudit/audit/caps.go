@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"encoding/binary"
+	"errors"
+	"syscall"
+
+	"github.com/prashant-sb/go-utils/privs"
+)
+
+const (
+	capXattr     string = "security.capability" // Extended attribute of file capabilities
+	capRevMask   uint32 = 0xFF000000            // Revision bits of magic
+	capRevision1 uint32 = 0x01000000            // 32 bit capabilities
+	capRevision2 uint32 = 0x02000000            // 64 bit capabilities
+	capRevision3 uint32 = 0x03000000            // 64 bit capabilities with root id
+)
+
+// Reads capabilities of file, nil if file has none
+func fileCapabilities(path string) ([]string, error) {
+	buf := make([]byte, 64)
+
+	n, err := syscall.Getxattr(path, capXattr, buf)
+	if err == syscall.ENODATA || err == syscall.ENOTSUP {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseCapabilities(buf[:n])
+}
+
+// ParseCapabilities decodes security.capability attribute to names
+// of permitted and inheritable capabilities.
+func ParseCapabilities(data []byte) ([]string, error) {
+	if len(data) < 4 {
+		return nil, errors.New("Invalid capability attribute.")
+	}
+
+	magic := binary.LittleEndian.Uint32(data)
+	words := 2
+	switch magic & capRevMask {
+	case capRevision1:
+		words = 1
+	case capRevision2, capRevision3:
+	default:
+		return nil, errors.New("Unsupported capability revision.")
+	}
+	if len(data) < 4+words*8 {
+		return nil, errors.New("Invalid capability attribute.")
+	}
+
+	var mask uint64
+	for i := 0; i < words; i++ {
+		permitted := binary.LittleEndian.Uint32(data[4+i*8:])
+		inheritable := binary.LittleEndian.Uint32(data[8+i*8:])
+		mask |= uint64(permitted|inheritable) << uint(32*i)
+	}
+
+	names := []string{}
+	for c := uint(0); c < 64; c++ {
+		if mask&(1<<c) == 0 {
+			continue
+		}
+		names = append(names, privs.Capability(c).String())
+	}
+
+	return names, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/permaudit/audit"
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+)
+
+// Exit codes of audit
+const (
+	exitOK       = 0
+	exitFindings = 1
+	exitError    = 2
+)
+
+// CLI Flags:
+//
+// -dest <dir>            : Root directory for audit / will be default
+// -exclude <p1,p2>       : Skips paths matching glob patterns
+// -allow-caps <c1,c2>    : Capabilities not reported, e.g. cap_net_bind_service
+// -baseline <json>       : Prints findings added and removed since saved report
+// -pseudo-fs             : Audits pseudo filesystems, as proc, sysfs and tmpfs of /run
+var (
+	dest      = flag.String("dest", "/", "Root directory for auditing permissions")
+	exclude   = flag.String("exclude", "", "Comma separated glob patterns of skipped paths")
+	allowCaps = flag.String("allow-caps", "", "Comma separated capabilities not reported")
+	baseline  = flag.String("baseline", "", "Saved report in json for diff")
+	pseudoFS  = flag.Bool("pseudo-fs", false, "Audits pseudo filesystems, proc, sysfs, devtmpfs and tmpfs of /run")
+	workers   = flag.Int("workers", runtime.NumCPU(), "Number of concurrent workers")
+)
+
+// Splits comma separated flag value
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
+
+func main() {
+	flag.Parse()
+
+	var base *audit.Report
+	if *baseline != "" {
+		var err error
+		if base, err = audit.Load(*baseline); err != nil {
+			log.Error("Error in reading ", *baseline, ": ", err)
+			os.Exit(exitError)
+		}
+	}
+
+	s := audit.NewScanner(audit.Options{
+		Workers:     *workers,
+		Exclude:     split(*exclude),
+		AllowedCaps: split(*allowCaps),
+		PseudoFS:    *pseudoFS,
+	})
+
+	r, err := s.Scan(*dest)
+	if err != nil {
+		log.Error("Error in scanning ", *dest, ": ", err)
+		os.Exit(exitError)
+	}
+
+	var out interface{} = r
+	found := len(r.Findings) > 0
+	if base != nil {
+		d := audit.Compare(base, r)
+		out, found = d, len(d.Added) > 0
+	}
+
+	jsonOut, err := uinfo.Decode(out)
+	if err != nil {
+		log.Error("Error in decode: ", err)
+		os.Exit(exitError)
+	}
+	fmt.Printf("%v\n", jsonOut)
+
+	if found {
+		os.Exit(exitFindings)
+	}
+	os.Exit(exitOK)
+}
@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/mountinfo/mounts"
+	"github.com/prashant-sb/go-utils/permaudit/audit"
+)
+
+func TestParseCapabilities(t *testing.T) {
+	// Revision 2, effective, cap_net_bind_service and cap_net_raw permitted
+	data := []byte{
+		0x01, 0x00, 0x00, 0x02,
+		0x00, 0x24, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}
+
+	caps, err := audit.ParseCapabilities(data)
+	if err != nil {
+		t.Errorf("ParseCapabilities() FAILED: %v", err)
+	}
+	if want := []string{"cap_net_bind_service", "cap_net_raw"}; !reflect.DeepEqual(caps, want) {
+		t.Errorf("ParseCapabilities() FAILED, got %v, want %v", caps, want)
+	}
+
+	if _, err := audit.ParseCapabilities([]byte{0x01, 0x00, 0x00, 0x09}); err == nil {
+		t.Errorf("ParseCapabilities() FAILED, accepted invalid attribute")
+	}
+	t.Logf("ParseCapabilities() PASSED")
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &audit.Report{Findings: []audit.Finding{
+		{Path: "/usr/bin/passwd", Kind: audit.KindSetuid},
+		{Path: "/srv/share", Kind: audit.KindWorldWritable},
+		{Path: "/usr/bin/ping", Kind: audit.KindCapabilities, Detail: "cap_net_raw"},
+	}}
+	current := &audit.Report{Findings: []audit.Finding{
+		{Path: "/usr/bin/passwd", Kind: audit.KindSetuid},
+		{Path: "/usr/bin/ping", Kind: audit.KindCapabilities, Detail: "cap_net_admin,cap_net_raw"},
+		{Path: "/tmp/sh", Kind: audit.KindSetuid},
+	}}
+
+	d := audit.Compare(baseline, current)
+
+	if len(d.Added) != 2 || d.Added[0].Path != "/tmp/sh" || d.Added[1].Path != "/usr/bin/ping" {
+		t.Errorf("Compare() FAILED, added %+v", d.Added)
+	}
+	if len(d.Removed) != 2 || d.Removed[0].Path != "/srv/share" || d.Removed[1].Path != "/usr/bin/ping" {
+		t.Errorf("Compare() FAILED, removed %+v", d.Removed)
+	}
+	t.Logf("Compare() PASSED")
+}
+
+func TestScan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "permaudit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "file")
+	fifo := filepath.Join(dir, "fifo")
+	if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Mkfifo(fifo, 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{file, fifo} {
+		if err := os.Chmod(path, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := audit.NewScanner(audit.Options{}).Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Findings) != 1 || r.Findings[0].Path != file || r.Findings[0].Kind != audit.KindWorldWritable {
+		t.Errorf("Scan() FAILED, findings %+v", r.Findings)
+	}
+	t.Logf("Scan() PASSED")
+}
+
+func TestPseudoMounts(t *testing.T) {
+	table := `23 28 0:22 / /proc rw,relatime - proc proc rw
+24 28 0:23 / /sys rw,relatime - sysfs sysfs rw
+25 28 0:6 / /dev rw,relatime - devtmpfs devtmpfs rw,mode=755
+26 25 0:24 / /dev/shm rw,relatime - tmpfs tmpfs rw
+28 1 254:0 / / rw,relatime - ext4 /dev/vda rw
+33 28 0:29 / /run rw,nosuid - tmpfs tmpfs rw,mode=755
+34 33 0:30 / /run/user/1000 rw,nosuid - tmpfs tmpfs rw,mode=700
+35 28 0:31 / /tmp rw,nosuid - tmpfs tmpfs rw
+`
+	list, err := mounts.Parse(strings.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"/proc", "/sys", "/dev", "/run", "/run/user/1000"}
+	if got := audit.PseudoMounts(list, "/"); !reflect.DeepEqual(got, want) {
+		t.Errorf("PseudoMounts() FAILED, got %v, want %v", got, want)
+	}
+	if got := audit.PseudoMounts(list, "/proc"); len(got) != 0 {
+		t.Errorf("PseudoMounts() FAILED, root /proc skipped %v", got)
+	}
+	if got := audit.PseudoMounts(list, "/run"); !reflect.DeepEqual(got, []string{"/run/user/1000"}) {
+		t.Errorf("PseudoMounts() FAILED, root /run skipped %v", got)
+	}
+	t.Logf("PseudoMounts() PASSED")
+}
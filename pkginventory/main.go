@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	pkg "github.com/prashant-sb/go-utils/pkginventory/packages"
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+)
+
+// CLI Flags, installed packages are listed without flags:
+//
+// -diff -old <json> -new <json> : Diff of two saved inventories
+// -diff -old <json>             : Diff of saved inventory with host
+var (
+	diff   = flag.Bool("diff", false, "Prints diff between two inventories")
+	oldInv = flag.String("old", "", "Saved inventory in json for diff")
+	newInv = flag.String("new", "", "Saved inventory in json for diff, host inventory will be default")
+)
+
+// Loads inventory from file, or lists the host inventory
+func inventory(file string) (*pkg.Inventory, error) {
+	if file != "" {
+		return pkg.Load(file)
+	}
+
+	return pkg.NewLister().List()
+}
+
+func main() {
+	flag.Parse()
+
+	var out interface{}
+	var err error
+
+	if *diff {
+		var old, cur *pkg.Inventory
+		if old, err = pkg.Load(*oldInv); err != nil {
+			log.Error("Error in reading ", *oldInv, ": ", err)
+			return
+		}
+		if cur, err = inventory(*newInv); err != nil {
+			log.Error("Error in reading inventory: ", err)
+			return
+		}
+		out = pkg.Compare(old, cur)
+	} else if out, err = inventory(""); err != nil {
+		log.Error("Error in listing packages: ", err)
+		return
+	}
+
+	jsonOut, err := uinfo.Decode(out)
+	if err != nil {
+		log.Error("Error in decode: ", err)
+		return
+	}
+
+	fmt.Printf("%v\n", jsonOut)
+}
@@ -0,0 +1,240 @@
+package packages
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Package databases
+const (
+	dpkgStatus string = "/var/lib/dpkg/status" // Installed packages of dpkg
+	rpmCmd     string = "rpm"                  // Command for querying rpm database
+
+	// Query format of name, epoch:version-release and arch
+	rpmFormat string = "%{NAME}\t%{EPOCHNUM}:%{VERSION}-%{RELEASE}\t%{ARCH}\n"
+
+	Dpkg string = "dpkg"
+	Rpm  string = "rpm"
+)
+
+// Package installed on the host
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Arch    string `json:"arch"`
+}
+
+// Inventory of installed packages
+type Inventory struct {
+	// Manager is the package database, dpkg | rpm.
+	Manager  string    `json:"manager"`
+	Packages []Package `json:"packages"`
+}
+
+// Change of package version between inventories
+type Change struct {
+	Name   string `json:"name"`
+	Arch   string `json:"arch"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// Diff of two inventories
+type Diff struct {
+	Added   []Package `json:"added"`
+	Removed []Package `json:"removed"`
+	Changed []Change  `json:"changed"`
+}
+
+// Lister interface lists installed packages
+type Lister interface {
+	List() (*Inventory, error)
+}
+
+type lister struct {
+	status string // Path of dpkg status file
+}
+
+// NewLister inits the interface for package inventory
+func NewLister() Lister {
+	return &lister{
+		status: dpkgStatus,
+	}
+}
+
+// List reads dpkg database if available, otherwise queries rpm
+func (l *lister) List() (*Inventory, error) {
+	var pkgs []Package
+
+	inv := &Inventory{}
+	if file, err := os.Open(l.status); err == nil {
+		defer file.Close()
+
+		inv.Manager = Dpkg
+		if pkgs, err = ParseDpkgStatus(file); err != nil {
+			return nil, err
+		}
+	} else if _, err := exec.LookPath(rpmCmd); err == nil {
+		inv.Manager = Rpm
+		if pkgs, err = l.queryRpm(); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, errors.New("No dpkg or rpm package database found.")
+	}
+
+	sortPackages(pkgs)
+	inv.Packages = pkgs
+
+	return inv, nil
+}
+
+// ParseDpkgStatus parses installed packages from dpkg status file
+func ParseDpkgStatus(rd io.Reader) ([]Package, error) {
+	pkgs := []Package{}
+	var pkg Package
+	var installed bool
+
+	flush := func() {
+		if installed && pkg.Name != "" {
+			pkgs = append(pkgs, pkg)
+		}
+		pkg, installed = Package{}, false
+	}
+
+	s := bufio.NewScanner(rd)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for s.Scan() {
+		line := s.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		// Continuation of multi-line field
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+
+		attrs := strings.SplitN(line, ":", 2)
+		if len(attrs) != 2 {
+			continue
+		}
+		val := strings.TrimSpace(attrs[1])
+
+		switch attrs[0] {
+		case "Package":
+			pkg.Name = val
+		case "Version":
+			pkg.Version = val
+		case "Architecture":
+			pkg.Arch = val
+		case "Status":
+			installed = strings.HasSuffix(val, " installed")
+		}
+	}
+	flush()
+
+	return pkgs, s.Err()
+}
+
+// Queries installed packages from rpm database
+func (l *lister) queryRpm() ([]Package, error) {
+	pkgs := []Package{}
+
+	out, err := exec.Command(rpmCmd, "-qa", "--queryformat", rpmFormat).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		pkgs = append(pkgs, Package{
+			Name:    fields[0],
+			Version: strings.TrimPrefix(fields[1], "0:"),
+			Arch:    fields[2],
+		})
+	}
+
+	return pkgs, nil
+}
+
+// Load reads inventory from json file
+func Load(file string) (*Inventory, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &Inventory{}
+	if err := json.Unmarshal(data, inv); err != nil {
+		return nil, err
+	}
+
+	return inv, nil
+}
+
+// Compare returns packages added, removed and changed in new inventory.
+// Packages are matched by name and arch.
+func Compare(old, new *Inventory) *Diff {
+	d := &Diff{
+		Added:   []Package{},
+		Removed: []Package{},
+		Changed: []Change{},
+	}
+
+	before := make(map[string]Package)
+	for _, p := range old.Packages {
+		before[p.Name+":"+p.Arch] = p
+	}
+
+	for _, p := range new.Packages {
+		key := p.Name + ":" + p.Arch
+		op, ok := before[key]
+		if !ok {
+			d.Added = append(d.Added, p)
+			continue
+		}
+		delete(before, key)
+
+		if op.Version != p.Version {
+			d.Changed = append(d.Changed, Change{
+				Name:   p.Name,
+				Arch:   p.Arch,
+				Before: op.Version,
+				After:  p.Version,
+			})
+		}
+	}
+
+	for _, p := range before {
+		d.Removed = append(d.Removed, p)
+	}
+
+	sortPackages(d.Added)
+	sortPackages(d.Removed)
+	sort.Slice(d.Changed, func(i, j int) bool {
+		return d.Changed[i].Name < d.Changed[j].Name
+	})
+
+	return d
+}
+
+// Sorts packages by name and arch
+func sortPackages(pkgs []Package) {
+	sort.Slice(pkgs, func(i, j int) bool {
+		if pkgs[i].Name == pkgs[j].Name {
+			return pkgs[i].Arch < pkgs[j].Arch
+		}
+		return pkgs[i].Name < pkgs[j].Name
+	})
+}
@@ -0,0 +1,60 @@
+package packages
+
+import (
+	"strings"
+	"testing"
+
+	pkg "github.com/prashant-sb/go-utils/pkginventory/packages"
+)
+
+const testStatus = `Package: adduser
+Status: install ok installed
+Architecture: all
+Version: 3.134
+Description: add and remove users and groups
+ This package includes the 'adduser' and 'deluser' commands.
+
+Package: removed
+Status: deinstall ok config-files
+Architecture: amd64
+Version: 1.0
+
+Package: bash
+Status: install ok installed
+Architecture: amd64
+Version: 5.2.15-2
+`
+
+func TestParseDpkgStatus(t *testing.T) {
+	pkgs, err := pkg.ParseDpkgStatus(strings.NewReader(testStatus))
+	if err != nil {
+		t.Errorf("ParseDpkgStatus() FAILED with %v", err.Error())
+		return
+	}
+
+	if len(pkgs) != 2 || pkgs[1].Name != "bash" || pkgs[1].Version != "5.2.15-2" {
+		t.Errorf("ParseDpkgStatus() FAILED, got %+v", pkgs)
+		return
+	}
+	t.Logf("ParseDpkgStatus() PASSED")
+}
+
+func TestCompare(t *testing.T) {
+	old := &pkg.Inventory{Packages: []pkg.Package{
+		{Name: "bash", Version: "5.1", Arch: "amd64"},
+		{Name: "vim", Version: "9.0", Arch: "amd64"},
+	}}
+	cur := &pkg.Inventory{Packages: []pkg.Package{
+		{Name: "bash", Version: "5.2", Arch: "amd64"},
+		{Name: "curl", Version: "7.88", Arch: "amd64"},
+	}}
+
+	d := pkg.Compare(old, cur)
+	if len(d.Added) != 1 || d.Added[0].Name != "curl" ||
+		len(d.Removed) != 1 || d.Removed[0].Name != "vim" ||
+		len(d.Changed) != 1 || d.Changed[0].After != "5.2" {
+		t.Errorf("Compare() FAILED, got %+v", d)
+		return
+	}
+	t.Logf("Compare() PASSED")
+}
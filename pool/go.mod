@@ -0,0 +1,3 @@
+module github.com/prashant-sb/go-utils/pool
+
+go 1.13
@@ -0,0 +1,209 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Task is the unit of work executed by pool workers.
+// Task should return early when context is done.
+type Task func(ctx context.Context) (interface{}, error)
+
+// Result of the executed task
+type Result struct {
+	Index int         // Submission order of the task
+	Value interface{} // Value returned by the task
+	Err   error       // Error returned by the task
+}
+
+// Options for the worker pool
+type Options struct {
+	// Workers is the number of concurrent workers,
+	// number of CPUs will be default.
+	Workers int
+
+	// Ordered delivers results in submission order
+	// instead of completion order.
+	Ordered bool
+
+	// StopOnError cancels the pending tasks on first error.
+	StopOnError bool
+
+	// OnResult is called for each result from single goroutine.
+	// Results are collected and returned by Wait() when not set.
+	OnResult func(Result)
+}
+
+// Errors aggregates errors returned by tasks
+type Errors []error
+
+// Pool interface for bounded workers
+type Pool interface {
+	// Submit queues the task, blocks while all workers are busy.
+	Submit(Task) error
+
+	// Wait waits for submitted tasks, returns collected results
+	// and aggregated errors. Pool must not be used after Wait.
+	Wait() ([]Result, error)
+}
+
+type indexedTask struct {
+	index int
+	task  Task
+}
+
+type workerPool struct {
+	ctx     context.Context    // Context passed to tasks
+	cancel  context.CancelFunc // Cancels the pending tasks
+	opts    Options            // Pool options
+	tasks   chan indexedTask   // Queued tasks
+	results chan Result        // Results from workers
+	workers sync.WaitGroup     // Running workers
+	done    chan struct{}      // Closed when collector returns
+	next    int                // Index of next submitted task
+
+	collected []Result // Results when OnResult is not set
+	errs      Errors   // Errors returned by tasks
+}
+
+// NewPool starts the workers bound to given context
+func NewPool(ctx context.Context, opts Options) Pool {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	p := &workerPool{
+		ctx:     cctx,
+		cancel:  cancel,
+		opts:    opts,
+		tasks:   make(chan indexedTask),
+		results: make(chan Result, opts.Workers),
+		done:    make(chan struct{}),
+	}
+
+	for i := 0; i < opts.Workers; i++ {
+		p.workers.Add(1)
+		go p.worker()
+	}
+	go p.collect()
+
+	return p
+}
+
+// Submit queues the task for workers
+func (p *workerPool) Submit(t Task) error {
+	select {
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	case p.tasks <- indexedTask{index: p.next, task: t}:
+		p.next++
+	}
+
+	return nil
+}
+
+// Wait for all workers and collector to finish
+func (p *workerPool) Wait() ([]Result, error) {
+	close(p.tasks)
+	p.workers.Wait()
+	close(p.results)
+	<-p.done
+
+	canceled := p.ctx.Err()
+	p.cancel()
+
+	if len(p.errs) == 0 && canceled != nil {
+		p.errs = append(p.errs, canceled)
+	}
+
+	return p.collected, p.errs.Err()
+}
+
+// worker executes the queued tasks, skips them once context is done
+func (p *workerPool) worker() {
+	defer p.workers.Done()
+
+	for it := range p.tasks {
+		if err := p.ctx.Err(); err != nil {
+			p.results <- Result{Index: it.index, Err: err}
+			continue
+		}
+
+		v, err := it.task(p.ctx)
+		if err != nil && p.opts.StopOnError {
+			p.cancel()
+		}
+		p.results <- Result{Index: it.index, Value: v, Err: err}
+	}
+}
+
+// collect receives the results and reorders them if required
+func (p *workerPool) collect() {
+	defer close(p.done)
+
+	pending := make(map[int]Result)
+	next := 0
+
+	for r := range p.results {
+		if !p.opts.Ordered {
+			p.emit(r)
+			continue
+		}
+
+		pending[r.Index] = r
+		for {
+			nr, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			p.emit(nr)
+			next++
+		}
+	}
+}
+
+// emit delivers the result and records task error
+func (p *workerPool) emit(r Result) {
+	if r.Err != nil && !isCanceled(r.Err) {
+		p.errs = append(p.errs, r.Err)
+	}
+
+	if p.opts.OnResult != nil {
+		p.opts.OnResult(r)
+		return
+	}
+	p.collected = append(p.collected, r)
+}
+
+// Returns true for errors of skipped tasks
+func isCanceled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// Error joins the aggregated errors
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strconv.Itoa(len(e)) + " errors: " + strings.Join(msgs, "; ")
+}
+
+// Err returns nil for no errors, or aggregated errors
+func (e Errors) Err() error {
+	switch len(e) {
+	case 0:
+		return nil
+	case 1:
+		return e[0]
+	}
+
+	return e
+}
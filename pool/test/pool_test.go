@@ -0,0 +1,100 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prashant-sb/go-utils/pool"
+)
+
+const testTasks = 50
+
+func TestOrdered(t *testing.T) {
+	var got []int
+
+	p := pool.NewPool(context.Background(), pool.Options{
+		Workers: 4,
+		Ordered: true,
+		OnResult: func(r pool.Result) {
+			got = append(got, r.Value.(int))
+		},
+	})
+
+	for i := 0; i < testTasks; i++ {
+		i := i
+		p.Submit(func(ctx context.Context) (interface{}, error) {
+			time.Sleep(time.Duration(testTasks-i) * time.Microsecond)
+			return i, nil
+		})
+	}
+
+	if _, err := p.Wait(); err != nil {
+		t.Errorf("Wait() FAILED with %v", err.Error())
+		return
+	}
+
+	for i := range got {
+		if got[i] != i {
+			t.Errorf("Ordered FAILED, expected: %v got: %v", i, got[i])
+			return
+		}
+	}
+	if len(got) != testTasks {
+		t.Errorf("Ordered FAILED, expected %v results got %v", testTasks, len(got))
+		return
+	}
+	t.Logf("Ordered PASSED")
+}
+
+func TestErrors(t *testing.T) {
+	p := pool.NewPool(context.Background(), pool.Options{Workers: 2})
+
+	for i := 0; i < testTasks; i++ {
+		i := i
+		p.Submit(func(ctx context.Context) (interface{}, error) {
+			if i%10 == 0 {
+				return nil, errors.New("task failed")
+			}
+			return i, nil
+		})
+	}
+
+	results, err := p.Wait()
+	errs, ok := err.(pool.Errors)
+	if !ok || len(errs) != testTasks/10 {
+		t.Errorf("Errors FAILED, got %v", err)
+		return
+	}
+	if len(results) != testTasks {
+		t.Errorf("Errors FAILED, expected %v results got %v", testTasks, len(results))
+		return
+	}
+	t.Logf("Errors PASSED")
+}
+
+func TestStopOnError(t *testing.T) {
+	executed := 0
+
+	p := pool.NewPool(context.Background(), pool.Options{
+		Workers:     1,
+		StopOnError: true,
+	})
+
+	for i := 0; i < testTasks; i++ {
+		err := p.Submit(func(ctx context.Context) (interface{}, error) {
+			executed++
+			return nil, errors.New("task failed")
+		})
+		if err != nil {
+			break
+		}
+	}
+
+	if _, err := p.Wait(); err == nil || executed == testTasks {
+		t.Errorf("StopOnError FAILED, executed %v tasks", executed)
+		return
+	}
+	t.Logf("StopOnError PASSED")
+}
@@ -0,0 +1,3 @@
+module github.com/prashant-sb/go-utils/privs
+
+go 1.13
@@ -0,0 +1,142 @@
+package privs
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Status of current process, lists capability sets
+const procStatus string = "/proc/self/status"
+
+// Capability is the number of linux capability
+type Capability uint
+
+// Capabilities checked by tools
+const (
+	CapChown         Capability = 0
+	CapDacOverride   Capability = 1
+	CapDacReadSearch Capability = 2
+	CapFowner        Capability = 3
+	CapSetgid        Capability = 6
+	CapSetuid        Capability = 7
+	CapNetAdmin      Capability = 12
+	CapSysAdmin      Capability = 21
+	CapAuditControl  Capability = 30
+)
+
+// CapNames are names of capabilities by number, as of capabilities(7)
+var CapNames = []string{
+	"cap_chown", "cap_dac_override", "cap_dac_read_search", "cap_fowner",
+	"cap_fsetid", "cap_kill", "cap_setgid", "cap_setuid", "cap_setpcap",
+	"cap_linux_immutable", "cap_net_bind_service", "cap_net_broadcast",
+	"cap_net_admin", "cap_net_raw", "cap_ipc_lock", "cap_ipc_owner",
+	"cap_sys_module", "cap_sys_rawio", "cap_sys_chroot", "cap_sys_ptrace",
+	"cap_sys_pacct", "cap_sys_admin", "cap_sys_boot", "cap_sys_nice",
+	"cap_sys_resource", "cap_sys_time", "cap_sys_tty_config", "cap_mknod",
+	"cap_lease", "cap_audit_write", "cap_audit_control", "cap_setfcap",
+	"cap_mac_override", "cap_mac_admin", "cap_syslog", "cap_wake_alarm",
+	"cap_block_suspend", "cap_audit_read", "cap_perfmon", "cap_bpf",
+	"cap_checkpoint_restore",
+}
+
+// String returns name of capability, as cap_setuid
+func (c Capability) String() string {
+	if int(c) < len(CapNames) {
+		return CapNames[c]
+	}
+
+	return "cap_" + strconv.FormatUint(uint64(c), 10)
+}
+
+// Set is the bit mask of capabilities
+type Set uint64
+
+// Has returns true if capability is in set
+func (s Set) Has(c Capability) bool {
+	return c < 64 && s&(1<<c) != 0
+}
+
+// ParseSet parses hex mask of capabilities, as CapEff of /proc status
+func ParseSet(mask string) (Set, error) {
+	n, err := strconv.ParseUint(strings.TrimSpace(mask), 16, 64)
+	if err != nil {
+		return 0, errors.New("Invalid capability mask " + mask)
+	}
+
+	return Set(n), nil
+}
+
+// Effective returns effective capabilities of current process
+func Effective() (Set, error) {
+	f, err := os.Open(procStatus)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "CapEff:") {
+			return ParseSet(strings.TrimPrefix(line, "CapEff:"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, errors.New("CapEff not found in " + procStatus)
+}
+
+// Missing returns capabilities not in set
+func Missing(s Set, caps ...Capability) []Capability {
+	var missing []Capability
+	for _, c := range caps {
+		if !s.Has(c) {
+			missing = append(missing, c)
+		}
+	}
+
+	return missing
+}
+
+// Require returns error naming capabilities missing from current process.
+// Capabilities are checked for root too, root in containers may run
+// without them.
+func Require(caps ...Capability) error {
+	s, err := Effective()
+	if err != nil {
+		return err
+	}
+
+	missing := Missing(s, caps...)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return missingError(missing)
+}
+
+// RequireRoot returns error when effective uid is not root
+func RequireRoot() error {
+	if os.Geteuid() != 0 {
+		return errors.New("Missing privilege: must run as root, effective uid is " +
+			strconv.Itoa(os.Geteuid()) + ".")
+	}
+
+	return nil
+}
+
+// Returns error with names of capabilities. File capabilities of setcap
+// are not passed to commands run by tools, as useradd, so root is needed.
+func missingError(missing []Capability) error {
+	names := make([]string, len(missing))
+	for i, c := range missing {
+		names[i] = c.String()
+	}
+
+	return errors.New("Missing privilege " + strings.Join(names, ", ") + ": run as root.")
+}
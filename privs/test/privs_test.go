@@ -0,0 +1,50 @@
+package privs
+
+import (
+	"testing"
+
+	"github.com/prashant-sb/go-utils/privs"
+)
+
+func TestParseSet(t *testing.T) {
+	s, err := privs.ParseSet("0000000000000084\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.Has(privs.CapDacReadSearch) || !s.Has(privs.CapSetuid) || s.Has(privs.CapChown) {
+		t.Errorf("ParseSet() FAILED, got %x", uint64(s))
+	}
+
+	if _, err := privs.ParseSet("zz"); err == nil {
+		t.Errorf("ParseSet() FAILED, invalid mask parsed")
+	}
+	t.Logf("ParseSet() PASSED")
+}
+
+func TestMissing(t *testing.T) {
+	s, _ := privs.ParseSet("80")
+
+	missing := privs.Missing(s, privs.CapSetuid, privs.CapDacReadSearch, privs.CapChown)
+	if len(missing) != 2 || missing[0] != privs.CapDacReadSearch || missing[1] != privs.CapChown {
+		t.Errorf("Missing() FAILED, got %v", missing)
+	}
+	if privs.Missing(s, privs.CapSetuid) != nil {
+		t.Errorf("Missing() FAILED, held capability reported")
+	}
+	t.Logf("Missing() PASSED")
+}
+
+func TestCapabilityString(t *testing.T) {
+	if privs.CapDacReadSearch.String() != "cap_dac_read_search" || privs.Capability(99).String() != "cap_99" {
+		t.Errorf("String() FAILED, got %s", privs.CapDacReadSearch)
+	}
+	t.Logf("String() PASSED")
+}
+
+func TestEffective(t *testing.T) {
+	if _, err := privs.Effective(); err != nil {
+		t.Errorf("Effective() FAILED, %v", err)
+	}
+	t.Logf("Effective() PASSED")
+}
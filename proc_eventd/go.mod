@@ -2,4 +2,9 @@ module github.com/prashant-sb/go-utils/proc_eventd
 
 go 1.13
 
-require github.com/golang/glog v0.0.0-20160126235308-23def4e6c14b
+require (
+	github.com/golang/glog v0.0.0-20160126235308-23def4e6c14b
+	github.com/prashant-sb/go-utils/lifecycle v0.0.0
+)
+
+replace github.com/prashant-sb/go-utils/lifecycle => ../lifecycle
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"time"
 
 	log "github.com/golang/glog"
+	"github.com/prashant-sb/go-utils/lifecycle"
 	prc "github.com/prashant-sb/go-utils/proc_eventd/proc"
 )
 
@@ -15,6 +18,15 @@ var (
 func main() {
 	flag.Parse()
 
+	ctx, cancel := lifecycle.SignalContext(context.Background())
+	defer cancel()
+
+	shutdown := lifecycle.NewShutdown(5 * time.Second)
+	shutdown.OnShutdown("flush logs", func(ctx context.Context) error {
+		log.Flush()
+		return nil
+	})
+
 	procIter := prc.NewProcIterator()
 
 	if *list {
@@ -25,9 +37,13 @@ func main() {
 	}
 
 	if *wpid != 0 {
-		err := procIter.Watch(*wpid)
+		err := procIter.WatchContext(ctx, *wpid)
 		if err != nil {
 			log.Error("Error occurred while watching pid: ", *wpid)
 		}
 	}
+
+	if err := shutdown.Shutdown(); err != nil {
+		log.Error("Error in shutdown: ", err)
+	}
 }
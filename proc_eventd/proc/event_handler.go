@@ -1,6 +1,8 @@
 package proc
 
 import (
+	"context"
+
 	log "github.com/golang/glog"
 )
 
@@ -15,6 +17,7 @@ type Events struct {
 // User level interface for events
 type EventHandler interface {
 	Notify(uint64) error
+	NotifyContext(context.Context, uint64) error
 }
 
 // Initialize event
@@ -38,6 +41,11 @@ func NewEventHandler() (EventHandler, error) {
 // Waits till exit.
 // TODO: Add methods for subscriptions for Events
 func (e *Events) Notify(pid uint64) error {
+	return e.NotifyContext(context.Background(), pid)
+}
+
+// Create and watch for given pid till ctx is done.
+func (e *Events) NotifyContext(ctx context.Context, pid uint64) error {
 
 	notif, err := NewWatcher()
 	if err != nil {
@@ -46,7 +54,6 @@ func (e *Events) Notify(pid uint64) error {
 	}
 
 	defer notif.Close()
-	done := make(chan bool)
 
 	err = notif.Watch(pid, e.all)
 	if err != nil {
@@ -58,6 +65,8 @@ func (e *Events) Notify(pid uint64) error {
 	go func() {
 		for {
 			select {
+			case <-ctx.Done():
+				return
 			case ev := <-notif.Fork:
 				log.Info("Fork event:", *ev)
 			case ev := <-notif.Exec:
@@ -71,7 +80,8 @@ func (e *Events) Notify(pid uint64) error {
 			}
 		}
 	}()
-	<-done
+	<-ctx.Done()
+	log.Info("Stopped watching pid: ", pid)
 
 	return nil
 }
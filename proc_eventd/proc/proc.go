@@ -1,6 +1,7 @@
 package proc
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
@@ -19,6 +20,7 @@ const (
 type ProcIter interface {
 	List() error
 	Watch(pid uint64) error
+	WatchContext(ctx context.Context, pid uint64) error
 	GetProcMap() (map[string]*ProcMeta, error)
 }
 
@@ -121,13 +123,18 @@ func (pi *ProcEntry) GetProcMap() (map[string]*ProcMeta, error) {
 
 // Init and calls the process watcher
 func (pi *ProcEntry) Watch(pid uint64) error {
+	return pi.WatchContext(context.Background(), pid)
+}
+
+// Calls the process watcher till ctx is done
+func (pi *ProcEntry) WatchContext(ctx context.Context, pid uint64) error {
 
 	eh, err := NewEventHandler()
 	if err != nil {
 		return err
 	}
 
-	if err = eh.Notify(pid); err != nil {
+	if err = eh.NotifyContext(ctx, pid); err != nil {
 		return err
 	}
 
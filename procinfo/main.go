@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	prc "github.com/prashant-sb/go-utils/procinfo/procs"
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+)
+
+// CLI Flags:
+//
+// -list                 : List all processes
+// -list -user <user>    : List processes owned by username or uid
+// -list -pid <pid>      : List specific process
+var (
+	list = flag.Bool("list", false, "Lists the processes")
+	user = flag.String("user", "", "Filter processes by username or uid")
+	pid  = flag.Int("pid", 0, "List specific process")
+)
+
+func main() {
+	flag.Parse()
+
+	if !*list {
+		flag.Usage()
+		return
+	}
+
+	pl := prc.NewProcLister()
+
+	var out interface{}
+	var err error
+	if *pid != 0 {
+		out, err = pl.Get(*pid)
+	} else {
+		out, err = pl.List(prc.Filter{User: *user})
+	}
+	if err != nil {
+		log.Error("Error in listing processes: ", err)
+		return
+	}
+
+	jsonOut, err := uinfo.Decode(out)
+	if err != nil {
+		log.Error("Error in decode: ", err)
+		return
+	}
+
+	fmt.Printf("%v\n", jsonOut)
+}
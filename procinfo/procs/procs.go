@@ -0,0 +1,209 @@
+package procs
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+)
+
+// Process data parsed from /proc
+const (
+	procDir    = "/proc"
+	statusFile = "status"  // Name, Uid and VmRSS of process
+	cmdFile    = "cmdline" // NUL separated arguments
+	fdDir      = "fd"      // Open file descriptors
+)
+
+// Process inventory entry
+type Process struct {
+	// Pid is the process id.
+	Pid int `json:"pid"`
+
+	// Uid is the real user id of process owner.
+	Uid string `json:"uid"`
+
+	// Username is the login name of process owner,
+	// blank if uid is not known to system.
+	Username string `json:"userName,omitempty"`
+
+	// Name is the executable name.
+	Name string `json:"name"`
+
+	// Cmdline is the command line, empty for kernel threads.
+	Cmdline []string `json:"cmdline"`
+
+	// RSS is the resident memory in bytes.
+	RSS uint64 `json:"rssBytes"`
+
+	// OpenFiles is the count of open files,
+	// -1 when not permitted to read.
+	OpenFiles int `json:"openFiles"`
+}
+
+// ProcessList is the inventory of processes
+type ProcessList struct {
+	Processes []Process `json:"processes"`
+}
+
+// Filter for listing processes
+type Filter struct {
+	// User is the username or uid of process owner / optional
+	User string
+}
+
+// ProcLister interface lists processes from /proc
+type ProcLister interface {
+	List(Filter) (*ProcessList, error)
+	Get(int) (*Process, error)
+}
+
+type procLister struct {
+	root  string            // Mount point of procfs
+	users uinfo.UserOps     // Resolves uid to username
+	names map[string]string // Cache of resolved usernames
+}
+
+// NewProcLister inits the interface for process inventory
+func NewProcLister() ProcLister {
+	return NewProcListerWithRoot(procDir)
+}
+
+// NewProcListerWithRoot inits process inventory of procfs mounted
+// at root, as /proc of container or copy of it.
+func NewProcListerWithRoot(root string) ProcLister {
+	return &procLister{
+		root:  root,
+		users: uinfo.NewUserOps(),
+		names: make(map[string]string),
+	}
+}
+
+// List returns processes matching the filter, sorted by pid.
+// Processes exiting while listing, or not permitted to read, are skipped.
+func (pl *procLister) List(f Filter) (*ProcessList, error) {
+
+	dirs, err := ioutil.ReadDir(pl.root)
+	if err != nil {
+		log.Error("Error in reading dir ", pl.root)
+		return nil, err
+	}
+
+	plist := []Process{}
+	skipped := 0
+	for _, d := range dirs {
+		pid, err := strconv.Atoi(d.Name())
+		if err != nil || !d.IsDir() {
+			continue
+		}
+
+		p, err := pl.Get(pid)
+		if err != nil {
+			if skippable(err) {
+				skipped++
+				continue
+			}
+			return nil, err
+		}
+
+		if f.User != "" && f.User != p.Uid && f.User != p.Username {
+			continue
+		}
+		plist = append(plist, *p)
+	}
+
+	if skipped > 0 {
+		log.Debug(skipped, " processes exited or not readable while listing")
+	}
+
+	sort.Slice(plist, func(i, j int) bool {
+		return plist[i].Pid < plist[j].Pid
+	})
+
+	return &ProcessList{Processes: plist}, nil
+}
+
+// Get returns the process by pid
+func (pl *procLister) Get(pid int) (*Process, error) {
+
+	dir := filepath.Join(pl.root, strconv.Itoa(pid))
+
+	status, err := ioutil.ReadFile(filepath.Join(dir, statusFile))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Process{Pid: pid, Cmdline: []string{}}
+	for _, line := range strings.Split(string(status), "\n") {
+		attrs := strings.SplitN(line, ":", 2)
+		if len(attrs) != 2 {
+			continue
+		}
+		fields := strings.Fields(attrs[1])
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch attrs[0] {
+		case "Name":
+			p.Name = fields[0]
+		case "Uid":
+			p.Uid = fields[0]
+		case "VmRSS":
+			kb, _ := strconv.ParseUint(fields[0], 10, 64)
+			p.RSS = kb * 1024
+		}
+	}
+	p.Username = pl.username(p.Uid)
+
+	cmd, err := ioutil.ReadFile(filepath.Join(dir, cmdFile))
+	if err != nil {
+		return nil, err
+	}
+	for _, arg := range strings.Split(strings.TrimRight(string(cmd), "\x00"), "\x00") {
+		if arg != "" {
+			p.Cmdline = append(p.Cmdline, arg)
+		}
+	}
+
+	fds, err := ioutil.ReadDir(filepath.Join(dir, fdDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, err
+		}
+		p.OpenFiles = -1
+	} else {
+		p.OpenFiles = len(fds)
+	}
+
+	return p, nil
+}
+
+// Returns true if error of reading process is of process exited,
+// or of process not permitted to read.
+func skippable(err error) bool {
+	return os.IsNotExist(err) || errors.Is(err, syscall.ESRCH) || errors.Is(err, syscall.EACCES)
+}
+
+// Resolves uid to username with users package
+func (pl *procLister) username(uid string) string {
+
+	if name, ok := pl.names[uid]; ok {
+		return name
+	}
+
+	var name string
+	if u, err := pl.users.GetByUid(uid); err == nil {
+		name = u.Username
+	}
+	pl.names[uid] = name
+
+	return name
+}
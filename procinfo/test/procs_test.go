@@ -0,0 +1,121 @@
+package procs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/procinfo/procs"
+)
+
+// Writes files of procfs under root
+func writeProc(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	root, err := ioutil.TempDir("", "proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	writeProc(t, root, map[string]string{
+		"1/status":  "Name:\tinit\nUid:\t0\t0\t0\t0\nVmRSS:\t    1024 kB\n",
+		"1/cmdline": "/sbin/init\x00splash\x00",
+		"1/fd/0":    "",
+		"1/fd/1":    "",
+		"2/status":  "Name:\tkthreadd\nUid:\t0\t0\t0\t0\n",
+		"2/cmdline": "",
+		"2/fd/.k":   "",
+
+		// Exited while listing, status or fd gone
+		"7/cmdline":   "",
+		"8/status":    "Name:\tgone\nUid:\t0\t0\t0\t0\n",
+		"8/cmdline":   "",
+		"self/status": "Name:\tself\n",
+		"uptime":      "1.0 1.0\n",
+	})
+
+	pl := procs.NewProcListerWithRoot(root)
+	list, err := pl.List(procs.Filter{})
+	if err != nil || len(list.Processes) != 2 {
+		t.Fatalf("List() FAILED, %+v, %v", list, err)
+	}
+
+	p := list.Processes[0]
+	if p.Pid != 1 || p.Name != "init" || p.Uid != "0" || p.Username != "root" || p.RSS != 1024*1024 ||
+		len(p.Cmdline) != 2 || p.Cmdline[1] != "splash" || p.OpenFiles != 2 {
+		t.Errorf("List() FAILED, got %+v", p)
+	}
+	if k := list.Processes[1]; k.Name != "kthreadd" || len(k.Cmdline) != 0 || k.RSS != 0 {
+		t.Errorf("List() FAILED, kernel thread %+v", k)
+	}
+
+	for _, user := range []string{"root", "0"} {
+		if list, err := pl.List(procs.Filter{User: user}); err != nil || len(list.Processes) != 2 {
+			t.Errorf("List() FAILED, user %s listed %+v, %v", user, list, err)
+		}
+	}
+	if list, err := pl.List(procs.Filter{User: "nobody"}); err != nil || len(list.Processes) != 0 {
+		t.Errorf("List() FAILED, user nobody listed %+v, %v", list, err)
+	}
+	t.Logf("List() PASSED")
+}
+
+func TestListUnreadable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("Files are readable by root")
+	}
+
+	root, err := ioutil.TempDir("", "proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	writeProc(t, root, map[string]string{
+		"1/status":  "Name:\tinit\nUid:\t0\t0\t0\t0\n",
+		"1/cmdline": "",
+		"9/status":  "Name:\tsecret\nUid:\t0\t0\t0\t0\n",
+		"9/cmdline": "",
+	})
+	os.MkdirAll(filepath.Join(root, "1/fd"), 0755)
+	os.Chmod(filepath.Join(root, "9/status"), 0)
+
+	list, err := procs.NewProcListerWithRoot(root).List(procs.Filter{})
+	if err != nil || len(list.Processes) != 1 || list.Processes[0].Pid != 1 {
+		t.Errorf("List() FAILED, unreadable process %+v, %v", list, err)
+	}
+	t.Logf("ListUnreadable() PASSED")
+}
+
+func TestListHost(t *testing.T) {
+	pl := procs.NewProcLister()
+	list, err := pl.List(procs.Filter{})
+	if err != nil {
+		t.Fatalf("List() FAILED, %v", err)
+	}
+
+	found := false
+	for _, p := range list.Processes {
+		found = found || p.Pid == os.Getpid()
+	}
+	if !found {
+		t.Errorf("List() FAILED, pid %d of test not listed", os.Getpid())
+	}
+
+	if _, err := pl.Get(os.Getpid()); err != nil {
+		t.Errorf("Get() FAILED, %v", err)
+	}
+	t.Logf("ListHost() PASSED")
+}
@@ -0,0 +1,3 @@
+module github.com/prashant-sb/go-utils/retry
+
+go 1.13
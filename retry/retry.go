@@ -0,0 +1,151 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Defaults of options
+const (
+	defaultAttempts   = 3
+	defaultInitial    = 100 * time.Millisecond
+	defaultMax        = 10 * time.Second
+	defaultMultiplier = 2.0
+)
+
+// Source of jitter
+var (
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// Options for retrying with exponential backoff
+type Options struct {
+	// Attempts is the maximum number of calls, 3 will be default.
+	Attempts int
+
+	// Initial is the delay after first failure, 100ms will be default.
+	Initial time.Duration
+
+	// Max caps the delay between attempts, 10s will be default.
+	Max time.Duration
+
+	// Multiplier grows the delay after each failure, 2 will be default.
+	Multiplier float64
+
+	// Jitter randomizes the delay by fraction of it, 0 - 1.
+	Jitter float64
+
+	// Retryable reports if error is transient, all errors are retried when not set.
+	Retryable func(error) bool
+}
+
+// Func is the retried operation, should return early when context is done.
+type Func func(ctx context.Context) error
+
+// Error not retried
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err to stop retrying, Do returns err unwrapped.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err: err}
+}
+
+// Do calls fn until it succeeds, returns permanent or non retryable error,
+// attempts are exhausted or context is done. Last error is returned.
+func Do(ctx context.Context, opts Options, fn Func) error {
+	opts = withDefaults(opts)
+	delay := opts.Initial
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if opts.Retryable != nil && !opts.Retryable(err) {
+			return err
+		}
+		if attempt >= opts.Attempts {
+			return err
+		}
+
+		timer := time.NewTimer(jittered(delay, opts.Jitter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		delay = time.Duration(float64(delay) * opts.Multiplier)
+		if delay > opts.Max {
+			delay = opts.Max
+		}
+	}
+}
+
+// Backoff returns delay before attempt n, starting with 1, without jitter
+func Backoff(opts Options, n int) time.Duration {
+	opts = withDefaults(opts)
+
+	delay := opts.Initial
+	for i := 1; i < n && delay < opts.Max; i++ {
+		delay = time.Duration(float64(delay) * opts.Multiplier)
+	}
+	if delay > opts.Max {
+		delay = opts.Max
+	}
+
+	return delay
+}
+
+// Fills unset options with defaults
+func withDefaults(opts Options) Options {
+	if opts.Attempts <= 0 {
+		opts.Attempts = defaultAttempts
+	}
+	if opts.Initial <= 0 {
+		opts.Initial = defaultInitial
+	}
+	if opts.Max <= 0 {
+		opts.Max = defaultMax
+	}
+	if opts.Multiplier < 1 {
+		opts.Multiplier = defaultMultiplier
+	}
+
+	return opts
+}
+
+// Randomizes delay by fraction jitter of it
+func jittered(delay time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return delay
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	jitterMu.Lock()
+	f := 1 - jitter + 2*jitter*jitterRand.Float64()
+	jitterMu.Unlock()
+
+	return time.Duration(float64(delay) * f)
+}
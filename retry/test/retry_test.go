@@ -0,0 +1,99 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prashant-sb/go-utils/retry"
+)
+
+var errTransient = errors.New("transient")
+
+func TestDo(t *testing.T) {
+	calls := 0
+	err := retry.Do(context.Background(), retry.Options{Attempts: 5, Initial: time.Millisecond}, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+		return nil
+	})
+
+	if err != nil || calls != 3 {
+		t.Errorf("Do() FAILED, %d calls, %v", calls, err)
+	}
+	t.Logf("Do() PASSED")
+}
+
+func TestDoExhausted(t *testing.T) {
+	calls := 0
+	err := retry.Do(context.Background(), retry.Options{Attempts: 3, Initial: time.Millisecond, Jitter: 0.5}, func(ctx context.Context) error {
+		calls++
+		return errTransient
+	})
+
+	if err != errTransient || calls != 3 {
+		t.Errorf("Do() FAILED, %d calls, %v", calls, err)
+	}
+	t.Logf("DoExhausted() PASSED")
+}
+
+func TestDoPermanent(t *testing.T) {
+	errFatal := errors.New("fatal")
+
+	calls := 0
+	err := retry.Do(context.Background(), retry.Options{Initial: time.Millisecond}, func(ctx context.Context) error {
+		calls++
+		return retry.Permanent(errFatal)
+	})
+	if err != errFatal || calls != 1 {
+		t.Errorf("Do() FAILED, permanent error retried %d calls, %v", calls, err)
+	}
+
+	calls = 0
+	err = retry.Do(context.Background(), retry.Options{
+		Initial:   time.Millisecond,
+		Retryable: func(err error) bool { return err == errTransient },
+	}, func(ctx context.Context) error {
+		calls++
+		return errFatal
+	})
+	if err != errFatal || calls != 1 {
+		t.Errorf("Do() FAILED, non retryable error retried %d calls, %v", calls, err)
+	}
+	t.Logf("DoPermanent() PASSED")
+}
+
+func TestDoCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := retry.Do(ctx, retry.Options{Attempts: 10, Initial: time.Second}, func(ctx context.Context) error {
+		return errTransient
+	})
+
+	if err != errTransient || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Do() FAILED, context not honoured: %v", err)
+	}
+	t.Logf("DoCanceled() PASSED")
+}
+
+func TestBackoff(t *testing.T) {
+	opts := retry.Options{Initial: 100 * time.Millisecond, Max: time.Second}
+
+	for n, want := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		5: time.Second,
+		9: time.Second,
+	} {
+		if got := retry.Backoff(opts, n); got != want {
+			t.Errorf("Backoff(%d) FAILED, got %v, want %v", n, got, want)
+		}
+	}
+	t.Logf("Backoff() PASSED")
+}
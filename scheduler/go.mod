@@ -0,0 +1,7 @@
+module github.com/prashant-sb/go-utils/scheduler
+
+go 1.13
+
+require github.com/prashant-sb/go-utils/logging v0.0.0
+
+replace github.com/prashant-sb/go-utils/logging => ../logging
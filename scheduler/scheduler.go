@@ -0,0 +1,334 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/prashant-sb/go-utils/logging"
+)
+
+// Years searched for next time matching the cron expression
+const searchYears = 5
+
+// Descriptors for common cron expressions
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Names allowed in month and day of week fields
+var (
+	monthNames = map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}
+	dayNames = map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}
+)
+
+// Source of jitter, shared by schedules
+var (
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// Schedule returns the next activation time after given time.
+// Zero time is returned when schedule never activates.
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// Job is the periodic run, should return early when context is done.
+type Job func(ctx context.Context) error
+
+// Scheduler runs jobs on their schedules in-process
+type Scheduler interface {
+	// Add registers job with schedule, jobs added after Run are ignored.
+	Add(name string, s Schedule, job Job)
+
+	// Run blocks until context is done, then waits for running jobs.
+	// Runs of a job never overlap, activations missed are skipped.
+	Run(ctx context.Context) error
+}
+
+type field uint64
+
+type cronSchedule struct {
+	minute, hour, dom, month, dow field
+	domAny, dowAny                bool
+}
+
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+type jitterSchedule struct {
+	schedule Schedule
+	jitter   time.Duration
+}
+
+type entry struct {
+	name     string
+	schedule Schedule
+	job      Job
+}
+
+type scheduler struct {
+	mu      sync.Mutex
+	entries []entry
+}
+
+// Parse parses the cron expression with minute, hour, day of month,
+// month and day of week fields. Fields accept *, lists, ranges and steps,
+// descriptors like @daily and "@every <duration>" are also accepted.
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+
+	if strings.HasPrefix(expr, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil || d <= 0 {
+			return nil, errors.New("Invalid interval in " + expr)
+		}
+		return Every(d), nil
+	}
+	if d, ok := descriptors[expr]; ok {
+		expr = d
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, errors.New("Cron expression " + expr + " must have 5 fields.")
+	}
+
+	c := &cronSchedule{
+		domAny: fields[2] == "*" || fields[2] == "?",
+		dowAny: fields[4] == "*" || fields[4] == "?",
+	}
+
+	var err error
+	if c.minute, err = parseField(fields[0], 0, 59, nil); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseField(fields[1], 0, 23, nil); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseField(fields[2], 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseField(fields[4], 0, 7, dayNames); err != nil {
+		return nil, err
+	}
+
+	// Sunday is either 0 or 7
+	if c.dow.has(7) {
+		c.dow |= 1
+	}
+
+	return c, nil
+}
+
+// Every returns schedule activating at fixed interval.
+func Every(interval time.Duration) Schedule {
+	return &intervalSchedule{interval: interval}
+}
+
+// WithJitter delays each activation of schedule by random duration
+// up to jitter, spreads periodic runs of many hosts.
+func WithJitter(s Schedule, jitter time.Duration) Schedule {
+	if jitter <= 0 {
+		return s
+	}
+
+	return &jitterSchedule{schedule: s, jitter: jitter}
+}
+
+// NewScheduler returns scheduler without jobs.
+func NewScheduler() Scheduler {
+	return &scheduler{}
+}
+
+func (s *intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+func (s *jitterSchedule) Next(t time.Time) time.Time {
+	next := s.schedule.Next(t)
+	if next.IsZero() {
+		return next
+	}
+
+	jitterMu.Lock()
+	delay := time.Duration(jitterRand.Int63n(int64(s.jitter)))
+	jitterMu.Unlock()
+
+	return next.Add(delay)
+}
+
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+	limit := t.Year() + searchYears
+
+	for t.Year() <= limit {
+		if !c.month.has(int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.hour.has(t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !c.minute.has(t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// Day matches when both day fields match, or either of them
+// when both are restricted, as in cron.
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom.has(t.Day())
+	dow := c.dow.has(int(t.Weekday()))
+
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+
+	return dom || dow
+}
+
+func (f field) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// Parses comma separated list of values, ranges and steps
+func parseField(expr string, min, max int, names map[string]int) (field, error) {
+	var f field
+
+	for _, part := range strings.Split(expr, ",") {
+		lo, hi, step := min, max, 1
+
+		rng := part
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, errors.New("Invalid step in " + expr)
+			}
+			rng, step = part[:i], n
+		}
+
+		if rng != "*" && rng != "?" {
+			bounds := strings.SplitN(rng, "-", 2)
+
+			var err error
+			if lo, err = parseValue(bounds[0], names); err != nil {
+				return 0, errors.New("Invalid value in " + expr)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = parseValue(bounds[1], names); err != nil {
+					return 0, errors.New("Invalid value in " + expr)
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, errors.New("Value out of range " + strconv.Itoa(min) + "-" +
+				strconv.Itoa(max) + " in " + expr)
+		}
+
+		for v := lo; v <= hi; v += step {
+			f |= 1 << uint(v)
+		}
+	}
+
+	return f, nil
+}
+
+// Parses number or name of value
+func parseValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToUpper(s)]; ok {
+		return v, nil
+	}
+
+	return strconv.Atoi(s)
+}
+
+func (s *scheduler) Add(name string, sched Schedule, job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = append(s.entries, entry{name: name, schedule: sched, job: job})
+}
+
+func (s *scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	entries := make([]entry, len(s.entries))
+	copy(entries, s.entries)
+	s.mu.Unlock()
+
+	if len(entries) == 0 {
+		return errors.New("No jobs scheduled.")
+	}
+
+	var wg sync.WaitGroup
+	for _, e := range entries {
+		wg.Add(1)
+		go func(e entry) {
+			defer wg.Done()
+			runEntry(ctx, e)
+		}(e)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// Runs the job on each activation until context is done
+func runEntry(ctx context.Context, e entry) {
+	for {
+		now := time.Now()
+		next := e.schedule.Next(now)
+		if next.IsZero() {
+			log.Warn("Job ", e.name, " has no next activation.")
+			return
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		log.Debug("Running job ", e.name)
+		if err := e.job(ctx); err != nil {
+			log.Error("Error in running job ", e.name, ": ", err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prashant-sb/go-utils/scheduler"
+)
+
+// Wednesday, 14 Oct 2020 17:05
+var from = time.Date(2020, time.October, 14, 17, 5, 30, 0, time.UTC)
+
+func TestParseNext(t *testing.T) {
+	for expr, want := range map[string]time.Time{
+		"* * * * *":          time.Date(2020, time.October, 14, 17, 6, 0, 0, time.UTC),
+		"*/15 * * * *":       time.Date(2020, time.October, 14, 17, 15, 0, 0, time.UTC),
+		"30 2 * * *":         time.Date(2020, time.October, 15, 2, 30, 0, 0, time.UTC),
+		"0 9 * * mon-fri":    time.Date(2020, time.October, 15, 9, 0, 0, 0, time.UTC),
+		"0 0 1 jan *":        time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 31 * *":         time.Date(2020, time.October, 31, 0, 0, 0, 0, time.UTC),
+		"0 0 13 * 7":         time.Date(2020, time.October, 18, 0, 0, 0, 0, time.UTC),
+		"5,10 17-18/1 * * *": time.Date(2020, time.October, 14, 17, 10, 0, 0, time.UTC),
+		"@weekly":            time.Date(2020, time.October, 18, 0, 0, 0, 0, time.UTC),
+		"@every 90s":         from.Add(90 * time.Second),
+	} {
+		s, err := scheduler.Parse(expr)
+		if err != nil {
+			t.Errorf("Parse(%s) FAILED: %v", expr, err)
+			continue
+		}
+
+		if got := s.Next(from); !got.Equal(want) {
+			t.Errorf("Next(%s) FAILED, got %v, want %v", expr, got, want)
+		}
+	}
+	t.Logf("Parse() PASSED")
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "@every -1m", "* * * foo *"} {
+		if _, err := scheduler.Parse(expr); err == nil {
+			t.Errorf("Parse(%s) FAILED, accepted invalid expression", expr)
+		}
+	}
+
+	s, _ := scheduler.Parse("0 0 30 2 *")
+	if !s.Next(from).IsZero() {
+		t.Errorf("Next() FAILED, schedule on 30 Feb activated")
+	}
+	t.Logf("ParseInvalid() PASSED")
+}
+
+func TestWithJitter(t *testing.T) {
+	s := scheduler.WithJitter(scheduler.Every(time.Minute), 10*time.Second)
+
+	for i := 0; i < 100; i++ {
+		d := s.Next(from).Sub(from)
+		if d < time.Minute || d >= time.Minute+10*time.Second {
+			t.Errorf("WithJitter() FAILED, activation after %v", d)
+		}
+	}
+	t.Logf("WithJitter() PASSED")
+}
+
+func TestRun(t *testing.T) {
+	var runs int32
+
+	s := scheduler.NewScheduler()
+	s.Add("count", scheduler.Every(10*time.Millisecond), func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+
+	if err := s.Run(ctx); err != nil {
+		t.Errorf("Run() FAILED: %v", err)
+	}
+
+	if n := atomic.LoadInt32(&runs); n < 2 || n > 5 {
+		t.Errorf("Run() FAILED, job ran %d times", n)
+	}
+	t.Logf("Run() PASSED")
+}
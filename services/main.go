@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/services/systemd"
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+)
+
+// CLI Flags:
+//
+// -list              : List all loaded units
+// -status <unit>     : Status of unit
+// -start <unit>      : Start unit
+// -stop <unit>       : Stop unit
+// -restart <unit>    : Restart unit
+// -enable <unit>     : Enable unit
+// -disable <unit>    : Disable unit
+var (
+	list    = flag.Bool("list", false, "Lists the loaded units")
+	status  = flag.String("status", "", "Prints status of unit")
+	start   = flag.String("start", "", "Starts the unit")
+	stop    = flag.String("stop", "", "Stops the unit")
+	restart = flag.String("restart", "", "Restarts the unit")
+	enable  = flag.String("enable", "", "Enables the unit")
+	disable = flag.String("disable", "", "Disables the unit")
+)
+
+// Runs the operation selected by flags
+func run(m systemd.Manager) (interface{}, error) {
+	switch {
+	case *list:
+		return m.List()
+	case *status != "":
+		return m.Status(*status)
+	case *start != "":
+		return m.Start(*start)
+	case *stop != "":
+		return m.Stop(*stop)
+	case *restart != "":
+		return m.Restart(*restart)
+	case *enable != "":
+		return m.Enable(*enable)
+	case *disable != "":
+		return m.Disable(*disable)
+	}
+
+	return nil, nil
+}
+
+func main() {
+	flag.Parse()
+
+	if flag.NFlag() == 0 {
+		flag.Usage()
+		return
+	}
+
+	m, err := systemd.NewManager()
+	if err != nil {
+		log.Error("Error in connecting to systemd: ", err)
+		return
+	}
+	defer m.Close()
+
+	out, err := run(m)
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+
+	jsonOut, err := uinfo.Decode(out)
+	if err != nil {
+		log.Error("Error in decode: ", err)
+		return
+	}
+
+	fmt.Printf("%v\n", jsonOut)
+}
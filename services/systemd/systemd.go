@@ -0,0 +1,221 @@
+package systemd
+
+import (
+	"errors"
+
+	dbus "github.com/godbus/dbus/v5"
+)
+
+// Systemd manager on the system bus
+const (
+	busName       = "org.freedesktop.systemd1"
+	managerPath   = dbus.ObjectPath("/org/freedesktop/systemd1")
+	managerIface  = "org.freedesktop.systemd1.Manager"
+	unitIface     = "org.freedesktop.systemd1.Unit"
+	propertyIface = "org.freedesktop.DBus.Properties"
+
+	// Queued job replaces conflicting jobs
+	jobMode = "replace"
+)
+
+// Unit state as reported by systemd
+type Unit struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	LoadState   string `json:"loadState"`
+	ActiveState string `json:"activeState"`
+	SubState    string `json:"subState"`
+
+	// UnitFileState is enabled | disabled | static..., set by Status.
+	UnitFileState string `json:"unitFileState,omitempty"`
+}
+
+// Change of unit file by enable / disable
+type Change struct {
+	Type        string `json:"type"`
+	Path        string `json:"path"`
+	Destination string `json:"destination"`
+}
+
+// Manager interface for systemd units over D-Bus
+type Manager interface {
+	List() ([]Unit, error)
+	Status(string) (*Unit, error)
+	Start(string) (string, error)
+	Stop(string) (string, error)
+	Restart(string) (string, error)
+	Enable(string) ([]Change, error)
+	Disable(string) ([]Change, error)
+	Close() error
+}
+
+// Raw unit from ListUnits, signature (ssssssouso)
+type listedUnit struct {
+	Name        string
+	Description string
+	LoadState   string
+	ActiveState string
+	SubState    string
+	Followed    string
+	Path        dbus.ObjectPath
+	JobID       uint32
+	JobType     string
+	JobPath     dbus.ObjectPath
+}
+
+// Bus returns objects of bus services, as the system bus
+// connection or faked in tests.
+type Bus interface {
+	Object(dest string, path dbus.ObjectPath) dbus.BusObject
+	Close() error
+}
+
+type manager struct {
+	bus Bus            // System bus connection
+	obj dbus.BusObject // Systemd manager object
+}
+
+// NewManager connects to systemd on the system bus
+func NewManager() (Manager, error) {
+	conn, err := dbus.SystemBusPrivate()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Auth(nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := conn.Hello(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return NewManagerWithBus(conn), nil
+}
+
+// NewManagerWithBus inits the interface for systemd units on bus
+func NewManagerWithBus(b Bus) Manager {
+	return &manager{
+		bus: b,
+		obj: b.Object(busName, managerPath),
+	}
+}
+
+// Close the bus connection
+func (m *manager) Close() error {
+	return m.bus.Close()
+}
+
+// List returns all units loaded by systemd
+func (m *manager) List() ([]Unit, error) {
+	var listed []listedUnit
+
+	if err := m.obj.Call(managerIface+".ListUnits", 0).Store(&listed); err != nil {
+		return nil, err
+	}
+
+	units := []Unit{}
+	for _, u := range listed {
+		units = append(units, Unit{
+			Name:        u.Name,
+			Description: u.Description,
+			LoadState:   u.LoadState,
+			ActiveState: u.ActiveState,
+			SubState:    u.SubState,
+		})
+	}
+
+	return units, nil
+}
+
+// Status loads the unit and returns its state
+func (m *manager) Status(name string) (*Unit, error) {
+	var path dbus.ObjectPath
+
+	if err := m.obj.Call(managerIface+".LoadUnit", 0, name).Store(&path); err != nil {
+		return nil, err
+	}
+
+	props := make(map[string]dbus.Variant)
+	err := m.bus.Object(busName, path).Call(propertyIface+".GetAll", 0, unitIface).Store(&props)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &Unit{Name: name}
+	for prop, field := range map[string]*string{
+		"Description":   &u.Description,
+		"LoadState":     &u.LoadState,
+		"ActiveState":   &u.ActiveState,
+		"SubState":      &u.SubState,
+		"UnitFileState": &u.UnitFileState,
+	} {
+		if v, ok := props[prop].Value().(string); ok {
+			*field = v
+		}
+	}
+
+	if u.LoadState == "not-found" {
+		return u, errors.New("Unit " + name + " not found.")
+	}
+
+	return u, nil
+}
+
+// Start queues start job for unit, returns the job path
+func (m *manager) Start(name string) (string, error) {
+	return m.job("StartUnit", name)
+}
+
+// Stop queues stop job for unit, returns the job path
+func (m *manager) Stop(name string) (string, error) {
+	return m.job("StopUnit", name)
+}
+
+// Restart queues restart job for unit, returns the job path
+func (m *manager) Restart(name string) (string, error) {
+	return m.job("RestartUnit", name)
+}
+
+// Enable links the unit file and reloads systemd
+func (m *manager) Enable(name string) ([]Change, error) {
+	var carriesInstall bool
+	var changes []Change
+
+	err := m.obj.Call(managerIface+".EnableUnitFiles", 0, []string{name}, false, true).
+		Store(&carriesInstall, &changes)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, m.reload()
+}
+
+// Disable removes the unit file links and reloads systemd
+func (m *manager) Disable(name string) ([]Change, error) {
+	var changes []Change
+
+	err := m.obj.Call(managerIface+".DisableUnitFiles", 0, []string{name}, false).Store(&changes)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, m.reload()
+}
+
+// Calls manager method queueing job for unit
+func (m *manager) job(method, name string) (string, error) {
+	var path dbus.ObjectPath
+
+	if err := m.obj.Call(managerIface+"."+method, 0, name, jobMode).Store(&path); err != nil {
+		return "", err
+	}
+
+	return string(path), nil
+}
+
+// Reloads unit files after enable / disable
+func (m *manager) reload() error {
+	return m.obj.Call(managerIface+".Reload", 0).Err
+}
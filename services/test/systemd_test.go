@@ -0,0 +1,170 @@
+package systemd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/prashant-sb/go-utils/services/systemd"
+)
+
+// Object replying to methods with fixed bodies, records calls
+type fakeObject struct {
+	dbus.BusObject
+	replies map[string][]interface{}
+	calls   []string
+}
+
+func (o *fakeObject) Call(method string, flags dbus.Flags, args ...interface{}) *dbus.Call {
+	o.calls = append(o.calls, strings.TrimSpace(fmt.Sprintln(append([]interface{}{method}, args...)...)))
+
+	body, ok := o.replies[method]
+	if !ok {
+		return &dbus.Call{Err: errors.New("No reply to " + method)}
+	}
+
+	return &dbus.Call{Body: body}
+}
+
+// Bus of fake objects by path
+type fakeBus struct {
+	objects map[dbus.ObjectPath]*fakeObject
+	closed  bool
+}
+
+func (b *fakeBus) Object(dest string, path dbus.ObjectPath) dbus.BusObject {
+	if o, ok := b.objects[path]; ok {
+		return o
+	}
+
+	return &fakeObject{}
+}
+
+func (b *fakeBus) Close() error {
+	b.closed = true
+	return nil
+}
+
+const (
+	managerPath  = dbus.ObjectPath("/org/freedesktop/systemd1")
+	managerIface = "org.freedesktop.systemd1.Manager"
+	sshPath      = dbus.ObjectPath("/org/freedesktop/systemd1/unit/ssh_2eservice")
+	missingPath  = dbus.ObjectPath("/org/freedesktop/systemd1/unit/missing_2eservice")
+)
+
+// Returns bus with systemd manager and units of ssh and missing service
+func newBus() (*fakeBus, *fakeObject) {
+	mgr := &fakeObject{replies: map[string][]interface{}{
+		managerIface + ".ListUnits": {[][]interface{}{
+			[]interface{}{"ssh.service", "OpenBSD Secure Shell server", "loaded", "active", "running",
+				"", sshPath, uint32(0), "", dbus.ObjectPath("/")},
+			[]interface{}{"backup.timer", "Nightly backup", "loaded", "inactive", "dead",
+				"", dbus.ObjectPath("/org/freedesktop/systemd1/unit/backup_2etimer"), uint32(7), "start",
+				dbus.ObjectPath("/org/freedesktop/systemd1/job/7")},
+		}},
+		managerIface + ".LoadUnit":    {sshPath},
+		managerIface + ".RestartUnit": {dbus.ObjectPath("/org/freedesktop/systemd1/job/42")},
+		managerIface + ".EnableUnitFiles": {true, [][]interface{}{
+			[]interface{}{"symlink", "/etc/systemd/system/multi-user.target.wants/ssh.service", "/lib/systemd/system/ssh.service"},
+		}},
+		managerIface + ".DisableUnitFiles": {[][]interface{}{
+			[]interface{}{"unlink", "/etc/systemd/system/multi-user.target.wants/ssh.service", ""},
+		}},
+		managerIface + ".Reload": {},
+	}}
+
+	props := "org.freedesktop.DBus.Properties.GetAll"
+	ssh := &fakeObject{replies: map[string][]interface{}{props: {map[string]dbus.Variant{
+		"Description":   dbus.MakeVariant("OpenBSD Secure Shell server"),
+		"LoadState":     dbus.MakeVariant("loaded"),
+		"ActiveState":   dbus.MakeVariant("active"),
+		"SubState":      dbus.MakeVariant("running"),
+		"UnitFileState": dbus.MakeVariant("enabled"),
+		"MainPID":       dbus.MakeVariant(uint32(812)),
+	}}}}
+	missing := &fakeObject{replies: map[string][]interface{}{props: {map[string]dbus.Variant{
+		"LoadState":   dbus.MakeVariant("not-found"),
+		"ActiveState": dbus.MakeVariant("inactive"),
+	}}}}
+
+	return &fakeBus{objects: map[dbus.ObjectPath]*fakeObject{
+		managerPath: mgr,
+		sshPath:     ssh,
+		missingPath: missing,
+	}}, mgr
+}
+
+func TestList(t *testing.T) {
+	bus, _ := newBus()
+	units, err := systemd.NewManagerWithBus(bus).List()
+	if err != nil || len(units) != 2 {
+		t.Fatalf("List() FAILED, %+v, %v", units, err)
+	}
+
+	want := []systemd.Unit{
+		{Name: "ssh.service", Description: "OpenBSD Secure Shell server", LoadState: "loaded", ActiveState: "active", SubState: "running"},
+		{Name: "backup.timer", Description: "Nightly backup", LoadState: "loaded", ActiveState: "inactive", SubState: "dead"},
+	}
+	for i := range want {
+		if units[i] != want[i] {
+			t.Errorf("List() FAILED, expected: %+v got: %+v", want[i], units[i])
+		}
+	}
+	t.Logf("List() PASSED")
+}
+
+func TestStatus(t *testing.T) {
+	bus, mgr := newBus()
+	m := systemd.NewManagerWithBus(bus)
+
+	u, err := m.Status("ssh.service")
+	want := systemd.Unit{Name: "ssh.service", Description: "OpenBSD Secure Shell server", LoadState: "loaded",
+		ActiveState: "active", SubState: "running", UnitFileState: "enabled"}
+	if err != nil || *u != want {
+		t.Errorf("Status() FAILED, expected: %+v got: %+v, %v", want, u, err)
+	}
+
+	mgr.replies[managerIface+".LoadUnit"] = []interface{}{missingPath}
+	u, err = m.Status("missing.service")
+	if err == nil || u.LoadState != "not-found" || u.UnitFileState != "" {
+		t.Errorf("Status() FAILED, missing unit %+v, %v", u, err)
+	}
+
+	delete(mgr.replies, managerIface+".LoadUnit")
+	if _, err := m.Status("ssh.service"); err == nil {
+		t.Errorf("Status() FAILED, error of LoadUnit ignored")
+	}
+	t.Logf("Status() PASSED")
+}
+
+func TestJobs(t *testing.T) {
+	bus, mgr := newBus()
+	m := systemd.NewManagerWithBus(bus)
+
+	job, err := m.Restart("ssh.service")
+	if err != nil || job != "/org/freedesktop/systemd1/job/42" ||
+		mgr.calls[0] != managerIface+".RestartUnit ssh.service replace" {
+		t.Errorf("Restart() FAILED, job %s, calls %q, %v", job, mgr.calls, err)
+	}
+	if _, err := m.Start("ssh.service"); err == nil {
+		t.Errorf("Start() FAILED, error of StartUnit ignored")
+	}
+
+	changes, err := m.Enable("ssh.service")
+	if err != nil || len(changes) != 1 || changes[0].Type != "symlink" ||
+		changes[0].Destination != "/lib/systemd/system/ssh.service" || mgr.calls[len(mgr.calls)-1] != managerIface+".Reload" {
+		t.Errorf("Enable() FAILED, changes %+v, calls %q, %v", changes, mgr.calls, err)
+	}
+
+	changes, err = m.Disable("ssh.service")
+	if err != nil || len(changes) != 1 || changes[0].Type != "unlink" || mgr.calls[len(mgr.calls)-1] != managerIface+".Reload" {
+		t.Errorf("Disable() FAILED, changes %+v, calls %q, %v", changes, mgr.calls, err)
+	}
+
+	if err := m.Close(); err != nil || !bus.closed {
+		t.Errorf("Close() FAILED, %v", err)
+	}
+	t.Logf("Jobs() PASSED")
+}
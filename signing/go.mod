@@ -0,0 +1,3 @@
+module github.com/prashant-sb/go-utils/signing
+
+go 1.13
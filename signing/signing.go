@@ -0,0 +1,109 @@
+// Package signing signs json documents with ed25519 keys, for
+// manifests of file_signatures and snapshots.
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
+)
+
+// Algorithm of signatures
+const Algorithm string = "ed25519"
+
+// Signature of json document
+type Signature struct {
+	Algorithm string `json:"algorithm"`
+
+	// PublicKey is base64 key of signer, for identifying the key.
+	PublicKey string `json:"publicKey"`
+
+	// Value is base64 signature of json of signed value.
+	Value string `json:"value"`
+}
+
+// Sign returns signature of json of v with private key
+func Sign(v interface{}, key ed25519.PrivateKey) (*Signature, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Signature{
+		Algorithm: Algorithm,
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Value:     base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)),
+	}, nil
+}
+
+// Verify checks signature of json of v with public key of signer.
+// Key embedded in signature is not trusted, name is of v in errors.
+func (s *Signature) Verify(name string, v interface{}, pub ed25519.PublicKey) error {
+	if s.Algorithm != Algorithm {
+		return errors.New("Signature algorithm " + s.Algorithm + " not supported.")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(s.Value)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(pub, data, sig) {
+		return errors.New("Signature of " + name + " is invalid.")
+	}
+
+	return nil
+}
+
+// GenerateKey writes new private key to file and public key to file.pub
+func GenerateKey(file string) error {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(file, []byte(base64.StdEncoding.EncodeToString(priv)+"\n"), 0600); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file+".pub", []byte(base64.StdEncoding.EncodeToString(pub)+"\n"), 0644)
+}
+
+// LoadPrivateKey reads base64 private key from file
+func LoadPrivateKey(file string) (ed25519.PrivateKey, error) {
+	key, err := readKey(file, ed25519.PrivateKeySize)
+	return ed25519.PrivateKey(key), err
+}
+
+// LoadPublicKey reads base64 public key from file
+func LoadPublicKey(file string) (ed25519.PublicKey, error) {
+	key, err := readKey(file, ed25519.PublicKeySize)
+	return ed25519.PublicKey(key), err
+}
+
+// Reads and decodes key of size from file
+func readKey(file string, size int) ([]byte, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != size {
+		return nil, errors.New("Invalid key in " + file)
+	}
+
+	return key, nil
+}
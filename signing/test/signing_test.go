@@ -0,0 +1,59 @@
+package signing
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/signing"
+)
+
+type doc struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestSign(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "key")
+	if err := signing.GenerateKey(file); err != nil {
+		t.Fatal(err)
+	}
+	priv, err := signing.LoadPrivateKey(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := signing.LoadPublicKey(file + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signing.LoadPublicKey(file); err == nil {
+		t.Errorf("LoadPublicKey() FAILED, private key loaded as public")
+	}
+
+	d := doc{Name: "hosts", Count: 2}
+	sig, err := signing.Sign(d, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sig.Verify("doc", d, pub); err != nil {
+		t.Errorf("Verify() FAILED, %v", err)
+	}
+
+	d.Count++
+	if err := sig.Verify("doc", d, pub); err == nil {
+		t.Errorf("Verify() FAILED, changed document verified")
+	}
+	d.Count--
+	sig.Algorithm = "rsa"
+	if err := sig.Verify("doc", d, pub); err == nil {
+		t.Errorf("Verify() FAILED, algorithm rsa accepted")
+	}
+	t.Logf("Sign() PASSED")
+}
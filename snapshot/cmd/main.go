@@ -0,0 +1,137 @@
+package main
+
+// CLI to capture, sign, verify and diff host snapshots
+
+import (
+	"crypto/ed25519"
+	"flag"
+	"os"
+	"runtime"
+	"strings"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/output"
+	"github.com/prashant-sb/go-utils/signing"
+	"github.com/prashant-sb/go-utils/snapshot"
+)
+
+// CLI Flags, snapshot is captured without flags:
+//
+// -paths <p1,p2> -key <file>        : Captures snapshot with digests, signed with key
+// -keygen <file>                    : Writes new key pair to file and file.pub
+// -diff -old <json> [-new <json>]   : Diff of saved snapshot with other or host snapshot
+// -pubkey <file>                    : Verifies signature of saved snapshots before diff
+var (
+	paths   = flag.String("paths", "", "Comma separated files and directories to digest")
+	exclude = flag.String("exclude", "", "Comma separated glob patterns of skipped paths")
+	key     = flag.String("key", "", "Private key file for signing snapshot")
+	keygen  = flag.String("keygen", "", "Writes new key pair to file and file.pub")
+	diff    = flag.Bool("diff", false, "Prints diff between two snapshots")
+	oldSnap = flag.String("old", "", "Saved snapshot in json for diff")
+	newSnap = flag.String("new", "", "Saved snapshot in json for diff, host snapshot will be default")
+	pubkey  = flag.String("pubkey", "", "Public key file for verifying saved snapshots")
+	workers = flag.Int("workers", runtime.NumCPU(), "Number of concurrent workers")
+)
+
+// Splits comma separated flag value
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
+
+// Captures host snapshot
+func capture() (*snapshot.Snapshot, error) {
+	return snapshot.NewCapturer(snapshot.Options{
+		Paths:   split(*paths),
+		Exclude: split(*exclude),
+		Workers: *workers,
+	}).Capture()
+}
+
+// Loads snapshot, verifies signature when public key is given
+func load(file string, pub ed25519.PublicKey) (*snapshot.Snapshot, error) {
+	d, err := snapshot.Load(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if pub != nil {
+		if err := d.Verify(pub); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.Snapshot, nil
+}
+
+// Returns document of flags with its kind
+func run() (string, interface{}, error) {
+	switch {
+	case *keygen != "":
+		if err := signing.GenerateKey(*keygen); err != nil {
+			return "", nil, err
+		}
+		return snapshot.KindKeys, map[string]string{"privateKey": *keygen, "publicKey": *keygen + ".pub"}, nil
+
+	case *diff:
+		var pub ed25519.PublicKey
+		if *pubkey != "" {
+			var err error
+			if pub, err = signing.LoadPublicKey(*pubkey); err != nil {
+				return "", nil, err
+			}
+		}
+
+		old, err := load(*oldSnap, pub)
+		if err != nil {
+			return "", nil, err
+		}
+
+		var cur *snapshot.Snapshot
+		if *newSnap != "" {
+			cur, err = load(*newSnap, pub)
+		} else {
+			cur, err = capture()
+		}
+		if err != nil {
+			return "", nil, err
+		}
+
+		return snapshot.KindDiff, snapshot.Compare(old, cur), nil
+	}
+
+	s, err := capture()
+	if err != nil {
+		return "", nil, err
+	}
+	if *key == "" {
+		return snapshot.KindSnapshot, &snapshot.Document{Snapshot: s}, nil
+	}
+
+	priv, err := signing.LoadPrivateKey(*key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	d, err := snapshot.Sign(s, priv)
+	return snapshot.KindSnapshot, d, err
+}
+
+func main() {
+	flag.Parse()
+
+	kind, out, err := run()
+	if err != nil {
+		log.Error(err.Error())
+		os.Exit(1)
+	}
+
+	e := output.NewEnvelope(snapshot.ToolName, kind, snapshot.SchemaVersion, out)
+	if err := e.Write(os.Stdout, "   "); err != nil {
+		log.Error("Error in writing output: ", err)
+		os.Exit(1)
+	}
+}
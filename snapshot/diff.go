@@ -0,0 +1,134 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+)
+
+// Kinds of changes
+const (
+	ChangeAdded   string = "added"
+	ChangeRemoved string = "removed"
+	ChangeChanged string = "changed"
+)
+
+// Change is the drift of single item between snapshots
+type Change struct {
+	// Section is one of users | groups | files | mounts | ports.
+	Section string `json:"section"`
+
+	// Key identifies the item in section, e.g. username or path.
+	Key  string `json:"key"`
+	Kind string `json:"kind"`
+
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// Diff of two snapshots
+type Diff struct {
+	Changes []Change `json:"changes"`
+}
+
+// Compare returns changes from old to new snapshot,
+// time of snapshots is not compared.
+func Compare(old, new *Snapshot) *Diff {
+	d := &Diff{Changes: []Change{}}
+
+	d.section("users", usersByKey(old), usersByKey(new))
+	d.section("groups", groupsByKey(old), groupsByKey(new))
+	d.section("files", filesByKey(old), filesByKey(new))
+	d.section("mounts", mountsByKey(old), mountsByKey(new))
+	d.section("ports", portsByKey(old), portsByKey(new))
+
+	if old.Hostname != new.Hostname {
+		d.Changes = append(d.Changes, Change{
+			Section: "host",
+			Key:     "hostname",
+			Kind:    ChangeChanged,
+			Before:  old.Hostname,
+			After:   new.Hostname,
+		})
+	}
+
+	return d
+}
+
+// Appends changes of section sorted by key
+func (d *Diff) section(name string, before, after map[string]interface{}) {
+	var keys []string
+	for k := range before {
+		keys = append(keys, k)
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		b, inBefore := before[k]
+		a, inAfter := after[k]
+
+		switch {
+		case !inBefore:
+			d.Changes = append(d.Changes, Change{Section: name, Key: k, Kind: ChangeAdded, After: a})
+		case !inAfter:
+			d.Changes = append(d.Changes, Change{Section: name, Key: k, Kind: ChangeRemoved, Before: b})
+		case !jsonEqual(b, a):
+			d.Changes = append(d.Changes, Change{Section: name, Key: k, Kind: ChangeChanged, Before: b, After: a})
+		}
+	}
+}
+
+func usersByKey(s *Snapshot) map[string]interface{} {
+	m := make(map[string]interface{})
+	for _, u := range s.Users {
+		m[u.Username] = u
+	}
+	return m
+}
+
+func groupsByKey(s *Snapshot) map[string]interface{} {
+	m := make(map[string]interface{})
+	for _, g := range s.Groups {
+		m[g.Name] = g
+	}
+	return m
+}
+
+func filesByKey(s *Snapshot) map[string]interface{} {
+	m := make(map[string]interface{})
+	for _, f := range s.Files {
+		m[f.Path] = f
+	}
+	return m
+}
+
+func mountsByKey(s *Snapshot) map[string]interface{} {
+	m := make(map[string]interface{})
+	for _, mt := range s.Mounts {
+		m[mt.Point] = mt
+	}
+	return m
+}
+
+func portsByKey(s *Snapshot) map[string]interface{} {
+	m := make(map[string]interface{})
+	for _, p := range s.Ports {
+		m[p.Protocol+" "+p.Address+":"+strconv.Itoa(p.Port)] = p
+	}
+	return m
+}
+
+// Items are equal when their json matches, so loaded
+// and captured snapshots compare alike.
+func jsonEqual(a, b interface{}) bool {
+	ja, erra := json.Marshal(a)
+	jb, errb := json.Marshal(b)
+
+	return erra == nil && errb == nil && bytes.Equal(ja, jb)
+}
@@ -0,0 +1,40 @@
+package snapshot
+
+import (
+	"crypto/ed25519"
+	"errors"
+
+	"github.com/prashant-sb/go-utils/signing"
+)
+
+// Signature of snapshot
+type Signature = signing.Signature
+
+// Document is the snapshot with its signature
+type Document struct {
+	Snapshot  *Snapshot  `json:"snapshot"`
+	Signature *Signature `json:"signature,omitempty"`
+}
+
+// Sign returns document of snapshot signed with private key
+func Sign(s *Snapshot, key ed25519.PrivateKey) (*Document, error) {
+	sig, err := signing.Sign(s, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Document{Snapshot: s, Signature: sig}, nil
+}
+
+// Verify checks signature of document with public key of signer.
+// Key embedded in document is not trusted.
+func (d *Document) Verify(pub ed25519.PublicKey) error {
+	if d.Snapshot == nil {
+		return errors.New("Snapshot missing in document.")
+	}
+	if d.Signature == nil {
+		return errors.New("Snapshot is not signed.")
+	}
+
+	return d.Signature.Verify("snapshot", d.Snapshot, pub)
+}
@@ -0,0 +1,331 @@
+package snapshot
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	hasher "github.com/prashant-sb/go-utils/file_signatures/hash"
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/mountinfo/mounts"
+	"github.com/prashant-sb/go-utils/netinfo/network"
+	"github.com/prashant-sb/go-utils/output"
+	"github.com/prashant-sb/go-utils/pool"
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+	"github.com/prashant-sb/go-utils/walker"
+)
+
+// Group database of linux
+const groupDB string = "/etc/group"
+
+// Name of tool and schema version of its documents in envelope
+const (
+	ToolName      string = "snapshot"
+	SchemaVersion int    = 1
+)
+
+// Kinds of documents in envelope
+const (
+	KindSnapshot string = "snapshot" // Document of snapshot
+	KindDiff     string = "diff"     // Diff of snapshots
+	KindKeys     string = "keys"     // Files of generated key pair
+)
+
+// Group of the host
+type Group struct {
+	Name    string   `json:"name"`
+	Gid     string   `json:"gid"`
+	Members []string `json:"members"`
+}
+
+// File is the digest and attributes of selected file
+type File struct {
+	Path   string `json:"path"`
+	Sha256 string `json:"sha256"`
+	Mode   string `json:"mode"`
+	Uid    uint32 `json:"uid"`
+	Gid    uint32 `json:"gid"`
+	Size   int64  `json:"size"`
+}
+
+// Mount without usage, which changes between snapshots
+type Mount struct {
+	Source  string   `json:"source"`
+	Point   string   `json:"mountPoint"`
+	Type    string   `json:"fsType"`
+	Options []string `json:"options"`
+}
+
+// Port is the listening socket
+type Port struct {
+	Protocol string `json:"protocol"`
+	Address  string `json:"address"`
+	Port     int    `json:"port"`
+	Process  string `json:"process,omitempty"`
+}
+
+// Snapshot of host state
+type Snapshot struct {
+	Hostname string           `json:"hostname"`
+	Time     time.Time        `json:"time"`
+	Users    []uinfo.Userinfo `json:"users"`
+	Groups   []Group          `json:"groups"`
+	Files    []File           `json:"files"`
+	Mounts   []Mount          `json:"mounts"`
+	Ports    []Port           `json:"ports"`
+}
+
+// Options for capturing snapshot
+type Options struct {
+	// Paths are the files and directories digested.
+	Paths []string
+
+	// Exclude lists glob patterns of skipped paths.
+	Exclude []string
+
+	// Workers is the number of concurrent workers for digests.
+	Workers int
+}
+
+// Capturer interface for host snapshots
+type Capturer interface {
+	Capture() (*Snapshot, error)
+}
+
+type capturer struct {
+	opts Options // Capture options
+}
+
+// NewCapturer inits the interface for host snapshots
+func NewCapturer(opts Options) Capturer {
+	opts.Paths = cleanPaths(opts.Paths)
+	return &capturer{opts: opts}
+}
+
+// Capture collects users, groups, digests of selected paths,
+// mounts and listening ports of host.
+func (c *capturer) Capture() (*Snapshot, error) {
+	s := &Snapshot{Time: time.Now().UTC().Truncate(time.Second)}
+
+	var err error
+	if s.Hostname, err = os.Hostname(); err != nil {
+		return nil, err
+	}
+
+	ul, err := uinfo.NewUserList().Get()
+	if err != nil {
+		log.Error("Error in listing users: ", err)
+		return nil, err
+	}
+	s.Users = ul.Users
+	sort.Slice(s.Users, func(i, j int) bool {
+		return s.Users[i].Username < s.Users[j].Username
+	})
+
+	if s.Groups, err = readGroups(groupDB); err != nil {
+		log.Error("Error in reading ", groupDB, ": ", err)
+		return nil, err
+	}
+
+	if s.Files, err = c.files(); err != nil {
+		return nil, err
+	}
+
+	if s.Mounts, err = listMounts(); err != nil {
+		log.Error("Error in listing mounts: ", err)
+		return nil, err
+	}
+
+	if s.Ports, err = listPorts(); err != nil {
+		log.Error("Error in listing sockets: ", err)
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Digests regular files under selected paths
+func (c *capturer) files() ([]File, error) {
+	files := []File{}
+
+	var werr error
+	onResult := func(res pool.Result) {
+		if res.Err != nil {
+			if werr == nil {
+				werr = res.Err
+			}
+			return
+		}
+		if f, ok := res.Value.(File); ok {
+			files = append(files, f)
+		}
+	}
+
+	for _, path := range c.opts.Paths {
+		err := walker.Walk(context.Background(), path, walker.Options{
+			Workers:  c.opts.Workers,
+			Exclude:  c.opts.Exclude,
+			OnResult: onResult,
+		}, digest)
+		if werr == nil {
+			werr = err
+		}
+	}
+	if werr != nil {
+		log.Error("Error in digesting files: ", werr)
+		return nil, werr
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	return files, nil
+}
+
+// Visitor digesting regular file
+func digest(ctx context.Context, path string, info os.FileInfo) (interface{}, error) {
+	if !info.Mode().IsRegular() {
+		return nil, nil
+	}
+
+	sum, err := hasher.FileSha256(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := File{
+		Path:   path,
+		Sha256: sum,
+		Mode:   info.Mode().String(),
+		Size:   info.Size(),
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		f.Uid = st.Uid
+		f.Gid = st.Gid
+	}
+
+	return f, nil
+}
+
+// Reads groups from group database
+func readGroups(file string) ([]Group, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	groups := []Group{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) < 4 {
+			continue
+		}
+
+		g := Group{Name: fields[0], Gid: fields[2], Members: []string{}}
+		if fields[3] != "" {
+			g.Members = strings.Split(fields[3], ",")
+			sort.Strings(g.Members)
+		}
+		groups = append(groups, g)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+
+	return groups, scanner.Err()
+}
+
+// Lists mounts with blocks, without usage
+func listMounts() ([]Mount, error) {
+	list, err := mounts.NewReporter(mounts.Thresholds{}).List(false)
+	if err != nil {
+		return nil, err
+	}
+
+	ms := []Mount{}
+	for _, m := range list {
+		ms = append(ms, Mount{Source: m.Source, Point: m.Point, Type: m.Type, Options: m.Options})
+	}
+
+	return ms, nil
+}
+
+// Lists listening sockets
+func listPorts() ([]Port, error) {
+	sockets, err := network.NewInventorier().Sockets()
+	if err != nil {
+		return nil, err
+	}
+
+	ports := []Port{}
+	for _, s := range sockets {
+		ports = append(ports, Port{Protocol: s.Protocol, Address: s.Address, Port: s.Port, Process: s.Process})
+	}
+
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].Protocol == ports[j].Protocol {
+			if ports[i].Port == ports[j].Port {
+				return ports[i].Address < ports[j].Address
+			}
+			return ports[i].Port < ports[j].Port
+		}
+		return ports[i].Protocol < ports[j].Protocol
+	})
+
+	return ports, nil
+}
+
+// Load reads snapshot document saved in json envelope, or
+// saved as plain document before envelopes.
+func Load(file string) (*Document, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Document{}
+	e, err := output.DecodeEnvelope(data, d)
+	if err == output.ErrNotEnvelope {
+		if err := json.Unmarshal(data, d); err != nil {
+			return nil, err
+		}
+		return d, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.Check(ToolName, KindSnapshot, SchemaVersion); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Returns absolute, cleaned paths
+func cleanPaths(paths []string) []string {
+	var cleaned []string
+	for _, p := range paths {
+		if abs, err := filepath.Abs(p); err == nil {
+			cleaned = append(cleaned, abs)
+		}
+	}
+
+	return cleaned
+}
@@ -0,0 +1,126 @@
+package snapshot
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prashant-sb/go-utils/output"
+	"github.com/prashant-sb/go-utils/snapshot"
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+)
+
+func testSnapshot() *snapshot.Snapshot {
+	return &snapshot.Snapshot{
+		Hostname: "host",
+		Time:     time.Date(2020, time.October, 14, 17, 0, 0, 0, time.UTC),
+		Users:    []uinfo.Userinfo{{Uid: "1000", Gid: "1000", Username: "test", SupplementaryGroups: []string{}}},
+		Groups:   []snapshot.Group{{Name: "test", Gid: "1000", Members: []string{}}},
+		Files:    []snapshot.File{{Path: "/etc/hosts", Sha256: "aa", Mode: "-rw-r--r--"}},
+		Mounts:   []snapshot.Mount{{Source: "/dev/sda1", Point: "/", Type: "ext4", Options: []string{"rw"}}},
+		Ports:    []snapshot.Port{{Protocol: "tcp", Address: "0.0.0.0", Port: 22, Process: "sshd"}},
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+
+	d, err := snapshot.Sign(testSnapshot(), priv)
+	if err != nil {
+		t.Fatalf("Sign() FAILED: %v", err)
+	}
+
+	// Verified after round trip through json
+	data, _ := json.Marshal(d)
+	loaded := &snapshot.Document{}
+	json.Unmarshal(data, loaded)
+	if err := loaded.Verify(pub); err != nil {
+		t.Errorf("Verify() FAILED: %v", err)
+	}
+
+	loaded.Snapshot.Files[0].Sha256 = "bb"
+	if err := loaded.Verify(pub); err == nil {
+		t.Errorf("Verify() FAILED, tampered snapshot verified")
+	}
+
+	other, _, _ := ed25519.GenerateKey(rand.Reader)
+	if err := d.Verify(other); err == nil {
+		t.Errorf("Verify() FAILED, verified with other key")
+	}
+	t.Logf("Sign(), Verify() PASSED")
+}
+
+func TestCompare(t *testing.T) {
+	old := testSnapshot()
+
+	cur := testSnapshot()
+	cur.Time = cur.Time.Add(time.Hour)
+	cur.Users[0].SupplementaryGroups = nil
+	cur.Files[0].Sha256 = "bb"
+	cur.Ports = append(cur.Ports, snapshot.Port{Protocol: "tcp", Address: "0.0.0.0", Port: 8080})
+	cur.Groups = nil
+
+	d := snapshot.Compare(old, cur)
+
+	want := []struct{ section, key, kind string }{
+		{"groups", "test", snapshot.ChangeRemoved},
+		{"files", "/etc/hosts", snapshot.ChangeChanged},
+		{"ports", "tcp 0.0.0.0:8080", snapshot.ChangeAdded},
+	}
+	if len(d.Changes) != len(want) {
+		t.Fatalf("Compare() FAILED, got %+v", d.Changes)
+	}
+	for i, w := range want {
+		c := d.Changes[i]
+		if c.Section != w.section || c.Key != w.key || c.Kind != w.kind {
+			t.Errorf("Compare() FAILED, change %d is %+v", i, c)
+		}
+	}
+	t.Logf("Compare() PASSED")
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	save := func(name string, v interface{}) string {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if e, ok := v.(*output.Envelope); ok {
+			err = e.Write(f, "   ")
+		} else {
+			err = json.NewEncoder(f).Encode(v)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f.Name()
+	}
+
+	d := &snapshot.Document{Snapshot: testSnapshot()}
+	wrapped := save("wrapped.json", output.NewEnvelope(snapshot.ToolName, snapshot.KindSnapshot, snapshot.SchemaVersion, d))
+	plain := save("plain.json", d)
+	diff := save("diff.json", output.NewEnvelope(snapshot.ToolName, snapshot.KindDiff, snapshot.SchemaVersion, &snapshot.Diff{}))
+
+	for _, file := range []string{wrapped, plain} {
+		loaded, err := snapshot.Load(file)
+		if err != nil || loaded.Snapshot == nil || loaded.Snapshot.Hostname != "host" {
+			t.Errorf("Load() FAILED of %s: %v", filepath.Base(file), err)
+		}
+	}
+	if _, err := snapshot.Load(diff); err == nil {
+		t.Errorf("Load() FAILED, diff loaded as snapshot")
+	}
+	t.Logf("Load() PASSED")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/sshaudit/sshd"
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+)
+
+// Exit codes for monitoring probes
+const (
+	exitOK       = 0
+	exitWarning  = 1
+	exitCritical = 2
+	exitUnknown  = 3
+)
+
+// CLI Flags:
+//
+// -file <sshd_config> : Audits sshd configuration / /etc/ssh/sshd_config will be default
+// -settings           : Prints effective settings instead of findings
+var (
+	file     = flag.String("file", sshd.DefaultConfig, "sshd configuration file")
+	settings = flag.Bool("settings", false, "Prints effective settings instead of findings")
+)
+
+func main() {
+	flag.Parse()
+
+	c, err := sshd.Load(*file)
+	if err != nil {
+		log.Error("Error in reading ", *file, ": ", err)
+		os.Exit(exitUnknown)
+	}
+
+	var out interface{} = c.Effective()
+	r := sshd.NewAuditor().Audit(*file, c)
+	if !*settings {
+		out = r
+	}
+
+	jsonOut, err := uinfo.Decode(out)
+	if err != nil {
+		log.Error("Error in decode: ", err)
+		os.Exit(exitUnknown)
+	}
+	fmt.Printf("%v\n", jsonOut)
+
+	switch sshd.Worst(r.Findings) {
+	case sshd.SeverityCritical:
+		os.Exit(exitCritical)
+	case sshd.SeverityWarning:
+		os.Exit(exitWarning)
+	}
+	os.Exit(exitOK)
+}
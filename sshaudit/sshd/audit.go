@@ -0,0 +1,245 @@
+package sshd
+
+import (
+	"os/user"
+	"strconv"
+	"strings"
+
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+)
+
+// Severity of findings
+const (
+	SeverityInfo     string = "info"
+	SeverityWarning  string = "warning"
+	SeverityCritical string = "critical"
+)
+
+// Login attempts above are reported
+const maxAuthTries = 6
+
+// Finding is the risky setting of sshd
+type Finding struct {
+	Keyword  string `json:"keyword"`
+	Value    string `json:"value"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+
+	// File and Line are blank for defaults of sshd.
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+
+	// Match is the criteria of Match block of setting.
+	Match string `json:"match,omitempty"`
+}
+
+// Report of sshd configuration audit
+type Report struct {
+	File     string    `json:"file"`
+	Findings []Finding `json:"findings"`
+}
+
+// Auditor interface for sshd configuration
+type Auditor interface {
+	Audit(file string, c *Config) *Report
+}
+
+// Check of single keyword, returns severity and message for risky value
+type check struct {
+	keyword string
+	def     string // Default of sshd when not set
+	risky   func(value string) (string, string)
+}
+
+// Checks of keywords, values are compared lower cased
+var checks = []check{
+	{
+		keyword: "permitrootlogin",
+		def:     "prohibit-password",
+		risky: func(v string) (string, string) {
+			if v == "yes" {
+				return SeverityCritical, "Root can login with password."
+			}
+			return "", ""
+		},
+	},
+	{
+		keyword: "passwordauthentication",
+		def:     "yes",
+		risky: func(v string) (string, string) {
+			if v == "yes" {
+				return SeverityWarning, "Password authentication is allowed, prefer keys."
+			}
+			return "", ""
+		},
+	},
+	{
+		keyword: "permitemptypasswords",
+		def:     "no",
+		risky: func(v string) (string, string) {
+			if v == "yes" {
+				return SeverityCritical, "Accounts with empty passwords can login."
+			}
+			return "", ""
+		},
+	},
+	{
+		keyword: "protocol",
+		risky: func(v string) (string, string) {
+			if strings.Contains(v, "1") {
+				return SeverityCritical, "Protocol 1 is insecure."
+			}
+			return "", ""
+		},
+	},
+	{
+		keyword: "permituserenvironment",
+		def:     "no",
+		risky: func(v string) (string, string) {
+			if v != "no" {
+				return SeverityWarning, "Users can set environment, may bypass restrictions."
+			}
+			return "", ""
+		},
+	},
+	{
+		keyword: "x11forwarding",
+		def:     "no",
+		risky: func(v string) (string, string) {
+			if v == "yes" {
+				return SeverityInfo, "X11 forwarding is enabled."
+			}
+			return "", ""
+		},
+	},
+	{
+		keyword: "maxauthtries",
+		def:     "6",
+		risky: func(v string) (string, string) {
+			if n, err := strconv.Atoi(v); err == nil && n > maxAuthTries {
+				return SeverityWarning, "Login attempts above " + strconv.Itoa(maxAuthTries) + " ease password guessing."
+			}
+			return "", ""
+		},
+	},
+}
+
+type auditor struct {
+	users uinfo.UserOps // Resolves users of allow lists
+}
+
+// NewAuditor inits the interface for auditing sshd configuration
+func NewAuditor() Auditor {
+	return &auditor{users: uinfo.NewUserOps()}
+}
+
+// Audit reports risky settings of global section and Match blocks,
+// and users and groups of allow lists unknown to system.
+func (a *auditor) Audit(file string, c *Config) *Report {
+	r := &Report{File: file, Findings: []Finding{}}
+
+	effective := c.Effective()
+	for _, chk := range checks {
+		global := false
+		for _, s := range effective {
+			if s.Keyword != chk.keyword {
+				continue
+			}
+			global = global || s.Match == ""
+			r.add(chk, s)
+		}
+
+		// Default of sshd applies when not set globally
+		if !global && chk.def != "" {
+			r.add(chk, Setting{Keyword: chk.keyword, Value: chk.def})
+		}
+	}
+
+	r.Findings = append(r.Findings, a.allowLists(c)...)
+
+	return r
+}
+
+// Adds finding when value of setting is risky
+func (r *Report) add(chk check, s Setting) {
+	severity, msg := chk.risky(strings.ToLower(s.Value))
+	if severity == "" {
+		return
+	}
+
+	if s.File == "" {
+		msg += " Default of sshd, not set in configuration."
+	}
+
+	r.Findings = append(r.Findings, newFinding(s, severity, msg))
+}
+
+// Cross-references AllowUsers and AllowGroups with system.
+// Patterns with wildcards are not resolved.
+func (a *auditor) allowLists(c *Config) []Finding {
+	findings := []Finding{}
+
+	users := c.Values("allowusers")
+	groups := c.Values("allowgroups")
+	if len(users) == 0 && len(groups) == 0 {
+		findings = append(findings, Finding{
+			Keyword:  "allowusers",
+			Severity: SeverityInfo,
+			Message:  "No AllowUsers or AllowGroups, all accounts with shell can login.",
+		})
+	}
+
+	for _, s := range users {
+		name := s.Value
+		if i := strings.Index(name, "@"); i >= 0 {
+			name = name[:i]
+		}
+		if strings.ContainsAny(name, "*?!") {
+			continue
+		}
+
+		if _, err := a.users.Get(name); err != nil {
+			findings = append(findings, newFinding(s, SeverityWarning, "User "+name+" of AllowUsers not found."))
+		} else if name == "root" {
+			findings = append(findings, newFinding(s, SeverityWarning, "Root is allowed to login."))
+		}
+	}
+
+	for _, s := range groups {
+		if strings.ContainsAny(s.Value, "*?!") {
+			continue
+		}
+		if _, err := user.LookupGroup(s.Value); err != nil {
+			findings = append(findings, newFinding(s, SeverityWarning, "Group "+s.Value+" of AllowGroups not found."))
+		}
+	}
+
+	return findings
+}
+
+// Returns finding of setting
+func newFinding(s Setting, severity, msg string) Finding {
+	return Finding{
+		Keyword:  s.Keyword,
+		Value:    s.Value,
+		Severity: severity,
+		Message:  msg,
+		File:     s.File,
+		Line:     s.Line,
+		Match:    s.Match,
+	}
+}
+
+// Worst returns highest severity of findings, blank without findings
+func Worst(findings []Finding) string {
+	worst := ""
+	rank := map[string]int{"": 0, SeverityInfo: 1, SeverityWarning: 2, SeverityCritical: 3}
+
+	for _, f := range findings {
+		if rank[f.Severity] > rank[worst] {
+			worst = f.Severity
+		}
+	}
+
+	return worst
+}
@@ -0,0 +1,213 @@
+package sshd
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultConfig is the sshd configuration of most distributions
+	DefaultConfig string = "/etc/ssh/sshd_config"
+
+	configDir    string = "/etc/ssh" // Relative includes are resolved from
+	includeDepth int    = 16         // Nesting of includes allowed
+)
+
+// Keywords accumulating values over lines, others keep first value
+var multiValued = map[string]bool{
+	"allowusers":  true,
+	"allowgroups": true,
+	"denyusers":   true,
+	"denygroups":  true,
+}
+
+// Setting is the keyword and value in configuration
+type Setting struct {
+	// Keyword is lower cased, keywords are case insensitive.
+	Keyword string `json:"keyword"`
+	Value   string `json:"value"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+
+	// Match is the criteria of Match block, blank for global settings.
+	Match string `json:"match,omitempty"`
+}
+
+// Config is the parsed sshd configuration in file order
+type Config struct {
+	Settings []Setting `json:"settings"`
+}
+
+// Load reads sshd configuration with included files
+func Load(file string) (*Config, error) {
+	c := &Config{Settings: []Setting{}}
+	if err := c.load(file, "", 0); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Parse reads sshd configuration from r, include directives are not followed
+func Parse(r io.Reader, name string) (*Config, error) {
+	c := &Config{Settings: []Setting{}}
+	if err := c.parse(r, name, "", 0, false); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Global returns first value of keyword outside Match blocks
+func (c *Config) Global(keyword string) (Setting, bool) {
+	keyword = strings.ToLower(keyword)
+	for _, s := range c.Settings {
+		if s.Keyword == keyword && s.Match == "" {
+			return s, true
+		}
+	}
+
+	return Setting{}, false
+}
+
+// Values returns all values of keyword outside Match blocks,
+// split on whitespace as for AllowUsers.
+func (c *Config) Values(keyword string) []Setting {
+	keyword = strings.ToLower(keyword)
+
+	var values []Setting
+	for _, s := range c.Settings {
+		if s.Keyword != keyword || s.Match != "" {
+			continue
+		}
+		for _, v := range strings.Fields(s.Value) {
+			sv := s
+			sv.Value = v
+			values = append(values, sv)
+		}
+	}
+
+	return values
+}
+
+// Effective returns settings in effect, first value of keyword
+// in each block, all values of accumulating keywords.
+func (c *Config) Effective() []Setting {
+	seen := make(map[string]bool)
+
+	var settings []Setting
+	for _, s := range c.Settings {
+		key := s.Match + "\x00" + s.Keyword
+		if seen[key] && !multiValued[s.Keyword] {
+			continue
+		}
+		seen[key] = true
+		settings = append(settings, s)
+	}
+
+	return settings
+}
+
+// Reads the file in block of match, follows includes up to depth
+func (c *Config) load(file, match string, depth int) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return c.parse(f, file, match, depth, true)
+}
+
+// Parses lines of keyword and value, separated by space or =.
+// Settings are of block of match until Match line, as of file
+// included within Match block.
+func (c *Config) parse(r io.Reader, name, match string, depth int, include bool) error {
+	lineNo := 0
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNo++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		keyword, value := splitLine(line)
+		keyword = strings.ToLower(keyword)
+
+		switch keyword {
+		case "match":
+			match = value
+			if strings.EqualFold(value, "all") {
+				match = ""
+			}
+
+		case "include":
+			if !include {
+				continue
+			}
+			if err := c.include(value, match, depth); err != nil {
+				return err
+			}
+
+		default:
+			c.Settings = append(c.Settings, Setting{
+				Keyword: keyword,
+				Value:   value,
+				File:    name,
+				Line:    lineNo,
+				Match:   match,
+			})
+		}
+	}
+
+	return scanner.Err()
+}
+
+// Loads files matching include patterns in block of match,
+// relative to /etc/ssh
+func (c *Config) include(patterns, match string, depth int) error {
+	if depth >= includeDepth {
+		return errors.New("Include nested too deeply: " + patterns)
+	}
+
+	for _, pattern := range strings.Fields(patterns) {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(configDir, pattern)
+		}
+
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if err := c.load(file, match, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Splits line to keyword and value, value may be quoted
+func splitLine(line string) (string, string) {
+	i := strings.IndexAny(line, " \t=")
+	if i < 0 {
+		return line, ""
+	}
+
+	value := strings.TrimSpace(line[i+1:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+	if len(value) > 1 && strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
+		value = value[1 : len(value)-1]
+	}
+
+	return line[:i], value
+}
@@ -0,0 +1,131 @@
+package sshd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/sshaudit/sshd"
+)
+
+const testConfig = `# Test configuration
+PermitRootLogin no
+permitrootlogin yes
+PasswordAuthentication=no
+AllowUsers root nosuchuser
+AllowUsers "adm*"
+AllowGroups nosuchgroup
+
+Match User backup
+	PasswordAuthentication yes
+Match all
+	X11Forwarding yes
+`
+
+func TestParse(t *testing.T) {
+	c, err := sshd.Parse(strings.NewReader(testConfig), "sshd_config")
+	if err != nil {
+		t.Fatalf("Parse() FAILED: %v", err)
+	}
+
+	s, ok := c.Global("PermitRootLogin")
+	if !ok || s.Value != "no" || s.Line != 2 {
+		t.Errorf("Global() FAILED, got %+v", s)
+	}
+
+	if s, ok := c.Global("PasswordAuthentication"); !ok || s.Value != "no" {
+		t.Errorf("Global() FAILED, value after = got %+v", s)
+	}
+
+	if users := c.Values("allowusers"); len(users) != 3 || users[2].Value != "adm*" {
+		t.Errorf("Values() FAILED, got %+v", users)
+	}
+
+	if s, ok := c.Global("x11forwarding"); !ok || s.Match != "" {
+		t.Errorf("Parse() FAILED, Match all not global: %+v", s)
+	}
+	t.Logf("Parse() PASSED")
+}
+
+func TestIncludeInMatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sshd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"sshd_config": "PasswordAuthentication no\nInclude " + dir + "/global.conf\n" +
+			"Match User backup\n\tInclude " + dir + "/backup.conf\n\tX11Forwarding no\n",
+		"global.conf": "PermitRootLogin no\n",
+		"backup.conf": "PasswordAuthentication yes\nMatch Address 10.0.0.0/8\n\tPermitRootLogin yes\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := sshd.Load(filepath.Join(dir, "sshd_config"))
+	if err != nil {
+		t.Fatalf("Load() FAILED: %v", err)
+	}
+
+	// Included settings are of enclosing block, Match of included file
+	// ends with it
+	var got []string
+	for _, s := range c.Settings {
+		got = append(got, filepath.Base(s.File)+":"+s.Keyword+":"+s.Value+":"+s.Match)
+	}
+	want := []string{
+		"sshd_config:passwordauthentication:no:",
+		"global.conf:permitrootlogin:no:",
+		"backup.conf:passwordauthentication:yes:User backup",
+		"backup.conf:permitrootlogin:yes:Address 10.0.0.0/8",
+		"sshd_config:x11forwarding:no:User backup",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Load() FAILED, expected: %v got: %v", want, got)
+	}
+	if s, ok := c.Global("PasswordAuthentication"); !ok || s.Value != "no" {
+		t.Errorf("Global() FAILED, included Match setting global: %+v", s)
+	}
+	t.Logf("IncludeInMatch() PASSED")
+}
+
+func TestAudit(t *testing.T) {
+	c, err := sshd.Parse(strings.NewReader(testConfig), "sshd_config")
+	if err != nil {
+		t.Fatalf("Parse() FAILED: %v", err)
+	}
+
+	r := sshd.NewAuditor().Audit("sshd_config", c)
+
+	found := make(map[string]string)
+	for _, f := range r.Findings {
+		found[f.Keyword+":"+f.Value+":"+f.Match] = f.Severity
+	}
+
+	for key, severity := range map[string]string{
+		"passwordauthentication:yes:User backup": sshd.SeverityWarning,
+		"x11forwarding:yes:":                     sshd.SeverityInfo,
+		"allowusers:nosuchuser:":                 sshd.SeverityWarning,
+		"allowusers:root:":                       sshd.SeverityWarning,
+		"allowgroups:nosuchgroup:":               sshd.SeverityWarning,
+	} {
+		if found[key] != severity {
+			t.Errorf("Audit() FAILED, finding %s is %q, want %q", key, found[key], severity)
+		}
+	}
+
+	if _, ok := found["permitrootlogin:yes:"]; ok {
+		t.Errorf("Audit() FAILED, second value of PermitRootLogin reported")
+	}
+
+	if sshd.Worst(r.Findings) != sshd.SeverityWarning {
+		t.Errorf("Worst() FAILED, got %s", sshd.Worst(r.Findings))
+	}
+	t.Logf("Audit() PASSED")
+}
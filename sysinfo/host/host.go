@@ -0,0 +1,224 @@
+package host
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Host facts parsed from procfs and sysfs
+const (
+	procDir    = "/proc"
+	sysCPUDir  = "/sys/devices/system/cpu"
+	osRelease  = "sys/kernel/osrelease"
+	osVersion  = "sys/kernel/version"
+	hostname   = "sys/kernel/hostname"
+	uptimeFile = "uptime"
+	loadFile   = "loadavg"
+	cpuFile    = "cpuinfo"
+	memFile    = "meminfo"
+)
+
+// CPU summary of host
+type CPU struct {
+	// Model is the model name of first processor.
+	Model string `json:"model"`
+
+	// Cores is the number of physical cores.
+	Cores int `json:"cores"`
+
+	// Threads is the number of logical processors.
+	Threads int `json:"threads"`
+}
+
+// Memory and swap of host in bytes
+type Memory struct {
+	Total     uint64 `json:"totalBytes"`
+	Available uint64 `json:"availableBytes"`
+	SwapTotal uint64 `json:"swapTotalBytes"`
+	SwapFree  uint64 `json:"swapFreeBytes"`
+}
+
+// Facts about the host
+type Facts struct {
+	Hostname      string     `json:"hostname"`
+	Kernel        string     `json:"kernel"`
+	KernelVersion string     `json:"kernelVersion"`
+	UptimeSeconds float64    `json:"uptimeSeconds"`
+	CPU           CPU        `json:"cpu"`
+	Memory        Memory     `json:"memory"`
+	LoadAverage   [3]float64 `json:"loadAverage"`
+}
+
+// Gatherer interface collects host facts
+type Gatherer interface {
+	Gather() (*Facts, error)
+}
+
+type gatherer struct {
+	proc string // Mount point of procfs
+	cpu  string // Sysfs directory of cpus
+}
+
+// NewGatherer inits the interface for host facts
+func NewGatherer() Gatherer {
+	return NewGathererWithRoot(procDir, sysCPUDir)
+}
+
+// NewGathererWithRoot inits host facts of procfs mounted at proc
+// and sysfs cpu directory, as of container or copy of them.
+func NewGathererWithRoot(proc, cpu string) Gatherer {
+	return &gatherer{
+		proc: proc,
+		cpu:  cpu,
+	}
+}
+
+// Gather collects the host facts
+func (g *gatherer) Gather() (*Facts, error) {
+	var err error
+	f := &Facts{}
+
+	if f.Hostname, err = g.readLine(hostname); err != nil {
+		return nil, err
+	}
+	if f.Kernel, err = g.readLine(osRelease); err != nil {
+		return nil, err
+	}
+	if f.KernelVersion, err = g.readLine(osVersion); err != nil {
+		return nil, err
+	}
+
+	uptime, err := g.readFields(uptimeFile, 1)
+	if err != nil {
+		return nil, err
+	}
+	f.UptimeSeconds, _ = strconv.ParseFloat(uptime[0], 64)
+
+	load, err := g.readFields(loadFile, len(f.LoadAverage))
+	if err != nil {
+		return nil, err
+	}
+	for i := range f.LoadAverage {
+		f.LoadAverage[i], _ = strconv.ParseFloat(load[i], 64)
+	}
+
+	if f.CPU, err = g.cpuInfo(); err != nil {
+		return nil, err
+	}
+	if f.Memory, err = g.memInfo(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Reads the first line of procfs file
+func (g *gatherer) readLine(name string) (string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(g.proc, name))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(strings.SplitN(string(content), "\n", 2)[0]), nil
+}
+
+// Reads space separated fields of procfs file, at least min fields
+func (g *gatherer) readFields(name string, min int) ([]string, error) {
+	line, err := g.readLine(name)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < min {
+		return nil, errors.New("Invalid content in " + name)
+	}
+
+	return fields, nil
+}
+
+// Parses model and threads from cpuinfo, cores from sysfs topology
+func (g *gatherer) cpuInfo() (CPU, error) {
+	cpu := CPU{}
+
+	content, err := ioutil.ReadFile(filepath.Join(g.proc, cpuFile))
+	if err != nil {
+		return cpu, err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		attrs := strings.SplitN(line, ":", 2)
+		if len(attrs) != 2 {
+			continue
+		}
+
+		switch strings.TrimSpace(attrs[0]) {
+		case "processor":
+			cpu.Threads++
+		case "model name":
+			if cpu.Model == "" {
+				cpu.Model = strings.TrimSpace(attrs[1])
+			}
+		}
+	}
+
+	cpu.Cores = g.physicalCores()
+	if cpu.Cores == 0 {
+		cpu.Cores = cpu.Threads
+	}
+
+	return cpu, nil
+}
+
+// Counts unique package and core id pairs from sysfs
+func (g *gatherer) physicalCores() int {
+	cores := make(map[string]bool)
+
+	dirs, _ := filepath.Glob(filepath.Join(g.cpu, "cpu[0-9]*", "topology"))
+	for _, dir := range dirs {
+		pkg, err := ioutil.ReadFile(filepath.Join(dir, "physical_package_id"))
+		if err != nil {
+			continue
+		}
+		core, err := ioutil.ReadFile(filepath.Join(dir, "core_id"))
+		if err != nil {
+			continue
+		}
+		cores[strings.TrimSpace(string(pkg))+":"+strings.TrimSpace(string(core))] = true
+	}
+
+	return len(cores)
+}
+
+// Parses memory and swap from meminfo
+func (g *gatherer) memInfo() (Memory, error) {
+	mem := Memory{}
+
+	content, err := ioutil.ReadFile(filepath.Join(g.proc, memFile))
+	if err != nil {
+		return mem, err
+	}
+
+	fields := map[string]*uint64{
+		"MemTotal":     &mem.Total,
+		"MemAvailable": &mem.Available,
+		"SwapTotal":    &mem.SwapTotal,
+		"SwapFree":     &mem.SwapFree,
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		attrs := strings.Fields(strings.Replace(line, ":", " ", 1))
+		if len(attrs) < 2 {
+			continue
+		}
+		if field, ok := fields[attrs[0]]; ok {
+			kb, _ := strconv.ParseUint(attrs[1], 10, 64)
+			*field = kb * 1024
+		}
+	}
+
+	return mem, nil
+}
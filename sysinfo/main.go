@@ -2,7 +2,11 @@ package main
 
 import (
 	"flag"
+	"io/ioutil"
+
 	log "github.com/golang/glog"
+	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
+	"github.io/sysinfo/host"
 )
 
 // Default option for save / print data
@@ -13,14 +17,38 @@ const (
 // Flags for the tool
 // format : yaml or json formatted output
 // saveas : file for saving the info
+// host   : collect host facts from procfs and sysfs, in json
 var (
 	format = flag.String("format", "json", "Prints the information in json | pretty format")
 	saveas = flag.String("saveas", STDOUT, "Saves the information in given file or stdout")
+	facts  = flag.Bool("host", false, "Collects host facts from /proc and sysfs in json")
 )
 
+// saveFacts gathers the host facts and saves in json
+func saveFacts(file string) error {
+	f, err := host.NewGatherer().Gather()
+	if err != nil {
+		return err
+	}
+
+	jsonFacts, err := uinfo.Decode(f)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, []byte(jsonFacts+"\n"), 0644)
+}
+
 func main() {
 	flag.Parse()
 
+	if *facts {
+		if err := saveFacts(*saveas); err != nil {
+			log.Error("Error : ", err.Error())
+		}
+		return
+	}
+
 	sysinfo, err := NewInformer()
 	if err != nil {
 		log.Error("Error: ", err.Error())
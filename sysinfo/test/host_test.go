@@ -0,0 +1,136 @@
+package host
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.io/sysinfo/host"
+)
+
+const testCPUInfo = `processor	: 0
+model name	: Test CPU @ 2.40GHz
+cpu cores	: 2
+
+processor	: 1
+model name	: Test CPU @ 2.40GHz
+
+processor	: 2
+model name	: Other CPU
+
+processor	: 3
+model name	: Other CPU
+`
+
+const testMemInfo = `MemTotal:        8048576 kB
+MemFree:         1024000 kB
+MemAvailable:    4096000 kB
+SwapTotal:       2097148 kB
+SwapFree:        2097000 kB
+`
+
+// Writes files under root
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Returns procfs and sysfs cpu directory of test host
+func writeHost(t *testing.T) (string, string, string) {
+	root, err := ioutil.TempDir("", "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proc, cpu := filepath.Join(root, "proc"), filepath.Join(root, "cpu")
+
+	writeFiles(t, proc, map[string]string{
+		"sys/kernel/hostname":  "web1\n",
+		"sys/kernel/osrelease": "6.1.0-test\n",
+		"sys/kernel/version":   "#1 SMP PREEMPT_DYNAMIC Debian 6.1.0\n",
+		"uptime":               "3600.25 7000.50\n",
+		"loadavg":              "0.50 0.25 0.10 1/200 4182\n",
+		"cpuinfo":              testCPUInfo,
+		"meminfo":              testMemInfo,
+	})
+
+	// Two cores with two threads each
+	topology := map[string]string{}
+	for i, core := range []string{"0", "1", "0", "1"} {
+		dir := "cpu" + strconv.Itoa(i) + "/topology/"
+		topology[dir+"physical_package_id"] = "0\n"
+		topology[dir+"core_id"] = core + "\n"
+	}
+	writeFiles(t, cpu, topology)
+
+	return root, proc, cpu
+}
+
+func TestGather(t *testing.T) {
+	root, proc, cpu := writeHost(t)
+	defer os.RemoveAll(root)
+
+	f, err := host.NewGathererWithRoot(proc, cpu).Gather()
+	if err != nil {
+		t.Fatalf("Gather() FAILED, %v", err)
+	}
+
+	if f.Hostname != "web1" || f.Kernel != "6.1.0-test" || f.KernelVersion != "#1 SMP PREEMPT_DYNAMIC Debian 6.1.0" ||
+		f.UptimeSeconds != 3600.25 || f.LoadAverage != [3]float64{0.50, 0.25, 0.10} {
+		t.Errorf("Gather() FAILED, got %+v", f)
+	}
+	if f.CPU.Model != "Test CPU @ 2.40GHz" || f.CPU.Threads != 4 || f.CPU.Cores != 2 {
+		t.Errorf("Gather() FAILED, cpu %+v", f.CPU)
+	}
+	if f.Memory.Total != 8048576*1024 || f.Memory.Available != 4096000*1024 ||
+		f.Memory.SwapTotal != 2097148*1024 || f.Memory.SwapFree != 2097000*1024 {
+		t.Errorf("Gather() FAILED, memory %+v", f.Memory)
+	}
+	t.Logf("Gather() PASSED")
+}
+
+func TestGatherNoTopology(t *testing.T) {
+	root, proc, _ := writeHost(t)
+	defer os.RemoveAll(root)
+
+	// Cores are threads when sysfs topology is missing
+	f, err := host.NewGathererWithRoot(proc, filepath.Join(root, "missing")).Gather()
+	if err != nil || f.CPU.Cores != 4 {
+		t.Errorf("Gather() FAILED, cpu %+v, %v", f, err)
+	}
+	t.Logf("GatherNoTopology() PASSED")
+}
+
+func TestGatherInvalid(t *testing.T) {
+	root, proc, cpu := writeHost(t)
+	defer os.RemoveAll(root)
+
+	writeFiles(t, proc, map[string]string{"loadavg": "0.50\n"})
+	if _, err := host.NewGathererWithRoot(proc, cpu).Gather(); err == nil {
+		t.Errorf("Gather() FAILED, invalid loadavg accepted")
+	}
+
+	os.Remove(filepath.Join(proc, "meminfo"))
+	writeFiles(t, proc, map[string]string{"loadavg": "0.50 0.25 0.10 1/200 4182\n"})
+	if _, err := host.NewGathererWithRoot(proc, cpu).Gather(); err == nil {
+		t.Errorf("Gather() FAILED, missing meminfo accepted")
+	}
+	t.Logf("GatherInvalid() PASSED")
+}
+
+func TestGatherHost(t *testing.T) {
+	f, err := host.NewGatherer().Gather()
+	hostname, _ := os.Hostname()
+	if err != nil || f.Hostname != hostname || f.Kernel == "" || f.CPU.Threads == 0 || f.Memory.Total == 0 {
+		t.Errorf("Gather() FAILED, host %+v, %v", f, err)
+	}
+	t.Logf("GatherHost() PASSED")
+}
@@ -3,6 +3,29 @@ module github.com/prashant-sb/go-utils/userinfo
 go 1.13
 
 require (
-	github.com/golang/glog v0.0.0-20160126235308-23def4e6c14b
+	github.com/prashant-sb/go-utils/archive v0.0.0
+	github.com/prashant-sb/go-utils/config v0.0.0
+	github.com/prashant-sb/go-utils/file_signatures v0.0.0
+	github.com/prashant-sb/go-utils/lifecycle v0.0.0
+	github.com/prashant-sb/go-utils/logging v0.0.0
+	github.com/prashant-sb/go-utils/output v0.0.0
+	github.com/prashant-sb/go-utils/pool v0.0.0
+	github.com/prashant-sb/go-utils/privs v0.0.0
+	github.com/prashant-sb/go-utils/retry v0.0.0
+	github.com/prashant-sb/go-utils/walker v0.0.0
 	golang.org/x/crypto v0.0.0-20201002170205-7f63de1d35b0
 )
+
+replace (
+	github.com/prashant-sb/go-utils/archive => ../archive
+	github.com/prashant-sb/go-utils/config => ../config
+	github.com/prashant-sb/go-utils/file_signatures => ../file_signatures
+	github.com/prashant-sb/go-utils/lifecycle => ../lifecycle
+	github.com/prashant-sb/go-utils/logging => ../logging
+	github.com/prashant-sb/go-utils/output => ../output
+	github.com/prashant-sb/go-utils/pool => ../pool
+	github.com/prashant-sb/go-utils/privs => ../privs
+	github.com/prashant-sb/go-utils/retry => ../retry
+	github.com/prashant-sb/go-utils/signing => ../signing
+	github.com/prashant-sb/go-utils/walker => ../walker
+)
@@ -1,10 +1,19 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
-	log "github.com/golang/glog"
+	"github.com/prashant-sb/go-utils/config"
+	"github.com/prashant-sb/go-utils/lifecycle"
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/output"
+	"github.com/prashant-sb/go-utils/privs"
 	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
 )
 
@@ -12,41 +21,386 @@ import (
 //
 // -list -user <username>   : List specific user schema
 // -list                    : List all system users
+// -list -output <format>   : auto | table | json, table on terminal
 // -create -from <json>	    : Create user from given json schema file
+// -modify -from <json>     : Modify user to match json schema file
+// -apply -from <json>      : Create or modify user to match json schema file
+// -apply -from <json> -dryrun : Prints the change plan without applying
 // -delete -user <username> : Deletes user by username
+// -delete -user <username> -grace <days> : Locks user, deletes it after days with -purge-deletions
+// -pending-deletions       : Lists users locked for deletion
+// -user <username> -cancel-deletion : Unlocks user pending deletion
+// -purge-deletions         : Deletes users pending deletion after grace, run from cron
+// -user <username> -move-home <dir> [-home-base <d1,d2>] : Moves home of user below bases, verified before removal
+// -user <username> -can-access <path> [-access <rwx>] : Checks access of user to path
+// -user <username> -rotate-ssh-key [-remove-keys-older <days>] : Installs new ssh key, prints private key
+// -delete -users <u1,u2>   : Prints confirm token for batch delete
+// -delete -users <u1,u2> -confirm <token> [-force] : Deletes batch of users
+// -list -group <group>     : List group with members, admins and password state
+// -groups                  : List all groups, gshadow needs root
+// -shells                  : List valid login shells of /etc/shells
+// -privileged              : List users with uid 0 and members of admin groups
+// -uid-collisions <p1,p2> -export-passwd <file> : Lists file owners mapped to other user by exporting host
+// -uid-collisions <p1,p2> -host <exporter> -ssh-key <key> : Reads users of exporting host over ssh
+// -group <group> -admins <u1,u2>  : Sets administrators of group
+// -group <group> -members <u1,u2> : Sets members of group
+// -group <group> -sync-members <u1,u2> [-prune] : Adds missing members, removes others with prune
+// -config <yaml|toml>      : Reads flag values from yaml or toml file
+// -resolver <resolver>     : auto | files | nss, os/user as built will be default
+// -host <host> -ssh-user <user> -ssh-key <key> [-sudo] : Manages users of remote host
+//
+// Flags are also read from USERINFO_<FLAG> environment,
+// command line takes precedence over environment and file.
 var (
 	list   = flag.Bool("list", false, "Lists the system users")
 	create = flag.Bool("create", false, "Creates the system user")
 	delete = flag.Bool("delete", false, "Deletes the system user")
+	modify = flag.Bool("modify", false, "Modifies the system user")
+	apply  = flag.Bool("apply", false, "Creates or modifies the system user")
+	dryrun = flag.Bool("dryrun", false, "Prints the change plan without applying")
+
+	user    = flag.String("user", "", "List specific system user")
+	users   = flag.String("users", "", "Comma separated users for batch delete")
+	from    = flag.String("from", "", "Json configuration for create user")
+	confirm = flag.String("confirm", "", "Confirmation token for batch delete")
+	force   = flag.Bool("force", false, "Allow batch delete of system users")
+	grace   = flag.Int("grace", 0, "Locks user deleted, deletes it after days with purge-deletions")
+	pending = flag.Bool("pending-deletions", false, "Lists users locked for deletion")
+	cancel  = flag.Bool("cancel-deletion", false, "Unlocks user pending deletion")
+	purge   = flag.Bool("purge-deletions", false, "Deletes users pending deletion after grace")
+	group   = flag.String("group", "", "Group to list or administer")
+	groups  = flag.Bool("groups", false, "Lists the system groups")
+	shells  = flag.Bool("shells", false, "Lists the valid login shells")
+	newHome = flag.String("move-home", "", "Moves home directory of user to path")
+	homeDir = flag.String("home-base", "/home", "Comma separated bases of homes moved with move-home")
+	canPath = flag.String("can-access", "", "Checks access of user to path")
+	access  = flag.String("access", "r", "Access mode checked with can-access, of r, w and x")
+	rotate  = flag.Bool("rotate-ssh-key", false, "Installs new ssh key of user, prints its private key")
+	keyAge  = flag.Int("remove-keys-older", 0, "Removes rotated ssh keys older than days")
+	privd   = flag.Bool("privileged", false, "Lists users with uid 0 and members of admin groups")
+	collide = flag.String("uid-collisions", "", "Comma separated paths scanned for owners mapped to other user by exporting host")
+	exports = flag.String("export-passwd", "", "Passwd file of exporting host for uid-collisions")
+	admins  = flag.String("admins", "", "Comma separated administrators of group, - removes all")
+	members = flag.String("members", "", "Comma separated members of group, - removes all")
+	syncs   = flag.String("sync-members", "", "Comma separated members added to group, - for none")
+	prune   = flag.Bool("prune", false, "Removes members of group not in sync-members")
+	cfgFile = flag.String("config", "", "Yaml or toml configuration file for flags")
+	format  = flag.String("output", "auto", "Output format of list, auto | table | json")
+	resolve = flag.String("resolver", "auto", "Resolver of local users and groups, auto | files | nss")
+
+	host       = flag.String("host", "", "Remote host managed over ssh, host[:port]")
+	sshUser    = flag.String("ssh-user", "root", "Login user of remote host")
+	sshKey     = flag.String("ssh-key", "", "Private key for ssh authentication")
+	knownHosts = flag.String("known-hosts", "", "Known hosts file, ~/.ssh/known_hosts will be default")
+	sudo       = flag.Bool("sudo", false, "Runs commands on remote host with sudo")
 
-	user = flag.String("user", "", "List specific system user")
-	from = flag.String("from", "", "Json configuration for create user")
+	logLevel  = flag.String("log-level", "info", "Log level, debug | info | warn | error")
+	logFormat = flag.String("log-format", "text", "Log format, text | json")
+)
+
+// Prefix of environment variables for flags
+const envPrefix = "USERINFO"
+
+// Actions changing users and their confirmations, set from command line
+// only so stray environment or configuration file never changes users
+var commandLineOnly = []string{
+	"create", "delete", "modify", "apply", "users", "confirm", "force",
+	"grace", "cancel-deletion", "purge-deletions", "move-home", "rotate-ssh-key",
+	"remove-keys-older", "admins", "members", "sync-members", "prune",
+}
+
+// Name of tool and schema version of its json documents
+const (
+	toolName      = "userinfo"
+	schemaVersion = 1
 )
 
 func main() {
 	flag.Parse()
 
+	err := config.Load(flag.CommandLine, config.Options{
+		File:            *cfgFile,
+		EnvPrefix:       envPrefix,
+		CommandLineOnly: commandLineOnly,
+	})
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+
+	if err := log.Setup(*logLevel, *logFormat); err != nil {
+		log.Error(err.Error())
+		return
+	}
+
+	if err := uinfo.SetResolver(*resolve); err != nil {
+		log.Error(err.Error())
+		return
+	}
+	log.Debug("Resolving local users and groups with ", uinfo.Resolver())
+
+	// Changes of users need privileges, checked before any change
+	changes := *create || ((*modify || *apply) && !*dryrun) ||
+		(*delete && (*user != "" || *confirm != "")) ||
+		(*group != "" && (*admins != "" || *members != "" || *syncs != "")) ||
+		(*user != "" && (*newHome != "" || *rotate || *cancel)) || *purge
+	if changes && *host == "" {
+		if err := privs.Require(privs.CapSetuid); err != nil {
+			log.Error(err.Error())
+			return
+		}
+	}
+
+	ui, closeOps, err := newUserOps()
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+	defer closeOps()
+
 	switch {
+	case *groups, *list && *group != "":
+		table, err := useTable(*format)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		var list []uinfo.Groupinfo
+		if *group != "" {
+			g, err := ui.GetGroup(*group)
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+			list = []uinfo.Groupinfo{*g}
+		} else if list, err = ui.ListGroups(); err != nil {
+			log.Error("Error in listing groups: ", err)
+			return
+		}
+
+		if table {
+			printGroups(list)
+			return
+		}
+
+		if *group != "" {
+			printJSON("group", list[0])
+			return
+		}
+		printJSON("groups", list)
+
+	case *privd:
+		table, err := useTable(*format)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		list, err := ui.PrivilegedUsers()
+		if err != nil {
+			log.Error("Error in listing privileged users: ", err)
+			return
+		}
+
+		if table {
+			t := output.NewTable(os.Stdout, "USERNAME", "UID", "REASONS")
+			for _, p := range list {
+				t.Append(p.Username, p.Uid, strings.Join(p.Reasons, ","))
+			}
+			if err := t.Render(); err != nil {
+				log.Error("Error in writing output: ", err)
+			}
+			return
+		}
+
+		printJSON("privileged", list)
+
+	case *collide != "":
+		table, err := useTable(*format)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		// Paths are walked locally, users of -host are of exporter
+		var exporter uinfo.UserOps
+		if *host != "" {
+			exporter = ui
+		}
+		list, err := uinfo.NewUserOps().UidCollisions(exporter, uinfo.CollisionOptions{
+			Paths:        strings.Split(*collide, ","),
+			ExportPasswd: *exports,
+		})
+		if err != nil {
+			log.Error("Error in scanning uid collisions: ", err)
+			return
+		}
+
+		if table {
+			t := output.NewTable(os.Stdout, "UID", "LOCAL", "EXPORTER", "FILES", "PATH")
+			for _, c := range list {
+				t.Append(c.Uid, orDash(c.LocalUser), orDash(c.ExportUser), strconv.Itoa(c.Files), c.Paths[0])
+			}
+			if err := t.Render(); err != nil {
+				log.Error("Error in writing output: ", err)
+			}
+			return
+		}
+
+		printJSON("uid-collisions", list)
+
+	case *pending:
+		table, err := useTable(*format)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		list, err := ui.ListPendingDeletions()
+		if err != nil {
+			log.Error("Error in listing pending deletions: ", err)
+			return
+		}
+
+		if table {
+			t := output.NewTable(os.Stdout, "USERNAME", "UID", "LOCKED", "DELETE AFTER")
+			for _, p := range list {
+				t.Append(p.Username, p.Uid, p.Locked.Format(time.RFC3339), p.DeleteAfter.Format(time.RFC3339))
+			}
+			if err := t.Render(); err != nil {
+				log.Error("Error in writing output: ", err)
+			}
+			return
+		}
+
+		printJSON("pending-deletions", list)
+
+	case *user != "" && *cancel:
+		if err := ui.CancelDeletion(*user); err != nil {
+			log.Error("Error in cancelling deletion: ", err)
+			return
+		}
+		fmt.Printf("Deletion of %s cancelled, user unlocked.\n", *user)
+
+	case *purge:
+		results, err := ui.PurgeDeletions()
+		if err != nil {
+			log.Error("Error in purging deletions: ", err)
+			return
+		}
+
+		printJSON("deletions", results)
+
+	case *user != "" && *newHome != "":
+		if err := uinfo.SetHomeBases(strings.Split(*homeDir, ",")); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		if err := ui.MoveHome(*user, *newHome); err != nil {
+			log.Error(err.Error())
+			return
+		}
+		fmt.Printf("Home of %s moved to %s\n", *user, *newHome)
+
+	case *user != "" && *canPath != "":
+		mode, err := uinfo.ParseAccessMode(*access)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		a, err := ui.CanAccess(*user, *canPath, mode)
+		if err != nil {
+			log.Error("Error in checking access: ", err)
+			return
+		}
+		printAccess(a)
+
+	case *user != "" && *rotate:
+		key, err := ui.RotateSSHKey(*user, uinfo.RotateOptions{
+			RemoveOlderThan: time.Duration(*keyAge) * 24 * time.Hour,
+		})
+		if err != nil {
+			log.Error("Error in rotating ssh key: ", err)
+			return
+		}
+
+		// Private key is not stored, printed once for caller
+		os.Stdout.Write(key.PrivateKey)
+		log.Info("Installed ssh key ", key.Fingerprint, " of ", *user, ", removed ", len(key.Removed), " rotated keys")
+
+	case *shells:
+		list, err := ui.ListValidShells()
+		if err != nil {
+			log.Error("Error in listing shells: ", err)
+			return
+		}
+		for _, s := range list {
+			fmt.Println(s)
+		}
+
+	case *group != "" && *syncs != "":
+		table, err := useTable(*format)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
+		changes, err := ui.SyncGroupMembers(*group, listFlag(*syncs), *prune)
+		if changes != nil {
+			printGroupChanges(changes, table)
+		}
+		// Changes applied before failure are printed, sync still fails
+		if err != nil {
+			log.Error("Error in syncing members of group: ", err)
+			closeOps()
+			os.Exit(1)
+		}
+
+	case *group != "" && (*admins != "" || *members != ""):
+		if *admins != "" {
+			if err := ui.SetGroupAdmins(*group, listFlag(*admins)); err != nil {
+				log.Error(err.Error())
+				return
+			}
+			fmt.Printf("Administrators of %s set.\n", *group)
+		}
+		if *members != "" {
+			if err := ui.SetGroupMembers(*group, listFlag(*members)); err != nil {
+				log.Error(err.Error())
+				return
+			}
+			fmt.Printf("Members of %s set.\n", *group)
+		}
+
 	case *list:
+		table, err := useTable(*format)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+
 		// Get the user details
 		if *user != "" {
-			ui := uinfo.NewUserOps()
-
 			u, err := ui.Get(*user)
 			if err != nil {
 				log.Error(err.Error())
 				return
 			}
 
-			jsonUser, err := uinfo.Decode(u)
-			if err != nil {
-				log.Error("Error in decode ", jsonUser)
+			if table {
+				printUsers([]uinfo.Userinfo{*u})
 				return
 			}
 
-			fmt.Printf("%+v\n", jsonUser)
+			printJSON("user", u)
 		} else {
 			// List all users
+			if *host != "" {
+				log.Error("Listing all users of remote host not supported, use -user")
+				return
+			}
 			ul := uinfo.NewUserList()
 			ulist, err := ul.Get()
 			if err != nil {
@@ -54,19 +408,17 @@ func main() {
 				return
 			}
 
-			jsonList, err := uinfo.Decode(ulist)
-			if err != nil {
-				log.Error("Error in decode: ", err)
+			if table {
+				printUsers(ulist.Users)
 				return
 			}
 
-			fmt.Printf("%v\n", jsonList)
+			printJSON("users", ulist)
 		}
 
 	case *create:
 		// Add user from json User Schema
 		if *from != "" {
-			ui := uinfo.NewUserOps()
 			userName, err := ui.AddUser(*from)
 			if err != nil {
 				log.Error(err.Error())
@@ -75,15 +427,61 @@ func main() {
 			fmt.Printf("User %s added\n", userName)
 		}
 
+	case *modify, *apply:
+		// Modify or apply user from json User Schema, prints the plan
+		if *from != "" {
+			var plan *uinfo.Plan
+			var err error
+			switch {
+			case *dryrun:
+				plan, err = ui.PlanChanges(*from)
+			case *modify:
+				plan, err = ui.Modify(*from)
+			default:
+				plan, err = ui.Apply(*from)
+			}
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+
+			printJSON("plan", plan)
+		}
+
 	case *delete:
-		// Deletes user by Username
-		if *user != "" {
-			ui := uinfo.NewUserOps()
+		// Locks user by Username, deleted after grace
+		if *user != "" && *grace > 0 {
+			p, err := ui.ScheduleDeletion(*user, time.Duration(*grace)*24*time.Hour)
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+			fmt.Printf("%s user locked, deleted after %s.\n", *user, p.DeleteAfter.Format(time.RFC3339))
+		} else if *user != "" {
+			// Deletes user by Username
 			if _, err := ui.DeleteUser(*user); err != nil {
 				log.Error(err.Error())
 				return
 			}
 			fmt.Printf("%s user deleted.\n", *user)
+		} else if *users != "" {
+			// Deletes batch of users with confirmation
+			names := strings.Split(*users, ",")
+			if *confirm == "" {
+				fmt.Printf("Confirm with -confirm %s\n", uinfo.DeleteToken(names))
+				return
+			}
+
+			results, err := ui.DeleteUsers(names, uinfo.DeleteOptions{
+				Force:   *force,
+				Confirm: *confirm,
+			})
+			if err != nil {
+				log.Error(err.Error())
+				return
+			}
+
+			printJSON("deletions", results)
 		}
 
 	default:
@@ -91,3 +489,130 @@ func main() {
 		flag.Usage()
 	}
 }
+
+// Prints v as json document of kind in envelope
+func printJSON(kind string, v interface{}) {
+	if err := output.NewEnvelope(toolName, kind, schemaVersion, v).Write(os.Stdout, "   "); err != nil {
+		log.Error("Error in writing output: ", err)
+	}
+}
+
+// Returns true if list is printed as table for output format
+func useTable(format string) (bool, error) {
+	switch format {
+	case "auto":
+		return lifecycle.IsTerminal(os.Stdout), nil
+	case "table":
+		return true, nil
+	case "json":
+		return false, nil
+	}
+
+	return false, errors.New("Output format " + format + " not supported.")
+}
+
+// Prints users aligned in columns
+func printUsers(users []uinfo.Userinfo) {
+	table := output.NewTable(os.Stdout, "USERNAME", "UID", "GID", "GROUP", "HOME", "NAME")
+	for _, u := range users {
+		table.Append(u.Username, u.Uid, u.Gid, u.PrimaryGroup, u.HomeDir, u.Name)
+	}
+
+	if err := table.Render(); err != nil {
+		log.Error("Error in writing output: ", err)
+	}
+}
+
+// Prints groups aligned in columns
+func printGroups(groups []uinfo.Groupinfo) {
+	table := output.NewTable(os.Stdout, "GROUP", "GID", "MEMBERS", "ADMINS", "PASSWORD")
+	for _, g := range groups {
+		passwd := "no"
+		switch {
+		case !g.Shadowed:
+			passwd = "-"
+		case g.HasPassword:
+			passwd = "yes"
+		}
+		table.Append(g.Name, g.Gid, strings.Join(g.Members, ","), strings.Join(g.Admins, ","), passwd)
+	}
+
+	if err := table.Render(); err != nil {
+		log.Error("Error in writing output: ", err)
+	}
+}
+
+// Prints members added, removed and kept by sync of group
+func printGroupChanges(changes *uinfo.GroupChanges, table bool) {
+	if !table {
+		printJSON("group-sync", changes)
+		return
+	}
+
+	t := output.NewTable(os.Stdout, "GROUP", "CHANGE", "MEMBER")
+	for _, name := range changes.Added {
+		t.Append(changes.Group, "added", name)
+	}
+	for _, name := range changes.Removed {
+		t.Append(changes.Group, "removed", name)
+	}
+	for _, name := range changes.Kept {
+		t.Append(changes.Group, "kept", name)
+	}
+	if err := t.Render(); err != nil {
+		log.Error("Error in writing output: ", err)
+	}
+}
+
+// Returns value, - when blank
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
+
+// Returns names of comma separated flag, - for empty list
+func listFlag(value string) []string {
+	if value == "-" {
+		return []string{}
+	}
+
+	return strings.Split(value, ",")
+}
+
+// Returns user ops of local host, or of remote host over ssh
+// with function closing the connection.
+func newUserOps() (uinfo.UserOps, func(), error) {
+	if *host == "" {
+		return uinfo.NewUserOps(), func() {}, nil
+	}
+
+	runner, err := uinfo.NewSSHRunner(uinfo.SSHOptions{
+		Host:       *host,
+		User:       *sshUser,
+		KeyFile:    *sshKey,
+		KnownHosts: *knownHosts,
+		Sudo:       *sudo,
+	})
+	if err != nil {
+		return nil, nil, errors.New("Error in connecting " + *host + ": " + err.Error())
+	}
+
+	return uinfo.NewRemoteUserOps(runner), func() { runner.Close() }, nil
+}
+
+// Prints whether user can access path, with entry deciding it
+func printAccess(a *uinfo.Access) {
+	verdict := "can"
+	if !a.Allowed {
+		verdict = "can NOT"
+	}
+
+	reason := a.Reason
+	if a.DeniedAt != "" {
+		reason = "search denied on " + a.DeniedAt + " by " + a.Reason
+	}
+	fmt.Printf("%s %s %s %s (%s)\n", a.Username, verdict, a.Mode, a.Path, reason)
+}
@@ -1,7 +1,14 @@
 package users
 
 import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	uinfo "github.com/prashant-sb/go-utils/userinfo/users"
 )
@@ -12,6 +19,23 @@ const (
 	testSysDB  = "/etc/passwd"
 )
 
+// State of tests is kept out of /var/lib/userinfo
+var testStateDir string
+
+func TestMain(m *testing.M) {
+	var err error
+	if testStateDir, err = ioutil.TempDir("", "userinfo-state"); err != nil {
+		panic(err)
+	}
+	if err := uinfo.SetStateDir(testStateDir); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(testStateDir)
+	os.Exit(code)
+}
+
 func TestAddUser(t *testing.T) {
 	ui := uinfo.NewUserOps()
 	userName, err := ui.AddUser("/media/common/workspace/go-utils/userinfo/test/usr.json")
@@ -67,3 +91,605 @@ func TestDeleteUser(t *testing.T) {
 		t.Logf("DeleteUser() PASSED, expected: %v got: %v", testUser, userName)
 	}
 }
+
+func TestDeleteUsersConfirm(t *testing.T) {
+	ui := uinfo.NewUserOps()
+	names := []string{testUser, "nobody"}
+
+	if uinfo.DeleteToken(names) != uinfo.DeleteToken([]string{"nobody", testUser}) {
+		t.Errorf("DeleteToken() FAILED, token depends on order")
+	}
+
+	if _, err := ui.DeleteUsers(names, uinfo.DeleteOptions{Confirm: "invalid"}); err == nil {
+		t.Errorf("DeleteUsers() FAILED, batch accepted with invalid token")
+	} else {
+		t.Logf("DeleteUsers() PASSED, %v", err.Error())
+	}
+}
+
+func TestParseGecos(t *testing.T) {
+	g := uinfo.ParseGecos("Jane Doe,B-7,555-1,,on call, weekends")
+	if g.FullName != "Jane Doe" || g.Room != "B-7" || g.WorkPhone != "555-1" ||
+		g.HomePhone != "" || g.Other != "on call, weekends" {
+		t.Errorf("ParseGecos() FAILED, got %+v", *g)
+	}
+
+	if s := uinfo.ParseGecos("Jane Doe,,,").String(); s != "Jane Doe" {
+		t.Errorf("Gecos.String() FAILED, got %q", s)
+	}
+	t.Logf("ParseGecos() PASSED")
+}
+
+func TestRedacted(t *testing.T) {
+	u := uinfo.Userinfo{Username: testUser, UserPasswd: "secret"}
+
+	out, err := uinfo.Decode(u)
+	if err != nil || strings.Contains(out, "secret") || strings.Contains(u.String(), "secret") {
+		t.Errorf("Decode() FAILED, password marshaled: %s", out)
+	}
+	if u.Redacted().UserPasswd != "" || u.UserPasswd != "secret" {
+		t.Errorf("Redacted() FAILED, got %q", u.Redacted().UserPasswd)
+	}
+
+	fixture, err := u.Fixture()
+	if err != nil || !strings.Contains(fixture, "secret") {
+		t.Errorf("Fixture() FAILED, password missing: %s", fixture)
+	}
+	t.Logf("Redacted() PASSED")
+}
+
+func TestRunner(t *testing.T) {
+	schema, err := ioutil.TempFile("", "usr.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(schema.Name())
+	schema.WriteString(`{"userName": "root", "name": "Runner Test"}`)
+	schema.Close()
+
+	rec := uinfo.NewRecorder(nil)
+	ui := uinfo.NewUserOpsWithRunner(rec)
+
+	p, err := ui.Modify(schema.Name())
+	if err != nil || !p.Applied {
+		t.Fatalf("Modify() FAILED, %v", err)
+	}
+
+	cmds := rec.Commands()
+	if len(cmds) != 1 || cmds[0].Name != "usermod" || cmds[0].Args[0] != "-c" ||
+		cmds[0].Args[len(cmds[0].Args)-1] != "root" {
+		t.Errorf("Runner() FAILED, recorded %+v", cmds)
+	}
+	t.Logf("Runner() PASSED")
+}
+
+// Error of command exited with code
+type exitError int
+
+func (e exitError) Error() string { return "exit status " + strconv.Itoa(int(e)) }
+func (e exitError) ExitCode() int { return int(e) }
+
+// Runner failing every command with exit code
+type failingRunner struct {
+	code int
+}
+
+func (r failingRunner) Run(ctx context.Context, input, name string, args ...string) ([]byte, error) {
+	return nil, exitError(r.code)
+}
+
+func TestTransientExits(t *testing.T) {
+	schema, err := ioutil.TempFile("", "usr.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(schema.Name())
+	schema.WriteString(`{"userName": "root", "name": "Retry Test"}`)
+	schema.Close()
+
+	for code, want := range map[int]int{1: 3, 10: 3, 6: 1} {
+		rec := uinfo.NewRecorder(failingRunner{code})
+		ui := uinfo.NewUserOpsWithRunner(rec)
+		if _, err := ui.Modify(schema.Name()); err == nil || len(rec.Commands()) != want {
+			t.Errorf("TransientExits() FAILED, usermod exit %d ran %d times, %v", code, len(rec.Commands()), err)
+		}
+	}
+
+	// Exit 10 of gpasswd is not transient
+	rec := uinfo.NewRecorder(failingRunner{10})
+	if err := uinfo.NewUserOpsWithRunner(rec).SetGroupAdmins("root", []string{"a"}); err == nil || len(rec.Commands()) != 1 {
+		t.Errorf("TransientExits() FAILED, gpasswd exit 10 ran %d times, %v", len(rec.Commands()), err)
+	}
+	t.Logf("TransientExits() PASSED")
+}
+
+func TestLegacyGroupName(t *testing.T) {
+	legacy := writeSchema(t, `{"userName": "root", "groupName": "bin"}`)
+	defer os.Remove(legacy)
+	conflict := writeSchema(t, `{"userName": "root", "groupName": "bin", "primaryGroup": "daemon"}`)
+	defer os.Remove(conflict)
+
+	ui := uinfo.NewUserOpsWithRunner(uinfo.NewRecorder(nil))
+	p, err := ui.PlanChanges(legacy)
+	if err != nil || len(p.Actions) != 1 || p.Actions[0].Field != "primaryGroup" || p.Actions[0].After != "bin" {
+		t.Errorf("PlanChanges() FAILED, groupName planned %+v, %v", p, err)
+	}
+	if _, err := ui.PlanChanges(conflict); err == nil {
+		t.Errorf("PlanChanges() FAILED, differing groupName and primaryGroup accepted")
+	}
+	t.Logf("LegacyGroupName() PASSED")
+}
+
+// Writes schema to temp file, returns its path
+func writeSchema(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "usr.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(content)
+	f.Close()
+
+	return f.Name()
+}
+
+// Returns recorded commands as name and args
+func recorded(rec *uinfo.Recorder) []string {
+	var cmds []string
+	for _, c := range rec.Commands() {
+		cmds = append(cmds, strings.Join(append([]string{c.Name}, c.Args...), " "))
+	}
+
+	return cmds
+}
+
+// Returns planned fields
+func plannedFields(p *uinfo.Plan) string {
+	var fields []string
+	for _, a := range p.Actions {
+		fields = append(fields, a.Field)
+	}
+
+	return strings.Join(fields, ",")
+}
+
+func TestPlanCreate(t *testing.T) {
+	schema := writeSchema(t, `{"userName": "plan-test", "name": "Plan Test", "homeDir": "/home/plan-test",
+		"shell": "/bin/sh", "supplementaryGroups": ["daemon", "bin"], "userPasswd": "secret"}`)
+	defer os.Remove(schema)
+
+	rec := uinfo.NewRecorder(nil)
+	ui := uinfo.NewUserOpsWithRunner(rec)
+
+	p, err := ui.PlanChanges(schema)
+	if err != nil || p.Operation != "create" || p.Applied || len(rec.Commands()) != 0 ||
+		plannedFields(p) != "gecos,homeDir,shell,supplementaryGroups" {
+		t.Fatalf("PlanChanges() FAILED, planned %+v, recorded %v, %v", p, recorded(rec), err)
+	}
+
+	if _, err := ui.Modify(schema); err == nil || len(rec.Commands()) != 0 {
+		t.Errorf("Modify() FAILED, missing user modified, recorded %v", recorded(rec))
+	}
+
+	p, err = ui.Apply(schema)
+	cmds := recorded(rec)
+	if err != nil || !p.Applied || len(cmds) != 2 ||
+		cmds[0] != "useradd -m -d /home/plan-test -s /bin/sh -c Plan Test -G daemon,bin plan-test" || cmds[1] != "chpasswd" {
+		t.Errorf("Apply() FAILED, applied %+v, recorded %q, %v", p, cmds, err)
+	}
+	t.Logf("PlanCreate() PASSED")
+}
+
+func TestPlanNone(t *testing.T) {
+	current, err := uinfo.NewUserOps().Get("root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := writeSchema(t, `{"userName": "root", "homeDir": "`+current.HomeDir+`", "shell": "`+current.Shell+
+		`", "primaryGroup": "`+current.PrimaryGroup+`"}`)
+	defer os.Remove(schema)
+
+	rec := uinfo.NewRecorder(nil)
+	ui := uinfo.NewUserOpsWithRunner(rec)
+	for name, run := range map[string]func(string) (*uinfo.Plan, error){
+		"PlanChanges": ui.PlanChanges,
+		"Apply":       ui.Apply,
+		"Modify":      ui.Modify,
+	} {
+		p, err := run(schema)
+		if err != nil || p.Operation != "none" || len(p.Actions) != 0 || p.Applied != (name != "PlanChanges") {
+			t.Errorf("%s() FAILED, no-op planned %+v, %v", name, p, err)
+		}
+	}
+	if cmds := recorded(rec); len(cmds) != 0 {
+		t.Errorf("PlanNone() FAILED, no-op recorded %q", cmds)
+	}
+	t.Logf("PlanNone() PASSED")
+}
+
+func TestModifyFlags(t *testing.T) {
+	for schema, want := range map[string]string{
+		`"name": "Plan Test"`:                       "usermod -c Plan Test root",
+		`"homeDir": "/srv/root"`:                    "usermod -d /srv/root -m root",
+		`"shell": "/bin/sh"`:                        "usermod -s /bin/sh root",
+		`"primaryGroup": "bin"`:                     "usermod -g bin root",
+		`"supplementaryGroups": ["daemon", "bin"]`:  "usermod -G bin,daemon root",
+		`"shell": "/bin/sh", "primaryGroup": "bin"`: "usermod -s /bin/sh -g bin root",
+	} {
+		file := writeSchema(t, `{"userName": "root", `+schema+`}`)
+		defer os.Remove(file)
+
+		rec := uinfo.NewRecorder(nil)
+		p, err := uinfo.NewUserOpsWithRunner(rec).Modify(file)
+		if cmds := recorded(rec); err != nil || p.Operation != "modify" || !p.Applied || len(cmds) != 1 || cmds[0] != want {
+			t.Errorf("Modify() FAILED, schema %s recorded %q, %v", schema, cmds, err)
+		}
+	}
+
+	// Name is changed with gecos comment only
+	file := writeSchema(t, `{"userName": "root", "name": "Plan Test"}`)
+	defer os.Remove(file)
+	if p, err := uinfo.NewUserOpsWithRunner(uinfo.NewRecorder(nil)).PlanChanges(file); err != nil || plannedFields(p) != "gecos" {
+		t.Errorf("PlanChanges() FAILED, name planned %+v, %v", p, err)
+	}
+
+	// Invalid shell is refused before usermod
+	file = writeSchema(t, `{"userName": "root", "shell": "/bin/no-such-shell"}`)
+	defer os.Remove(file)
+	rec := uinfo.NewRecorder(nil)
+	if _, err := uinfo.NewUserOpsWithRunner(rec).Apply(file); err == nil || len(rec.Commands()) != 0 {
+		t.Errorf("Apply() FAILED, invalid shell recorded %q, %v", recorded(rec), err)
+	}
+	t.Logf("ModifyFlags() PASSED")
+}
+
+func TestRemoteLookup(t *testing.T) {
+	local, err := uinfo.NewUserOps().Get("root")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote, err := uinfo.NewRemoteUserOps(uinfo.NewExecRunner()).Get("root")
+	if err != nil {
+		t.Fatalf("Get() FAILED, %v", err)
+	}
+	if remote.Username != local.Username || remote.Uid != local.Uid ||
+		remote.PrimaryGroup != local.PrimaryGroup || remote.HomeDir != local.HomeDir {
+		t.Errorf("Get() FAILED, remote %+v, local %+v", remote, local)
+	}
+	t.Logf("RemoteLookup() PASSED")
+}
+
+func TestResolver(t *testing.T) {
+	defer uinfo.SetResolver(uinfo.ResolverAuto)
+
+	if err := uinfo.SetResolver("ldap"); err == nil {
+		t.Errorf("SetResolver() FAILED, ldap accepted")
+	}
+
+	local, err := uinfo.NewUserOps().Get("root")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range []string{uinfo.ResolverFiles, uinfo.ResolverNSS} {
+		if err := uinfo.SetResolver(r); err != nil {
+			t.Fatal(err)
+		}
+		u, err := uinfo.NewUserOps().GetByUid("0")
+		if err != nil || u.Username != local.Username || u.PrimaryGroup != local.PrimaryGroup ||
+			u.HomeDir != local.HomeDir {
+			t.Errorf("Resolver() FAILED, %s resolved %+v, %v", r, u, err)
+		}
+	}
+	t.Logf("Resolver() PASSED")
+}
+
+func TestGroups(t *testing.T) {
+	g, err := uinfo.NewUserOps().GetGroup("root")
+	if err != nil || g.Gid != "0" {
+		t.Fatalf("GetGroup() FAILED, %+v, %v", g, err)
+	}
+
+	rec := uinfo.NewRecorder(nil)
+	if err := uinfo.NewUserOpsWithRunner(rec).SetGroupAdmins("root", []string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	cmds := rec.Commands()
+	if len(cmds) != 1 || cmds[0].Name != "gpasswd" || strings.Join(cmds[0].Args, " ") != "-A a,b root" {
+		t.Errorf("SetGroupAdmins() FAILED, recorded %+v", cmds)
+	}
+	t.Logf("Groups() PASSED")
+}
+
+func TestSyncGroupMembers(t *testing.T) {
+	g, err := uinfo.NewUserOps().GetGroup("root")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := uinfo.NewRecorder(nil)
+	desired := append([]string{"b", "a", "a"}, g.Members...)
+	changes, err := uinfo.NewUserOpsWithRunner(rec).SyncGroupMembers("root", desired, true)
+	if err != nil || strings.Join(changes.Added, ",") != "a,b" || len(changes.Removed) != 0 {
+		t.Fatalf("SyncGroupMembers() FAILED, %+v, %v", changes, err)
+	}
+	cmds := rec.Commands()
+	if len(cmds) != 2 || strings.Join(cmds[0].Args, " ") != "-a a root" ||
+		strings.Join(cmds[1].Args, " ") != "-a b root" {
+		t.Errorf("SyncGroupMembers() FAILED, recorded %+v", cmds)
+	}
+
+	if _, err := uinfo.NewUserOps().SyncGroupMembers("no-such-group", nil, true); err == nil {
+		t.Errorf("SyncGroupMembers() FAILED, missing group synced")
+	}
+	t.Logf("SyncGroupMembers() PASSED")
+}
+
+func TestShells(t *testing.T) {
+	shells, err := uinfo.NewUserOps().ListValidShells()
+	if err != nil || len(shells) == 0 {
+		t.Fatalf("ListValidShells() FAILED, %v, %v", shells, err)
+	}
+
+	schema, err := ioutil.TempFile("", "usr.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(schema.Name())
+	schema.WriteString(`{"userName": "root", "shell": "/bin/nosuchshell"}`)
+	schema.Close()
+
+	rec := uinfo.NewRecorder(nil)
+	if _, err := uinfo.NewUserOpsWithRunner(rec).Modify(schema.Name()); err == nil || len(rec.Commands()) != 0 {
+		t.Errorf("Modify() FAILED, invalid shell accepted")
+	}
+	t.Logf("Shells() PASSED")
+}
+
+func TestPrivilegedUsers(t *testing.T) {
+	users, err := uinfo.NewUserOps().PrivilegedUsers()
+	if err != nil {
+		t.Fatalf("PrivilegedUsers() FAILED, %v", err)
+	}
+
+	for _, p := range users {
+		if p.Username == "root" && p.Uid == "0" && p.Reasons[0] == uinfo.ReasonRoot {
+			t.Logf("PrivilegedUsers() PASSED")
+			return
+		}
+	}
+	t.Errorf("PrivilegedUsers() FAILED, root missing in %+v", users)
+}
+
+func TestMoveHome(t *testing.T) {
+	rec := uinfo.NewRecorder(nil)
+	ui := uinfo.NewUserOpsWithRunner(rec)
+
+	for _, path := range []string{"relative/root", "/root/sub", "/"} {
+		if err := ui.MoveHome("root", path); err == nil {
+			t.Errorf("MoveHome() FAILED, %s accepted", path)
+		}
+	}
+
+	// Homes of system accounts, removed after copy, are refused
+	refused := map[string]string{
+		"root":            "is not below",
+		"bin":             "is shared with",
+		"daemon":          "is not owned by",
+		"systemd-network": "can't be moved",
+	}
+	for name, reason := range refused {
+		if _, err := ui.Get(name); err != nil {
+			continue
+		}
+		err := ui.MoveHome(name, "/tmp/moved-home-"+name)
+		if err == nil || !strings.Contains(err.Error(), reason) {
+			t.Errorf("MoveHome() FAILED, home of %s: %v", name, err)
+		}
+	}
+	if err := uinfo.SetHomeBases([]string{"/"}); err == nil {
+		t.Errorf("SetHomeBases() FAILED, / accepted")
+	}
+	if len(rec.Commands()) != 0 {
+		t.Errorf("MoveHome() FAILED, recorded %+v", rec.Commands())
+	}
+	t.Logf("MoveHome() PASSED")
+}
+
+func TestRotateSSHKey(t *testing.T) {
+	if _, err := uinfo.NewUserOps().RotateSSHKey("nosuchuser", uinfo.RotateOptions{}); err == nil {
+		t.Errorf("RotateSSHKey() FAILED, unknown user accepted")
+	}
+
+	rec := uinfo.NewRecorder(uinfo.NewExecRunner())
+	if _, err := uinfo.NewRemoteUserOps(rec).RotateSSHKey("root", uinfo.RotateOptions{}); err == nil {
+		t.Errorf("RotateSSHKey() FAILED, remote host accepted")
+	}
+	t.Logf("RotateSSHKey() PASSED")
+}
+
+func TestCanAccess(t *testing.T) {
+	if _, err := uinfo.ParseAccessMode("rq"); err == nil {
+		t.Errorf("ParseAccessMode() FAILED, rq accepted")
+	}
+
+	ui := uinfo.NewUserOps()
+	read, err := ui.CanAccess("nobody", testSysDB, uinfo.AccessRead)
+	if err != nil || !read.Allowed || read.Reason != "other" {
+		t.Errorf("CanAccess() FAILED, read %+v, %v", read, err)
+	}
+	write, err := ui.CanAccess("nobody", testSysDB, uinfo.AccessRead|uinfo.AccessWrite)
+	if err != nil || write.Allowed || write.Mode != "rw" {
+		t.Errorf("CanAccess() FAILED, write %+v, %v", write, err)
+	}
+	t.Logf("CanAccess() PASSED")
+}
+
+func TestUidCollisions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collisions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	owner, err := uinfo.NewUserOps().GetByUid(strconv.Itoa(os.Getuid()))
+	if err != nil {
+		t.Skip("UidCollisions() SKIPPED, uid not in passwd")
+	}
+
+	scan := func(name string) []uinfo.UidCollision {
+		passwd := filepath.Join(dir, "passwd")
+		entry := name + ":x:" + owner.Uid + ":" + owner.Gid + "::/:/bin/sh\n"
+		if err := ioutil.WriteFile(passwd, []byte(entry), 0644); err != nil {
+			t.Fatal(err)
+		}
+		list, err := uinfo.NewUserOps().UidCollisions(nil, uinfo.CollisionOptions{
+			Paths:        []string{file},
+			ExportPasswd: passwd,
+		})
+		if err != nil {
+			t.Fatalf("UidCollisions() FAILED: %v", err)
+		}
+		return list
+	}
+
+	if list := scan(owner.Username); len(list) != 0 {
+		t.Errorf("UidCollisions() FAILED, same user reported %+v", list)
+	}
+	list := scan("other")
+	if len(list) != 1 || list[0].LocalUser != owner.Username || list[0].ExportUser != "other" || list[0].Paths[0] != file {
+		t.Errorf("UidCollisions() FAILED, got %+v", list)
+	}
+	t.Logf("UidCollisions() PASSED")
+}
+
+func TestUidCollisionsUnreadable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collisions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	owner, err := uinfo.NewUserOps().GetByUid(strconv.Itoa(os.Getuid()))
+	if err != nil {
+		t.Skip("UidCollisions() SKIPPED, uid not in passwd")
+	}
+	passwd := filepath.Join(dir, "passwd")
+	entry := "other:x:" + owner.Uid + ":" + owner.Gid + "::/:/bin/sh\n"
+	if err := ioutil.WriteFile(passwd, []byte(entry), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Private dir is not readable by user, deep tree is longer
+	// than paths accepted by kernel, as for root too
+	private := filepath.Join(dir, "private")
+	os.MkdirAll(filepath.Join(private, "sub"), 0755)
+	os.Chmod(private, 0)
+	defer os.Chmod(private, 0755)
+
+	cwd, _ := os.Getwd()
+	os.Chdir(dir)
+	for i := 0; i < 24; i++ {
+		name := strings.Repeat("d", 200)
+		if err := os.Mkdir(name, 0755); err != nil {
+			os.Chdir(cwd)
+			t.Fatal(err)
+		}
+		os.Chdir(name)
+	}
+	os.Chdir(cwd)
+
+	list, err := uinfo.NewUserOps().UidCollisions(nil, uinfo.CollisionOptions{
+		Paths:        []string{dir},
+		ExportPasswd: passwd,
+		MaxPaths:     2,
+	})
+	if err != nil || len(list) != 1 || list[0].ExportUser != "other" || list[0].Files < 20 || list[0].Paths[0] != dir {
+		t.Errorf("UidCollisions() FAILED, unreadable paths reported %+v, %v", list, err)
+	}
+	t.Logf("UidCollisionsUnreadable() PASSED")
+}
+
+func TestScheduleDeletion(t *testing.T) {
+	rec := uinfo.NewRecorder(nil)
+	ui := uinfo.NewUserOpsWithRunner(rec)
+
+	p, err := ui.ScheduleDeletion("nobody", 24*time.Hour)
+	if err != nil {
+		t.Fatalf("ScheduleDeletion() FAILED: %v", err)
+	}
+	defer ui.CancelDeletion("nobody")
+	if p.DeleteAfter.Sub(p.Locked) != 24*time.Hour {
+		t.Errorf("ScheduleDeletion() FAILED, got %+v", p)
+	}
+	if _, err := ui.ScheduleDeletion("nobody", time.Hour); err == nil {
+		t.Errorf("ScheduleDeletion() FAILED, scheduled twice")
+	}
+
+	pending, err := ui.ListPendingDeletions()
+	if err != nil || len(pending) == 0 || pending[len(pending)-1].Username != "nobody" {
+		t.Errorf("ListPendingDeletions() FAILED, got %+v, %v", pending, err)
+	}
+	if _, err := os.Stat("/var/lib/userinfo/pending-deletions.json"); err == nil {
+		t.Errorf("ScheduleDeletion() FAILED, state written out of state directory")
+	}
+
+	// Not due, nothing deleted
+	if results, err := ui.PurgeDeletions(); err != nil || len(results) != 0 {
+		t.Errorf("PurgeDeletions() FAILED, got %+v, %v", results, err)
+	}
+
+	if err := ui.CancelDeletion("nobody"); err != nil {
+		t.Errorf("CancelDeletion() FAILED: %v", err)
+	}
+	if err := ui.CancelDeletion("nobody"); err == nil {
+		t.Errorf("CancelDeletion() FAILED, cancelled twice")
+	}
+
+	cmds := rec.Commands()
+	if len(cmds) < 2 || strings.Join(cmds[0].Args, " ") != "-L -e 1 nobody" ||
+		cmds[len(cmds)-1].Args[0] != "-U" {
+		t.Errorf("ScheduleDeletion() FAILED, recorded %+v", cmds)
+	}
+	t.Logf("ScheduleDeletion(), CancelDeletion() PASSED")
+}
+
+func TestStateDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "userinfo-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := uinfo.SetStateDir("state"); err == nil {
+		t.Errorf("SetStateDir() FAILED, relative directory accepted")
+	}
+	if err := uinfo.SetStateDir(filepath.Join(dir, "userinfo")); err != nil {
+		t.Fatal(err)
+	}
+	defer uinfo.SetStateDir(testStateDir)
+
+	ui := uinfo.NewUserOpsWithRunner(uinfo.NewRecorder(nil))
+	for _, name := range []string{"nobody", "daemon"} {
+		if _, err := ui.ScheduleDeletion(name, time.Hour); err != nil {
+			t.Fatalf("ScheduleDeletion() FAILED: %v", err)
+		}
+	}
+
+	// Temporary files are renamed, only state is left
+	files, _ := filepath.Glob(filepath.Join(dir, "userinfo", "*"))
+	hidden, _ := filepath.Glob(filepath.Join(dir, "userinfo", ".*"))
+	if len(files) != 1 || filepath.Base(files[0]) != "pending-deletions.json" || len(hidden) != 0 {
+		t.Errorf("SetStateDir() FAILED, state files %v %v", files, hidden)
+	}
+
+	pending, err := ui.ListPendingDeletions()
+	if err != nil || len(pending) != 2 {
+		t.Errorf("ListPendingDeletions() FAILED, got %+v, %v", pending, err)
+	}
+	t.Logf("StateDir() PASSED")
+}
@@ -0,0 +1,314 @@
+package users
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Modes of access, as bits of permissions
+const (
+	AccessRead  uint32 = 4
+	AccessWrite uint32 = 2
+	AccessExec  uint32 = 1
+)
+
+// Tags of POSIX ACL entries in system.posix_acl_access
+const (
+	aclUserObj  uint16 = 0x01
+	aclUser     uint16 = 0x02
+	aclGroupObj uint16 = 0x04
+	aclGroup    uint16 = 0x08
+	aclMask     uint16 = 0x10
+	aclOther    uint16 = 0x20
+
+	aclXattr   string = "system.posix_acl_access"
+	aclVersion uint32 = 2
+	stRdonly   int64  = 1 // Flag of read-only mount in statfs
+)
+
+// Access is the answer of CanAccess, with entry deciding it
+type Access struct {
+	Username string `json:"userName"`
+	Path     string `json:"path"`
+	Mode     string `json:"mode"`
+	Allowed  bool   `json:"allowed"`
+
+	// Reason is the entry granting or denying access, as owner, group:staff,
+	// acl-user:alice, acl-group:dev or other.
+	Reason string `json:"reason"`
+
+	// DeniedAt is the parent directory denying search, blank otherwise.
+	DeniedAt string `json:"deniedAt,omitempty"`
+}
+
+// Credentials of user checked against paths
+type accessUser struct {
+	name string
+	uid  uint32
+	gids map[uint32]bool
+}
+
+// Entry of POSIX ACL
+type aclEntry struct {
+	tag  uint16
+	perm uint32
+	id   uint32
+}
+
+// ParseAccessMode parses mode of r, w and x letters, as rw
+func ParseAccessMode(s string) (uint32, error) {
+	var mode uint32
+	for _, c := range s {
+		switch c {
+		case 'r':
+			mode |= AccessRead
+		case 'w':
+			mode |= AccessWrite
+		case 'x':
+			mode |= AccessExec
+		default:
+			return 0, errors.New("Access mode " + s + " not supported, use r, w and x.")
+		}
+	}
+	if mode == 0 {
+		return 0, errors.New("Access mode is blank, use r, w and x.")
+	}
+
+	return mode, nil
+}
+
+// CanAccess returns whether user can access path with mode, evaluated with
+// uid and groups of user against owner, mode bits and POSIX ACLs of path,
+// and search permission of its parent directories. Symlinks are resolved.
+// Capabilities other than of root and LSMs as SELinux are not evaluated.
+func (u *Userinfo) CanAccess(userName, path string, mode uint32) (*Access, error) {
+	if u.remote {
+		return nil, errors.New("Access of user on remote host not supported.")
+	}
+
+	current, err := u.Get(userName)
+	if err != nil {
+		return nil, err
+	}
+	au, err := u.credentials(current)
+	if err != nil {
+		return nil, err
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	real, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	access := &Access{Username: userName, Path: real, Mode: modeString(mode)}
+
+	// Every directory up to path is searched
+	for dir := filepath.Dir(real); ; dir = filepath.Dir(dir) {
+		if ok, reason, err := u.permitted(dir, au, AccessExec); err != nil {
+			return nil, err
+		} else if !ok {
+			access.Reason, access.DeniedAt = reason, dir
+			return access, nil
+		}
+		if dir == "/" {
+			break
+		}
+	}
+
+	if mode&AccessWrite != 0 && readOnly(real) {
+		access.Reason = "read-only filesystem"
+		return access, nil
+	}
+
+	access.Allowed, access.Reason, err = u.permitted(real, au, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return access, nil
+}
+
+// Returns credentials of user with gids of primary and supplementary groups
+func (u *Userinfo) credentials(ui *Userinfo) (*accessUser, error) {
+	uid, err := strconv.ParseUint(ui.Uid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	gid, err := strconv.ParseUint(ui.Gid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	au := &accessUser{name: ui.Username, uid: uint32(uid), gids: map[uint32]bool{uint32(gid): true}}
+	for _, name := range ui.SupplementaryGroups {
+		g, err := u.lookupGroup(name)
+		if err != nil {
+			return nil, err
+		}
+		id, err := strconv.ParseUint(g, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		au.gids[uint32(id)] = true
+	}
+
+	return au, nil
+}
+
+// Returns whether mode is permitted on path with entry deciding it, checked
+// in order of kernel: owner, named users, groups and other. Root may read
+// and write anything, and execute when any execute bit is set.
+func (u *Userinfo) permitted(path string, au *accessUser, mode uint32) (bool, string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, "", err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false, "", errors.New("Owner of " + path + " unknown.")
+	}
+	perm := uint32(info.Mode().Perm())
+
+	if au.uid == 0 {
+		if mode&AccessExec == 0 || info.IsDir() || perm&0111 != 0 {
+			return true, "root", nil
+		}
+		return false, "root without execute bits", nil
+	}
+
+	acl, err := readACL(path)
+	if err != nil {
+		return false, "", err
+	}
+
+	if au.uid == st.Uid {
+		return perm>>6&mode == mode, "owner", nil
+	}
+
+	// Group class bits are the mask when file has ACL
+	mask := perm >> 3 & 7
+	for _, e := range acl {
+		if e.tag == aclMask {
+			mask = e.perm
+		}
+	}
+	for _, e := range acl {
+		if e.tag == aclUser && e.id == au.uid {
+			return e.perm&mask&mode == mode, "acl-user:" + au.name, nil
+		}
+	}
+
+	// Any matching group granting mode allows, matching groups
+	// not granting it deny without falling back to other.
+	matched := ""
+	groups := []aclEntry{{tag: aclGroupObj, perm: perm >> 3 & 7, id: st.Gid}}
+	for _, e := range acl {
+		switch e.tag {
+		case aclGroupObj:
+			groups[0].perm = e.perm
+		case aclGroup:
+			groups = append(groups, e)
+		}
+	}
+	for _, e := range groups {
+		if !au.gids[e.id] {
+			continue
+		}
+		reason := "group:" + u.groupLabel(e.id)
+		if e.tag == aclGroup {
+			reason = "acl-" + reason
+		}
+		if len(acl) > 0 {
+			e.perm &= mask
+		}
+		if e.perm&mode == mode {
+			return true, reason, nil
+		}
+		if matched == "" {
+			matched = reason
+		}
+	}
+	if matched != "" {
+		return false, matched, nil
+	}
+
+	return perm&mode == mode, "other", nil
+}
+
+// Returns name of group, gid when not found
+func (u *Userinfo) groupLabel(gid uint32) string {
+	id := strconv.FormatUint(uint64(gid), 10)
+	if name, err := u.lookupGroupId(id); err == nil {
+		return name
+	}
+
+	return id
+}
+
+// Returns entries of access ACL of path, none without ACL
+func readACL(path string) ([]aclEntry, error) {
+	buf := make([]byte, 1024)
+	n, err := syscall.Getxattr(path, aclXattr, buf)
+	if err == syscall.ENODATA || err == syscall.ENOTSUP {
+		return nil, nil
+	}
+	if err == syscall.ERANGE {
+		if n, err = syscall.Getxattr(path, aclXattr, nil); err == nil {
+			buf = make([]byte, n)
+			n, err = syscall.Getxattr(path, aclXattr, buf)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := buf[:n]
+	if len(data) < 4 || binary.LittleEndian.Uint32(data) != aclVersion || (len(data)-4)%8 != 0 {
+		return nil, errors.New("ACL of " + path + " is malformed.")
+	}
+
+	var entries []aclEntry
+	for i := 4; i < len(data); i += 8 {
+		entries = append(entries, aclEntry{
+			tag:  binary.LittleEndian.Uint16(data[i:]),
+			perm: uint32(binary.LittleEndian.Uint16(data[i+2:])),
+			id:   binary.LittleEndian.Uint32(data[i+4:]),
+		})
+	}
+
+	return entries, nil
+}
+
+// Returns true if path is on read-only mount
+func readOnly(path string) bool {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(path, &fs); err != nil {
+		return false
+	}
+
+	return int64(fs.Flags)&stRdonly != 0
+}
+
+// Returns mode as r, w and x letters
+func modeString(mode uint32) string {
+	var sb strings.Builder
+	for _, m := range []struct {
+		bit    uint32
+		letter string
+	}{{AccessRead, "r"}, {AccessWrite, "w"}, {AccessExec, "x"}} {
+		if mode&m.bit != 0 {
+			sb.WriteString(m.letter)
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,173 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+	"syscall"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/pool"
+	"github.com/prashant-sb/go-utils/walker"
+)
+
+// Sample paths reported of each uid by default
+const collisionPaths int = 10
+
+// CollisionOptions are options of scanning uid collisions
+type CollisionOptions struct {
+	// Paths are the directories walked, as NFS mounts of exporting host.
+	Paths []string `json:"paths"`
+
+	// ExportPasswd is passwd file of exporting host, for exporter
+	// without ops / optional
+	ExportPasswd string `json:"exportPasswd,omitempty"`
+
+	// Exclude lists glob patterns of skipped paths / optional
+	Exclude []string `json:"exclude,omitempty"`
+
+	// MaxPaths is the number of sample paths of each uid,
+	// 10 will be default / optional
+	MaxPaths int `json:"maxPaths,omitempty"`
+}
+
+// UidCollision is the uid owning files which maps to other user locally
+// than on exporting host. Blank user is uid unknown on that host.
+type UidCollision struct {
+	Uid        string   `json:"uid"`
+	LocalUser  string   `json:"localUser"`
+	ExportUser string   `json:"exportUser"`
+	Files      int      `json:"files"`
+	Paths      []string `json:"paths"`
+}
+
+// Owned files of uid found while walking
+type ownedFiles struct {
+	files int
+	paths []string
+}
+
+// File with numeric owner
+type fileOwner struct {
+	path string
+	uid  uint32
+}
+
+// UidCollisions walks paths and returns uids of file owners mapping to
+// other user locally than on exporter, ops of exporting host, or users
+// of ExportPasswd when exporter is nil. Files of such uids are accessed
+// locally by user other than who owns them on exporter.
+func (u *Userinfo) UidCollisions(exporter UserOps, opts CollisionOptions) ([]UidCollision, error) {
+	if u.remote {
+		return nil, errors.New("Scanning uid collisions of remote host not supported.")
+	}
+	if exporter == nil && opts.ExportPasswd == "" {
+		return nil, errors.New("Users of exporting host needed, as ops or passwd file.")
+	}
+	if opts.MaxPaths <= 0 {
+		opts.MaxPaths = collisionPaths
+	}
+
+	owners := make(map[uint32]*ownedFiles)
+	unreadable := 0
+	onResult := func(res pool.Result) {
+		if res.Err != nil {
+			unreadable++
+			log.Debug("Error in walking: ", res.Err)
+			return
+		}
+		f, ok := res.Value.(fileOwner)
+		if !ok {
+			return
+		}
+		o, ok := owners[f.uid]
+		if !ok {
+			o = &ownedFiles{}
+			owners[f.uid] = o
+		}
+		o.files++
+		if len(o.paths) < opts.MaxPaths {
+			o.paths = append(o.paths, f.path)
+		}
+	}
+
+	for _, root := range opts.Paths {
+		if _, err := os.Stat(root); err != nil {
+			return nil, err
+		}
+		failed := unreadable
+		err := walker.Walk(context.Background(), root, walker.Options{
+			Dirs:     true,
+			Exclude:  opts.Exclude,
+			OnResult: onResult,
+		}, func(ctx context.Context, path string, info os.FileInfo) (interface{}, error) {
+			st, ok := info.Sys().(*syscall.Stat_t)
+			if !ok {
+				return nil, errors.New("Owner of " + path + " unknown.")
+			}
+			return fileOwner{path: path, uid: st.Uid}, nil
+		})
+		// Unreadable paths are counted, walk continues below them
+		if err != nil && unreadable == failed {
+			return nil, err
+		}
+	}
+	if unreadable > 0 {
+		log.Warn(unreadable, " paths not readable while scanning uid collisions")
+	}
+
+	collisions := []UidCollision{}
+	for uid, o := range owners {
+		id := strconv.FormatUint(uint64(uid), 10)
+
+		local := ""
+		if ui, err := u.lookupUid(id); err == nil {
+			local = ui.Username
+		}
+		exported, err := exportUser(exporter, opts.ExportPasswd, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if local != exported {
+			sort.Strings(o.paths)
+			collisions = append(collisions, UidCollision{
+				Uid:        id,
+				LocalUser:  local,
+				ExportUser: exported,
+				Files:      o.files,
+				Paths:      o.paths,
+			})
+		}
+	}
+
+	sort.Slice(collisions, func(i, j int) bool {
+		a, _ := strconv.ParseUint(collisions[i].Uid, 10, 32)
+		b, _ := strconv.ParseUint(collisions[j].Uid, 10, 32)
+		return a < b
+	})
+
+	return collisions, nil
+}
+
+// Returns name of user of uid on exporting host, blank when unknown
+func exportUser(exporter UserOps, passwd, uid string) (string, error) {
+	if exporter != nil {
+		if ui, err := exporter.GetByUid(uid); err == nil {
+			return ui.Username, nil
+		}
+		return "", nil
+	}
+
+	fields, err := fileEntry(passwd, uid)
+	if os.IsNotExist(err) || os.IsPermission(err) {
+		return "", err
+	}
+	if err != nil {
+		return "", nil
+	}
+
+	return fields[0], nil
+}
@@ -0,0 +1,124 @@
+package users
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/prashant-sb/go-utils/logging"
+)
+
+const (
+	minUserUid  int    = 1000  // First uid allocated to regular users
+	whoCmd      string = "who" // Command for listing logged-in users
+	tokenLength int    = 12    // Length of the delete confirmation token
+)
+
+// DeleteOptions guards the bulk deletion of users
+type DeleteOptions struct {
+	// Force allows deleting system accounts (uid < 1000).
+	Force bool `json:"force,omitempty"`
+
+	// Confirm must match DeleteToken() of the batch.
+	Confirm string `json:"confirm"`
+}
+
+// DeleteResult is the outcome of deleting a single user
+type DeleteResult struct {
+	// Username is the login name requested for deletion.
+	Username string `json:"userName"`
+
+	// Deleted is set when the user was removed from system.
+	Deleted bool `json:"deleted"`
+
+	// Error describes why the user was not deleted.
+	Error string `json:"error,omitempty"`
+}
+
+// DeleteToken returns the confirmation token for the batch of users.
+// Token is independent of the order of names.
+func DeleteToken(names []string) string {
+	sorted := make([]string, len(names))
+	copy(sorted, names)
+	sort.Strings(sorted)
+
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+	return hex.EncodeToString(sum[:])[:tokenLength]
+}
+
+// DeleteUsers deletes the batch of users, returns result for each user.
+// Batch is refused as a whole if confirmation token does not match.
+func (u *Userinfo) DeleteUsers(names []string, opts DeleteOptions) ([]DeleteResult, error) {
+
+	if opts.Confirm != DeleteToken(names) {
+		return nil, errors.New("Confirmation token mismatch, expected token for " +
+			strconv.Itoa(len(names)) + " users.")
+	}
+
+	loggedIn, err := u.loggedInUsers()
+	if err != nil {
+		log.Error("Error in listing logged-in users: ", err.Error())
+		return nil, err
+	}
+
+	results := []DeleteResult{}
+	for _, name := range names {
+		res := DeleteResult{Username: name}
+
+		if err := u.deleteGuarded(name, opts, loggedIn); err != nil {
+			res.Error = err.Error()
+		} else {
+			res.Deleted = true
+		}
+		results = append(results, res)
+	}
+
+	return results, nil
+}
+
+// deletes single user after checking the guards
+func (u *Userinfo) deleteGuarded(name string, opts DeleteOptions, loggedIn map[string]bool) error {
+
+	uinfo, err := u.Get(name)
+	if err != nil {
+		return errors.New("User " + name + " not found.")
+	}
+
+	uid, err := strconv.Atoi(uinfo.Uid)
+	if err != nil {
+		return err
+	}
+	if uid < minUserUid && !opts.Force {
+		return errors.New("User " + name + " is a system account, use force to delete.")
+	}
+
+	if loggedIn[name] {
+		return errors.New("User " + name + " is currently logged in.")
+	}
+
+	return u.delete(uinfo)
+}
+
+// Returns set of users having active login sessions
+func (u *Userinfo) loggedInUsers() (map[string]bool, error) {
+
+	users := make(map[string]bool)
+
+	out, err := u.cmdRunner().Run(context.Background(), "", whoCmd)
+	if err != nil {
+		return users, err
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			users[fields[0]] = true
+		}
+	}
+
+	return users, nil
+}
@@ -0,0 +1,141 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/retry"
+)
+
+// Exit codes of commands when passwd or group file is locked
+// or can not be updated, failures are transient. Codes of other
+// commands mean otherwise and are not retried.
+var transientExits = map[string]map[int]bool{
+	userAdd: {
+		1:  true, // can't update password file
+		10: true, // can't update group file
+	},
+	userMod: {
+		1:  true, // can't update password file
+		10: true, // can't update group file
+	},
+}
+
+// Retry of user and group commands
+var cmdRetry = retry.Options{
+	Attempts: 3,
+	Initial:  200 * time.Millisecond,
+	Jitter:   0.2,
+}
+
+// Runner executes user and group commands, input is passed on stdin.
+// Errors with ExitCode() are retried for transient exit codes.
+type Runner interface {
+	Run(ctx context.Context, input, name string, args ...string) ([]byte, error)
+}
+
+// Command is the recorded invocation. Input is not recorded,
+// it carries passwords.
+type Command struct {
+	Name string   `json:"name"`
+	Args []string `json:"args"`
+}
+
+// Recorder records commands before passing them to runner
+type Recorder struct {
+	runner   Runner
+	mu       sync.Mutex
+	commands []Command
+}
+
+type execRunner struct{}
+
+// NewExecRunner inits the runner executing commands on local host
+func NewExecRunner() Runner {
+	return execRunner{}
+}
+
+// Run executes command, returns its stdout
+func (execRunner) Run(ctx context.Context, input, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
+
+	return cmd.Output()
+}
+
+// NewRecorder inits recorder of commands passed to runner,
+// commands are only recorded when runner is nil.
+func NewRecorder(r Runner) *Recorder {
+	return &Recorder{runner: r}
+}
+
+// Run records command and runs it with wrapped runner
+func (r *Recorder) Run(ctx context.Context, input, name string, args ...string) ([]byte, error) {
+	r.mu.Lock()
+	r.commands = append(r.commands, Command{Name: name, Args: append([]string{}, args...)})
+	r.mu.Unlock()
+
+	if r.runner == nil {
+		return nil, nil
+	}
+
+	return r.runner.Run(ctx, input, name, args...)
+}
+
+// Commands returns recorded commands in order
+func (r *Recorder) Commands() []Command {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Command{}, r.commands...)
+}
+
+// Returns runner of user ops, local exec when not set
+func (u *Userinfo) cmdRunner() Runner {
+	if u.runner == nil {
+		return NewExecRunner()
+	}
+
+	return u.runner
+}
+
+// Runs command, retries while passwd or group files are busy
+func (u *Userinfo) runCmd(name string, args ...string) ([]byte, error) {
+	return u.runCmdInput("", name, args...)
+}
+
+// Runs command with input on stdin, secrets are passed as input
+// instead of arguments visible in process list.
+func (u *Userinfo) runCmdInput(input, name string, args ...string) ([]byte, error) {
+	var out []byte
+	runner := u.cmdRunner()
+	opts := cmdRetry
+	opts.Retryable = func(err error) bool { return transientExit(name, err) }
+
+	err := retry.Do(context.Background(), opts, func(ctx context.Context) error {
+		var err error
+		if out, err = runner.Run(ctx, input, name, args...); err != nil {
+			log.Debug("Command ", name, " failed: ", err)
+		}
+		return err
+	})
+
+	return out, err
+}
+
+// Reports if command name failed with transient exit code
+func transientExit(name string, err error) bool {
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return transientExits[name][exitErr.ExitCode()]
+	}
+
+	return false
+}
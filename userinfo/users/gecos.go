@@ -0,0 +1,113 @@
+package users
+
+import (
+	"errors"
+	"strings"
+)
+
+// Characters not allowed in GECOS sub-fields, as by chfn
+const gecosInvalid string = ",:="
+
+// Gecos is the comment field of passwd entry, comma separated
+// sub-fields as set by chfn.
+type Gecos struct {
+	// FullName is the first sub-field, same as Name of user.
+	FullName string `json:"fullName,omitempty"`
+
+	// Room is the building and room number.
+	Room string `json:"room,omitempty"`
+
+	WorkPhone string `json:"workPhone,omitempty"`
+	HomePhone string `json:"homePhone,omitempty"`
+
+	// Other is the rest of field, it may contain commas.
+	Other string `json:"other,omitempty"`
+}
+
+// ParseGecos splits comment field of passwd entry to sub-fields
+func ParseGecos(raw string) *Gecos {
+	fields := strings.SplitN(raw, ",", 5)
+	for len(fields) < 5 {
+		fields = append(fields, "")
+	}
+
+	return &Gecos{
+		FullName:  fields[0],
+		Room:      fields[1],
+		WorkPhone: fields[2],
+		HomePhone: fields[3],
+		Other:     fields[4],
+	}
+}
+
+// String joins sub-fields as in passwd, trailing blank sub-fields are dropped
+func (g *Gecos) String() string {
+	if g == nil {
+		return ""
+	}
+
+	fields := []string{g.FullName, g.Room, g.WorkPhone, g.HomePhone, g.Other}
+	for len(fields) > 1 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// Returns copy of g with sub-fields set in o
+func (g *Gecos) merge(o *Gecos) *Gecos {
+	m := &Gecos{}
+	if g != nil {
+		*m = *g
+	}
+	if o == nil {
+		return m
+	}
+
+	if o.FullName != "" {
+		m.FullName = o.FullName
+	}
+	if o.Room != "" {
+		m.Room = o.Room
+	}
+	if o.WorkPhone != "" {
+		m.WorkPhone = o.WorkPhone
+	}
+	if o.HomePhone != "" {
+		m.HomePhone = o.HomePhone
+	}
+	if o.Other != "" {
+		m.Other = o.Other
+	}
+
+	return m
+}
+
+// Returns error if sub-fields contain characters not allowed in passwd
+func (g *Gecos) validate() error {
+	if g == nil {
+		return nil
+	}
+
+	for _, f := range []string{g.FullName, g.Room, g.WorkPhone, g.HomePhone} {
+		if strings.ContainsAny(f, gecosInvalid) {
+			return errors.New("Gecos field " + f + " must not contain any of " + gecosInvalid)
+		}
+	}
+	if strings.ContainsAny(g.Other, ":=") {
+		return errors.New("Gecos field " + g.Other + " must not contain : or =")
+	}
+
+	return nil
+}
+
+// Returns comment field of schema applied on current sub-fields,
+// Name of schema is the full name.
+func (u *Userinfo) comment(current *Gecos) string {
+	g := current.merge(u.Gecos)
+	if u.Name != "" {
+		g.FullName = u.Name
+	}
+
+	return g.String()
+}
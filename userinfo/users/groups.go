@@ -0,0 +1,56 @@
+package users
+
+import (
+	"errors"
+	"os/user"
+
+	log "github.com/prashant-sb/go-utils/logging"
+)
+
+// Returns names of groups user is member of, other than primary group
+func supplementaryGroups(u *user.User) ([]string, error) {
+	var groups []string
+
+	gids, err := u.GroupIds()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, gid := range gids {
+		if gid == u.Gid {
+			continue
+		}
+		g, err := user.LookupGroupId(gid)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g.Name)
+	}
+
+	return groups, nil
+}
+
+// ensureGroup checks group exists, creates it if missing and create is set.
+// Group is created with gid when set.
+func (u *Userinfo) ensureGroup(groupName, gid string, create bool) error {
+
+	if _, err := u.lookupGroup(groupName); err == nil {
+		return nil
+	}
+
+	if !create {
+		return errors.New("Group " + groupName + " not found.")
+	}
+
+	args := []string{groupName}
+	if gid != "" {
+		args = []string{"-g", gid, groupName}
+	}
+
+	if _, err := u.runCmd(groupAdd, args...); err != nil {
+		log.Error("Error in adding group : ", groupName, " ", err.Error())
+		return err
+	}
+
+	return nil
+}
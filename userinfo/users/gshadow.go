@@ -0,0 +1,245 @@
+package users
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	log "github.com/prashant-sb/go-utils/logging"
+)
+
+const (
+	gshadowDB string = "/etc/gshadow" // Group passwords and administrators
+	gpasswd   string = "gpasswd"      // Command for administering groups
+)
+
+// Groupinfo is the group with members, administrators
+// and password state of gshadow
+type Groupinfo struct {
+	Name    string   `json:"name"`
+	Gid     string   `json:"gid"`
+	Members []string `json:"members"`
+
+	// Admins can add and remove members with gpasswd.
+	Admins []string `json:"admins,omitempty"`
+
+	// HasPassword is set when group password is set, users
+	// knowing it can join the group with newgrp.
+	HasPassword bool `json:"hasPassword"`
+
+	// Shadowed is set when group has gshadow entry, admins and
+	// password are unknown otherwise.
+	Shadowed bool `json:"shadowed"`
+}
+
+// GetGroup returns group with members, admins and password state
+func (u *Userinfo) GetGroup(groupName string) (*Groupinfo, error) {
+	groups, err := u.ListGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range groups {
+		if groups[i].Name == groupName {
+			return &groups[i], nil
+		}
+	}
+
+	return nil, errors.New("Group " + groupName + " not found.")
+}
+
+// ListGroups returns groups of group database with gshadow entries,
+// gshadow is readable by root only, groups are not shadowed otherwise.
+func (u *Userinfo) ListGroups() ([]Groupinfo, error) {
+	groupLines, err := u.readDB("group", groupDB)
+	if err != nil {
+		return nil, err
+	}
+	shadowLines, err := u.readDB("gshadow", gshadowDB)
+	switch {
+	case os.IsPermission(err):
+		log.Warn("Error in reading ", gshadowDB, ", admins and passwords of groups unknown: ", err)
+	case err != nil && !os.IsNotExist(err):
+		return nil, err
+	}
+
+	shadows := make(map[string][]string)
+	for _, fields := range shadowLines {
+		if len(fields) >= 4 {
+			shadows[fields[0]] = fields
+		}
+	}
+
+	groups := []Groupinfo{}
+	for _, fields := range groupLines {
+		if len(fields) < 4 {
+			continue
+		}
+
+		g := Groupinfo{Name: fields[0], Gid: fields[2], Members: splitList(fields[3])}
+		if s, ok := shadows[g.Name]; ok {
+			g.Shadowed = true
+			g.HasPassword = hasGroupPassword(s[1])
+			g.Admins = splitList(s[2])
+		}
+		groups = append(groups, g)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+
+	return groups, nil
+}
+
+// AddGroupMember adds user to members of group
+func (u *Userinfo) AddGroupMember(groupName, userName string) error {
+	_, err := u.runCmd(gpasswd, "-a", userName, groupName)
+	return err
+}
+
+// RemoveGroupMember removes user from members of group
+func (u *Userinfo) RemoveGroupMember(groupName, userName string) error {
+	_, err := u.runCmd(gpasswd, "-d", userName, groupName)
+	return err
+}
+
+// SetGroupAdmins replaces administrators of group, empty list removes all
+func (u *Userinfo) SetGroupAdmins(groupName string, admins []string) error {
+	_, err := u.runCmd(gpasswd, "-A", strings.Join(admins, ","), groupName)
+	return err
+}
+
+// SetGroupMembers replaces members of group, empty list removes all
+func (u *Userinfo) SetGroupMembers(groupName string, members []string) error {
+	_, err := u.runCmd(gpasswd, "-M", strings.Join(members, ","), groupName)
+	return err
+}
+
+// GroupChanges is the change set of syncing members of group,
+// members added and removed with gpasswd.
+type GroupChanges struct {
+	Group   string   `json:"group"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+
+	// Kept are members not removed as prune was not set
+	Kept []string `json:"kept,omitempty"`
+}
+
+// SyncGroupMembers adds missing members of desired to group. Members not
+// desired are removed with prune, kept otherwise. Only members changing are
+// added or removed, so each change is one gpasswd run. Changes applied
+// are returned along with error of first failed change.
+func (u *Userinfo) SyncGroupMembers(groupName string, desired []string, prune bool) (*GroupChanges, error) {
+	g, err := u.GetGroup(groupName)
+	if err != nil {
+		return nil, err
+	}
+
+	want := make(map[string]bool)
+	for _, name := range desired {
+		if name = strings.TrimSpace(name); name != "" {
+			want[name] = true
+		}
+	}
+	have := make(map[string]bool)
+	for _, name := range g.Members {
+		have[name] = true
+	}
+
+	changes := &GroupChanges{Group: groupName, Added: []string{}, Removed: []string{}}
+	var add, remove []string
+	for name := range want {
+		if !have[name] {
+			add = append(add, name)
+		}
+	}
+	for name := range have {
+		if want[name] {
+			continue
+		}
+		if prune {
+			remove = append(remove, name)
+		} else {
+			changes.Kept = append(changes.Kept, name)
+		}
+	}
+	sort.Strings(add)
+	sort.Strings(remove)
+	sort.Strings(changes.Kept)
+
+	for _, name := range add {
+		if err := u.AddGroupMember(groupName, name); err != nil {
+			log.Error("Error in adding ", name, " to group ", groupName, ": ", err)
+			return changes, err
+		}
+		changes.Added = append(changes.Added, name)
+	}
+	for _, name := range remove {
+		if err := u.RemoveGroupMember(groupName, name); err != nil {
+			log.Error("Error in removing ", name, " from group ", groupName, ": ", err)
+			return changes, err
+		}
+		changes.Removed = append(changes.Removed, name)
+	}
+
+	return changes, nil
+}
+
+// RemoveGroupPassword removes password of group, only
+// members can join it with newgrp afterwards.
+func (u *Userinfo) RemoveGroupPassword(groupName string) error {
+	_, err := u.runCmd(gpasswd, "-r", groupName)
+	return err
+}
+
+// Returns fields of all entries of database, from getent on remote
+// host or with nss resolver, from local file otherwise.
+func (u *Userinfo) readDB(db, file string) ([][]string, error) {
+	var data []byte
+	var err error
+	switch {
+	case u.remote:
+		data, err = u.cmdRunner().Run(context.Background(), "", getentCmd, db)
+	case resolver == ResolverNSS:
+		data, err = NewExecRunner().Run(context.Background(), "", getentCmd, db)
+	default:
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries [][]string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, strings.Split(line, ":"))
+	}
+
+	return entries, scanner.Err()
+}
+
+// Password field of gshadow is blank or starts with ! or * when
+// no password is set, ! before hash locks the password.
+func hasGroupPassword(field string) bool {
+	return field != "" && !strings.HasPrefix(field, "!") && !strings.HasPrefix(field, "*")
+}
+
+// Splits comma separated list, blank gives empty list
+func splitList(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+
+	return strings.Split(s, ",")
+}
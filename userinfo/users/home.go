@@ -0,0 +1,289 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/prashant-sb/go-utils/archive"
+	hasher "github.com/prashant-sb/go-utils/file_signatures/hash"
+	log "github.com/prashant-sb/go-utils/logging"
+	"github.com/prashant-sb/go-utils/pool"
+	"github.com/prashant-sb/go-utils/walker"
+)
+
+// Bases of homes moved by MoveHome, set before ops are used
+var homeBases = []string{"/home"}
+
+// SetHomeBases sets directories homes are moved from, homes of system
+// accounts outside of bases are never moved. /home will be default.
+func SetHomeBases(bases []string) error {
+	var clean []string
+	for _, b := range bases {
+		b = filepath.Clean(b)
+		if !filepath.IsAbs(b) || b == "/" {
+			return errors.New("Home base " + b + " must be absolute path other than /.")
+		}
+		clean = append(clean, b)
+	}
+	if len(clean) == 0 {
+		return errors.New("Home base is needed.")
+	}
+
+	homeBases = clean
+	return nil
+}
+
+// MoveHome moves home directory of user to newPath. Home is copied with
+// ownership, modes, times and xattrs, copy is verified with checksums,
+// passwd is updated and old home removed last. Copy is removed when any
+// step before updating passwd fails. Refused while user is logged in, and
+// for homes not owned by user, shared with other users or outside of bases.
+func (u *Userinfo) MoveHome(userName, newPath string) error {
+	if u.remote {
+		return errors.New("Moving home of user on remote host not supported.")
+	}
+
+	current, err := u.Get(userName)
+	if err != nil {
+		return err
+	}
+	oldPath := filepath.Clean(current.HomeDir)
+
+	if err := u.checkOldHome(current, oldPath); err != nil {
+		return err
+	}
+	if err := checkHomePaths(oldPath, newPath); err != nil {
+		return err
+	}
+	newPath = filepath.Clean(newPath)
+
+	loggedIn, err := u.loggedInUsers()
+	if err != nil {
+		return err
+	}
+	if loggedIn[userName] {
+		return errors.New("User " + userName + " is logged in, home not moved.")
+	}
+
+	if err := copyHome(oldPath, newPath); err != nil {
+		os.RemoveAll(newPath)
+		log.Error("Error in copying home of ", userName, ": ", err)
+		return err
+	}
+	if err := verifyHome(oldPath, newPath); err != nil {
+		os.RemoveAll(newPath)
+		log.Error("Error in verifying home of ", userName, ": ", err)
+		return err
+	}
+
+	if _, err := u.runCmd(userMod, "-d", newPath, userName); err != nil {
+		os.RemoveAll(newPath)
+		log.Error("Error in modifying user : ", userName, " ", err.Error())
+		return err
+	}
+
+	// Passwd points to the verified copy, failing removal leaves old home behind
+	if err := os.RemoveAll(oldPath); err != nil {
+		log.Warn("Home of ", userName, " moved, error in removing ", oldPath, ": ", err)
+	}
+
+	return nil
+}
+
+// Checks old home of user is safe to remove once copied: not /, not
+// shared with or containing home of other user, directory owned by
+// user and below one of home bases.
+func (u *Userinfo) checkOldHome(user *Userinfo, oldPath string) error {
+	if oldPath == "/" || !filepath.IsAbs(oldPath) {
+		return errors.New("Home " + oldPath + " of " + user.Username + " can't be moved.")
+	}
+
+	passwd, err := u.readDB("passwd", userDB)
+	if err != nil {
+		return err
+	}
+	for _, fields := range passwd {
+		if len(fields) < 6 || fields[0] == user.Username || fields[5] == "" {
+			continue
+		}
+		// Home of / is of system accounts without home
+		other := filepath.Clean(fields[5])
+		if withinDir(other, oldPath) || (other != "/" && withinDir(oldPath, other)) {
+			return errors.New("Home " + oldPath + " is shared with user " + fields[0] + ".")
+		}
+	}
+
+	// Symlinked home is refused, copy and removal would follow it
+	info, err := os.Lstat(oldPath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return errors.New("Home " + oldPath + " is not a directory.")
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || strconv.FormatUint(uint64(st.Uid), 10) != user.Uid {
+		return errors.New("Home " + oldPath + " is not owned by " + user.Username + ".")
+	}
+
+	for _, base := range homeBases {
+		if oldPath != base && withinDir(oldPath, base) {
+			return nil
+		}
+	}
+
+	return errors.New("Home " + oldPath + " is not below " + strings.Join(homeBases, ", ") + ".")
+}
+
+// Checks new path is absolute, not existing and outside of old home
+func checkHomePaths(oldPath, newPath string) error {
+	if !filepath.IsAbs(newPath) {
+		return errors.New("Home " + newPath + " must be absolute path.")
+	}
+	newPath = filepath.Clean(newPath)
+	if withinDir(newPath, oldPath) {
+		return errors.New("Home " + newPath + " is inside of " + oldPath + ".")
+	}
+	if _, err := os.Lstat(newPath); err == nil {
+		return errors.New("Home " + newPath + " already exists.")
+	}
+
+	return nil
+}
+
+// Returns true if path is dir or inside of dir, both clean and absolute
+func withinDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// Copies home by streaming tar archive of old home to extraction at new path.
+// Embedded manifest is not used, as file MANIFEST of home would be taken
+// as manifest, copy is verified afterwards.
+func copyHome(oldPath, newPath string) error {
+	pr, pw := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		err := archive.Create(pw, oldPath, archive.Options{Format: archive.FormatTar})
+		pw.CloseWithError(err)
+		done <- err
+	}()
+
+	err := archive.Extract(pr, newPath, archive.Options{Format: archive.FormatTar})
+	pr.CloseWithError(err)
+	if cerr := <-done; err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	// Extraction creates new path as root, owner and mode of home are kept
+	info, err := os.Stat(oldPath)
+	if err != nil {
+		return err
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		if err := os.Lchown(newPath, int(st.Uid), int(st.Gid)); err != nil {
+			return err
+		}
+	}
+
+	return os.Chmod(newPath, info.Mode().Perm())
+}
+
+// Attributes of copied file compared with original
+type homeFile struct {
+	sum   string
+	owner string
+	mode  os.FileMode
+}
+
+// Verifies files under new path have checksums, owners and
+// modes of files under old path.
+func verifyHome(oldPath, newPath string) error {
+	var mismatched []string
+	var werr error
+
+	onResult := func(res pool.Result) {
+		if res.Err != nil {
+			if werr == nil {
+				werr = res.Err
+			}
+			return
+		}
+		if rel, ok := res.Value.(string); ok && rel != "" {
+			mismatched = append(mismatched, rel)
+		}
+	}
+
+	err := walker.Walk(context.Background(), oldPath, walker.Options{
+		Dirs:     true,
+		OnResult: onResult,
+	}, func(ctx context.Context, path string, info os.FileInfo) (interface{}, error) {
+		rel, err := filepath.Rel(oldPath, path)
+		if err != nil {
+			return nil, err
+		}
+
+		want, err := homeAttrs(path, info)
+		if err != nil {
+			return nil, err
+		}
+		copyPath := filepath.Join(newPath, rel)
+		copyInfo, err := os.Lstat(copyPath)
+		if err != nil {
+			return rel, nil
+		}
+		got, err := homeAttrs(copyPath, copyInfo)
+		if err != nil {
+			return nil, err
+		}
+
+		if got != want {
+			return rel, nil
+		}
+		return "", nil
+	})
+	if werr == nil {
+		werr = err
+	}
+	if werr != nil {
+		return werr
+	}
+
+	if len(mismatched) > 0 {
+		return errors.New("Copy of home differs for " + strconv.Itoa(len(mismatched)) +
+			" files: " + strings.Join(mismatched, ", "))
+	}
+
+	return nil
+}
+
+// Returns checksum of regular file, owner and mode
+func homeAttrs(path string, info os.FileInfo) (homeFile, error) {
+	f := homeFile{mode: info.Mode()}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		f.owner = strconv.FormatUint(uint64(st.Uid), 10) + ":" + strconv.FormatUint(uint64(st.Gid), 10)
+	}
+
+	if info.Mode().IsRegular() {
+		var err error
+		if f.sum, err = hasher.FileSha256(path); err != nil {
+			return f, err
+		}
+	}
+
+	return f, nil
+}
@@ -0,0 +1,113 @@
+package users
+
+import (
+	"errors"
+	"time"
+
+	log "github.com/prashant-sb/go-utils/logging"
+)
+
+// DeletedUser is the record of user removed from system.
+// Files owned by its uid are accessible to the next user with same uid.
+type DeletedUser struct {
+	Username string    `json:"userName"`
+	Uid      string    `json:"uid"`
+	Gid      string    `json:"gid"`
+	Deleted  time.Time `json:"deleted"`
+}
+
+// DeletedUsers returns records of users deleted by this tool
+func DeletedUsers() ([]DeletedUser, error) {
+	deleted := []DeletedUser{}
+	if err := readState(deletedDB, &deleted); err != nil {
+		return nil, err
+	}
+
+	return deleted, nil
+}
+
+// Appends deleted user to records
+func recordDeleted(uinfo *Userinfo) error {
+	deleted, err := DeletedUsers()
+	if err != nil {
+		return err
+	}
+
+	deleted = append(deleted, DeletedUser{
+		Username: uinfo.Username,
+		Uid:      uinfo.Uid,
+		Gid:      uinfo.Gid,
+		Deleted:  time.Now().UTC().Truncate(time.Second),
+	})
+
+	return writeState(deletedDB, deleted)
+}
+
+// Returns latest deletion of user with uid, nil if none
+func deletedByUid(uid string) *DeletedUser {
+	deleted, err := DeletedUsers()
+	if err != nil {
+		log.Warn("Error in reading ", statePath(deletedDB), ": ", err)
+		return nil
+	}
+
+	var found *DeletedUser
+	for i := range deleted {
+		if deleted[i].Uid == uid {
+			found = &deleted[i]
+		}
+	}
+
+	return found
+}
+
+// Checks uid and gid of schema before adding user. Uid used by other user,
+// uid of deleted user and gid not matching primary group are refused.
+func (u *Userinfo) checkIds(uinfo *Userinfo) error {
+	if uinfo.Uid != "" {
+		if other, err := u.lookupUid(uinfo.Uid); err == nil {
+			return errors.New("Uid " + uinfo.Uid + " is used by user " + other.Username + ".")
+		}
+
+		if d := deletedByUid(uinfo.Uid); d != nil && !uinfo.AllowUidReuse && !u.remote {
+			return errors.New("Uid " + uinfo.Uid + " belonged to user " + d.Username +
+				" deleted at " + d.Deleted.Format(time.RFC3339) +
+				", its files would be accessible. Set allowUidReuse to reuse.")
+		}
+	}
+
+	return u.checkGid(uinfo.PrimaryGroup, uinfo.Gid)
+}
+
+// Checks gid of schema matches primary group, or is free for creating it
+func (u *Userinfo) checkGid(groupName, gid string) error {
+	if groupName == "" || gid == "" {
+		return nil
+	}
+
+	if current, err := u.lookupGroup(groupName); err == nil {
+		if current != gid {
+			return errors.New("Group " + groupName + " has gid " + current + ", schema has gid " + gid + ".")
+		}
+		return nil
+	}
+
+	if other, err := u.lookupGroupId(gid); err == nil {
+		return errors.New("Gid " + gid + " is used by group " + other + ".")
+	}
+
+	return nil
+}
+
+// Reports uid assigned by system when it belonged to deleted user
+func (u *Userinfo) reportUidReuse(userName string) {
+	added, err := u.lookupUser(userName)
+	if err != nil {
+		return
+	}
+
+	if d := deletedByUid(added.Uid); d != nil {
+		log.Warn("User ", userName, " reuses uid ", added.Uid, " of user ", d.Username,
+			" deleted at ", d.Deleted.Format(time.RFC3339), ", files of ", d.Username, " are accessible.")
+	}
+}
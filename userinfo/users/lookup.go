@@ -0,0 +1,256 @@
+package users
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+const (
+	getentCmd string = "getent" // Command for reading passwd and group databases
+	idCmd     string = "id"     // Command for listing groups of user
+)
+
+// Lookups of users and groups consult local databases with os/user,
+// or with resolver selected by SetResolver(), and databases of remote
+// host with getent over runner.
+
+// Source of passwd and group entries
+type entrySource struct {
+	// Returns fields of database entry by name or id
+	entry func(db, key string) ([]string, error)
+
+	// Returns names of all groups of user
+	groups func(userName string) ([]string, error)
+}
+
+// Returns source of entries for lookups, nil for os/user
+func (u *Userinfo) source() *entrySource {
+	switch {
+	case u.remote:
+		return runnerSource(u.cmdRunner())
+	case resolver == ResolverNSS:
+		// Lookups are reads, not recorded or faked with runner of ops
+		return runnerSource(NewExecRunner())
+	case resolver == ResolverFiles:
+		return filesSource()
+	}
+
+	return nil
+}
+
+// Returns user by name
+func (u *Userinfo) lookupUser(userName string) (*Userinfo, error) {
+	if s := u.source(); s != nil {
+		return s.user(userName)
+	}
+
+	ui, err := user.Lookup(userName)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromUser(ui)
+}
+
+// Returns user by numeric id
+func (u *Userinfo) lookupUid(uid string) (*Userinfo, error) {
+	if s := u.source(); s != nil {
+		return s.user(uid)
+	}
+
+	ui, err := user.LookupId(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromUser(ui)
+}
+
+// Returns gid of group by name
+func (u *Userinfo) lookupGroup(groupName string) (string, error) {
+	if s := u.source(); s != nil {
+		fields, err := s.entry("group", groupName)
+		if err != nil {
+			return "", errors.New("Group " + groupName + " not found.")
+		}
+		return fields[2], nil
+	}
+
+	g, err := user.LookupGroup(groupName)
+	if err != nil {
+		return "", err
+	}
+
+	return g.Gid, nil
+}
+
+// Returns name of group by gid
+func (u *Userinfo) lookupGroupId(gid string) (string, error) {
+	if s := u.source(); s != nil {
+		return s.groupName(gid)
+	}
+
+	g, err := user.LookupGroupId(gid)
+	if err != nil {
+		return "", err
+	}
+
+	return g.Name, nil
+}
+
+// Constructs user schema from passwd entry
+func (s *entrySource) user(key string) (*Userinfo, error) {
+	fields, err := s.entry("passwd", key)
+	if err != nil || len(fields) < 7 {
+		return nil, errors.New("User " + key + " not found.")
+	}
+
+	uinfo := &Userinfo{
+		Username: fields[0],
+		Uid:      fields[2],
+		Gid:      fields[3],
+		HomeDir:  fields[5],
+		Shell:    fields[6],
+	}
+	if fields[4] != "" {
+		uinfo.Gecos = ParseGecos(fields[4])
+		uinfo.Name = uinfo.Gecos.FullName
+	}
+
+	if uinfo.PrimaryGroup, err = s.groupName(uinfo.Gid); err != nil {
+		return nil, err
+	}
+
+	groups, err := s.groups(uinfo.Username)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		if g != uinfo.PrimaryGroup {
+			uinfo.SupplementaryGroups = append(uinfo.SupplementaryGroups, g)
+		}
+	}
+
+	return uinfo, nil
+}
+
+// Returns name of group by gid
+func (s *entrySource) groupName(gid string) (string, error) {
+	fields, err := s.entry("group", gid)
+	if err != nil {
+		return "", errors.New("Group " + gid + " not found.")
+	}
+
+	return fields[0], nil
+}
+
+// Returns source of getent and id over runner
+func runnerSource(r Runner) *entrySource {
+	return &entrySource{
+		entry: func(db, key string) ([]string, error) {
+			return getent(r, db, key)
+		},
+		groups: func(userName string) ([]string, error) {
+			out, err := r.Run(context.Background(), "", idCmd, "-Gn", userName)
+			if err != nil {
+				return nil, err
+			}
+			return strings.Fields(string(out)), nil
+		},
+	}
+}
+
+// Returns fields of database entry by name or id
+func getent(r Runner, db, key string) ([]string, error) {
+	out, err := r.Run(context.Background(), "", getentCmd, db, key)
+	if err != nil {
+		return nil, err
+	}
+
+	line := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	fields := strings.Split(line, ":")
+	if len(fields) < 3 {
+		return nil, errors.New("Entry " + key + " not found in " + db)
+	}
+
+	return fields, nil
+}
+
+// Returns source parsing passwd and group files
+func filesSource() *entrySource {
+	files := map[string]string{"passwd": userDB, "group": groupDB}
+
+	return &entrySource{
+		entry: func(db, key string) ([]string, error) {
+			return fileEntry(files[db], key)
+		},
+		groups: func(userName string) ([]string, error) {
+			return fileGroups(groupDB, userName)
+		},
+	}
+}
+
+// Returns fields of entry in database file, numeric keys
+// match the id as getent does, others the name.
+func fileEntry(file, key string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	field := 0
+	if _, err := strconv.Atoi(key); err == nil {
+		field = 2
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) >= 3 && fields[field] == key {
+			return fields, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, errors.New("Entry " + key + " not found in " + file)
+}
+
+// Returns groups listing user as member in group file
+func fileGroups(file, userName string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var groups []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(strings.TrimSpace(scanner.Text()), ":")
+		if len(fields) < 4 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		for _, member := range strings.Split(fields[3], ",") {
+			if member == userName {
+				groups = append(groups, fields[0])
+				break
+			}
+		}
+	}
+
+	return groups, scanner.Err()
+}
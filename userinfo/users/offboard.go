@@ -0,0 +1,250 @@
+package users
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	log "github.com/prashant-sb/go-utils/logging"
+)
+
+const (
+	shadowDB     string = "/etc/shadow"                  // Passwords and expiry of users
+	disabledKeys string = authorizedKeys + ".offboarded" // Keys set aside while pending
+)
+
+// PendingDeletion is the user locked and scheduled for deletion
+// once grace period ends. Cancelling restores the account.
+type PendingDeletion struct {
+	Username    string    `json:"userName"`
+	Uid         string    `json:"uid"`
+	Locked      time.Time `json:"locked"`
+	DeleteAfter time.Time `json:"deleteAfter"`
+
+	// Expire is account expiry in days of shadow before locking,
+	// blank for account not expiring.
+	Expire string `json:"expire,omitempty"`
+
+	// DisabledKeys is the authorized_keys file set aside, blank
+	// when user had no keys.
+	DisabledKeys string `json:"disabledKeys,omitempty"`
+}
+
+// ScheduleDeletion offboards user instead of deleting it: password is
+// locked, account expired so ssh keys can't login, authorized_keys set
+// aside and user recorded for deletion after grace by PurgeDeletions.
+func (u *Userinfo) ScheduleDeletion(userName string, grace time.Duration) (*PendingDeletion, error) {
+	if u.remote {
+		return nil, errors.New("Scheduling deletion of user on remote host not supported.")
+	}
+
+	pending, err := u.ListPendingDeletions()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pending {
+		if p.Username == userName {
+			return nil, errors.New("User " + userName + " is already pending deletion after " +
+				p.DeleteAfter.Format(time.RFC3339) + ".")
+		}
+	}
+
+	uinfo, err := u.Get(userName)
+	if err != nil {
+		return nil, errors.New("User " + userName + " not found.")
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	p := PendingDeletion{
+		Username:    userName,
+		Uid:         uinfo.Uid,
+		Locked:      now,
+		DeleteAfter: now.Add(grace),
+	}
+	if fields, err := fileEntry(shadowDB, userName); err == nil && len(fields) > 7 {
+		p.Expire = fields[7]
+	}
+
+	// Expiry of 1 is a day after epoch, denying all logins
+	if _, err := u.runCmd(userMod, "-L", "-e", "1", userName); err != nil {
+		log.Error("Error in locking user ", userName, ": ", err)
+		return nil, err
+	}
+
+	if err := disableKeys(uinfo, &p); err != nil {
+		log.Warn("Error in disabling ssh keys of ", userName, ": ", err)
+	}
+
+	if err := writePending(append(pending, p)); err != nil {
+		log.Error("User ", userName, " is locked but not scheduled for deletion: ", err)
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// ListPendingDeletions returns users scheduled for deletion
+func (u *Userinfo) ListPendingDeletions() ([]PendingDeletion, error) {
+	pending := []PendingDeletion{}
+	if err := readState(pendingDB, &pending); err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+// CancelDeletion unlocks user pending deletion, restores expiry
+// of account and its ssh keys.
+func (u *Userinfo) CancelDeletion(userName string) error {
+	pending, err := u.ListPendingDeletions()
+	if err != nil {
+		return err
+	}
+
+	var kept []PendingDeletion
+	var found *PendingDeletion
+	for i := range pending {
+		if pending[i].Username == userName {
+			found = &pending[i]
+			continue
+		}
+		kept = append(kept, pending[i])
+	}
+	if found == nil {
+		return errors.New("User " + userName + " is not pending deletion.")
+	}
+
+	if _, err := u.runCmd(userMod, "-U", "-e", expireDate(found.Expire), userName); err != nil {
+		log.Error("Error in unlocking user ", userName, ": ", err)
+		return err
+	}
+
+	if found.DisabledKeys != "" {
+		if err := restoreKeys(found); err != nil {
+			log.Warn("Error in restoring ssh keys of ", userName, ": ", err)
+		}
+	}
+
+	return writePending(kept)
+}
+
+// PurgeDeletions deletes users whose grace period ended, users logged
+// in are left for next run. Returns result of each due user.
+func (u *Userinfo) PurgeDeletions() ([]DeleteResult, error) {
+	pending, err := u.ListPendingDeletions()
+	if err != nil {
+		return nil, err
+	}
+
+	loggedIn, err := u.loggedInUsers()
+	if err != nil {
+		log.Error("Error in listing logged-in users: ", err.Error())
+		return nil, err
+	}
+
+	now := time.Now()
+	results := []DeleteResult{}
+	var kept []PendingDeletion
+	for _, p := range pending {
+		if now.Before(p.DeleteAfter) {
+			kept = append(kept, p)
+			continue
+		}
+
+		res := DeleteResult{Username: p.Username}
+		uinfo, err := u.Get(p.Username)
+		switch {
+		case err != nil:
+			// Removed by other means, nothing left to delete
+			res.Deleted = true
+		case uinfo.Uid != p.Uid:
+			res.Error = "User " + p.Username + " has uid " + uinfo.Uid + ", scheduled with uid " + p.Uid + "."
+		case loggedIn[p.Username]:
+			res.Error = "User " + p.Username + " is currently logged in."
+		default:
+			if err := u.delete(uinfo); err != nil {
+				res.Error = err.Error()
+			} else {
+				res.Deleted = true
+			}
+		}
+
+		if !res.Deleted {
+			kept = append(kept, p)
+		}
+		results = append(results, res)
+	}
+
+	return results, writePending(kept)
+}
+
+// Sets authorized_keys of user aside, recorded in pending deletion
+func disableKeys(uinfo *Userinfo, p *PendingDeletion) error {
+	uid, err := strconv.Atoi(uinfo.Uid)
+	if err != nil {
+		return err
+	}
+	dir, err := openKeyDir(uinfo.HomeDir, uid, -1, false)
+	if dir == nil || err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	keys, err := dir.open(authorizedKeys)
+	if keys == nil || err != nil {
+		return err
+	}
+	keys.Close()
+
+	if err := dir.rename(authorizedKeys, disabledKeys); err != nil {
+		return err
+	}
+	p.DisabledKeys = filepath.Join(dir.path, disabledKeys)
+
+	return nil
+}
+
+// Restores authorized_keys of pending deletion, kept aside if
+// user has new keys.
+func restoreKeys(p *PendingDeletion) error {
+	uid, err := strconv.Atoi(p.Uid)
+	if err != nil {
+		return err
+	}
+	dir, err := openKeyDir(filepath.Dir(filepath.Dir(p.DisabledKeys)), uid, -1, false)
+	if err != nil {
+		return err
+	}
+	if dir == nil {
+		return errors.New("Directory of " + p.DisabledKeys + " not found.")
+	}
+	defer dir.Close()
+
+	if dir.exists(authorizedKeys) {
+		log.Warn("Keys of ", p.Username, " were added while pending, disabled keys kept in ", p.DisabledKeys)
+		return nil
+	}
+
+	return dir.rename(filepath.Base(p.DisabledKeys), authorizedKeys)
+}
+
+// Writes users pending deletion
+func writePending(pending []PendingDeletion) error {
+	if pending == nil {
+		pending = []PendingDeletion{}
+	}
+
+	return writeState(pendingDB, pending)
+}
+
+// Returns expiry date for usermod of days since epoch,
+// blank for account not expiring.
+func expireDate(days string) string {
+	n, err := strconv.ParseInt(days, 10, 64)
+	if err != nil || n < 0 {
+		return ""
+	}
+
+	return time.Unix(n*24*60*60, 0).UTC().Format("2006-01-02")
+}
@@ -0,0 +1,250 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	log "github.com/prashant-sb/go-utils/logging"
+)
+
+const (
+	userMod string = "usermod" // Command for modifying user
+
+	opCreate string = "create" // Plan creates the user
+	opModify string = "modify" // Plan modifies the user
+	opNone   string = "none"   // User already matches the schema
+)
+
+// Action is the change of single user attribute
+type Action struct {
+	// Field is the json name of changed attribute.
+	Field string `json:"field"`
+
+	// Before is the value on system, blank for new user.
+	Before string `json:"before,omitempty"`
+
+	// After is the value from schema.
+	After string `json:"after,omitempty"`
+}
+
+// Plan lists the changes required to match user with schema
+type Plan struct {
+	// Username is the login name of planned user.
+	Username string `json:"userName"`
+
+	// Operation is one of create | modify | none.
+	Operation string `json:"operation"`
+
+	// Actions are the attribute changes.
+	Actions []Action `json:"actions"`
+
+	// Applied is set when the plan is executed on system.
+	Applied bool `json:"applied"`
+}
+
+// PlanChanges returns the plan for schema without applying it
+func (u *Userinfo) PlanChanges(usrJsonFile string) (*Plan, error) {
+
+	uinfo, err := u.loadUser(usrJsonFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.plan(uinfo), nil
+}
+
+// Apply creates or modifies user to match the schema,
+// returns the executed plan.
+func (u *Userinfo) Apply(usrJsonFile string) (*Plan, error) {
+
+	uinfo, err := u.loadUser(usrJsonFile)
+	if err != nil {
+		return nil, err
+	}
+
+	p := u.plan(uinfo)
+	switch p.Operation {
+	case opCreate:
+		err = u.add(uinfo)
+	case opModify:
+		err = u.modify(uinfo, p)
+	}
+	if err != nil {
+		log.Error("Error in applying plan for user ", uinfo.Username)
+		return p, err
+	}
+	p.Applied = true
+
+	return p, nil
+}
+
+// Modify changes existing user to match the schema,
+// returns the executed plan.
+func (u *Userinfo) Modify(usrJsonFile string) (*Plan, error) {
+
+	uinfo, err := u.loadUser(usrJsonFile)
+	if err != nil {
+		return nil, err
+	}
+
+	p := u.plan(uinfo)
+	if p.Operation == opCreate {
+		return p, errors.New("User " + uinfo.Username + " not found.")
+	}
+
+	if p.Operation == opModify {
+		if err := u.modify(uinfo, p); err != nil {
+			return p, err
+		}
+	}
+	p.Applied = true
+
+	return p, nil
+}
+
+// Reads and unmarshal user schema from json file
+func (u *Userinfo) loadUser(usrJsonFile string) (*Userinfo, error) {
+
+	uinfo := Userinfo{}
+
+	b, err := u.readUsers(usrJsonFile)
+	if err != nil {
+		log.Error("Error in reading ", usrJsonFile)
+		return nil, err
+	}
+
+	if err = json.Unmarshal(b, &uinfo); err != nil {
+		log.Error("Error in unmarshal: ", err.Error())
+		return nil, err
+	}
+	if err = uinfo.legacyGroup(b); err != nil {
+		return nil, err
+	}
+
+	if uinfo.Username == "" {
+		return nil, errors.New("Username missing in " + usrJsonFile)
+	}
+	if err := uinfo.Gecos.validate(); err != nil {
+		return nil, err
+	}
+
+	return &uinfo, nil
+}
+
+// Maps groupName of older schemas to primaryGroup, the key was renamed.
+func (uinfo *Userinfo) legacyGroup(b []byte) error {
+	legacy := struct {
+		Groupname string `json:"groupName"`
+	}{}
+	if err := json.Unmarshal(b, &legacy); err != nil || legacy.Groupname == "" {
+		return err
+	}
+
+	switch uinfo.PrimaryGroup {
+	case "":
+		log.Warn("Key groupName of schema is deprecated, use primaryGroup.")
+		uinfo.PrimaryGroup = legacy.Groupname
+	case legacy.Groupname:
+	default:
+		return errors.New("Schema has groupName " + legacy.Groupname +
+			" and primaryGroup " + uinfo.PrimaryGroup + ", keep primaryGroup only.")
+	}
+
+	return nil
+}
+
+// Compares schema with system user and constructs the plan.
+// Only attributes set in schema are planned.
+func (u *Userinfo) plan(uinfo *Userinfo) *Plan {
+
+	p := &Plan{
+		Username: uinfo.Username,
+		Actions:  []Action{},
+	}
+
+	current, err := u.Get(uinfo.Username)
+	if err != nil {
+		p.Operation = opCreate
+		current = &Userinfo{}
+	}
+
+	// Comment keeps sub-fields not set in schema, name is its first
+	gecos := Action{Field: "gecos", Before: current.Gecos.String()}
+	if uinfo.Gecos != nil || uinfo.Name != "" {
+		gecos.After = uinfo.comment(current.Gecos)
+	}
+
+	for _, a := range []Action{
+		gecos,
+		{Field: "homeDir", Before: current.HomeDir, After: uinfo.HomeDir},
+		{Field: "shell", Before: current.Shell, After: uinfo.Shell},
+		{Field: "primaryGroup", Before: current.PrimaryGroup, After: uinfo.PrimaryGroup},
+		{
+			Field:  "supplementaryGroups",
+			Before: joinGroups(current.SupplementaryGroups),
+			After:  joinGroups(uinfo.SupplementaryGroups),
+		},
+	} {
+		if a.After != "" && a.After != a.Before {
+			p.Actions = append(p.Actions, a)
+		}
+	}
+
+	if p.Operation == "" {
+		p.Operation = opNone
+		if len(p.Actions) > 0 {
+			p.Operation = opModify
+		}
+	}
+
+	return p
+}
+
+// modifies the system user with actions from plan
+func (u *Userinfo) modify(uinfo *Userinfo, p *Plan) error {
+
+	var argUser []string
+
+	for _, a := range p.Actions {
+		switch a.Field {
+		case "gecos":
+			argUser = append(argUser, "-c", a.After)
+		case "homeDir":
+			argUser = append(argUser, "-d", a.After, "-m")
+		case "shell":
+			if err := u.checkShell(uinfo, a.After); err != nil {
+				return err
+			}
+			argUser = append(argUser, "-s", a.After)
+		case "primaryGroup":
+			if err := u.checkGid(a.After, uinfo.Gid); err != nil {
+				return err
+			}
+			if err := u.ensureGroup(a.After, uinfo.Gid, uinfo.CreateGroup); err != nil {
+				return err
+			}
+			argUser = append(argUser, "-g", a.After)
+		case "supplementaryGroups":
+			argUser = append(argUser, "-G", a.After)
+		}
+	}
+	argUser = append(argUser, uinfo.Username)
+
+	if _, err := u.runCmd(userMod, argUser...); err != nil {
+		log.Error("Error in modifying user : ", uinfo.Username, " ", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Returns sorted, comma separated group names
+func joinGroups(groups []string) string {
+	sorted := make([]string, len(groups))
+	copy(sorted, groups)
+	sort.Strings(sorted)
+
+	return strings.Join(sorted, ",")
+}
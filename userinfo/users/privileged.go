@@ -0,0 +1,101 @@
+package users
+
+import (
+	"sort"
+)
+
+// Groups granting administration, sudo on debian and su with pam_wheel
+var adminGroups = []string{"wheel", "sudo", "admin"}
+
+// Reasons of privileged users
+const (
+	ReasonRoot     string = "uid-0"       // root itself
+	ReasonUidAlias string = "uid-0-alias" // Other name of uid 0
+	ReasonGroup    string = "group:"      // Member of admin group, with group name
+	ReasonPrimary  string = "primary:"    // Admin group is primary group, with group name
+)
+
+// PrivilegedUser is the user with uid 0 or membership of admin groups
+type PrivilegedUser struct {
+	Username string `json:"userName"`
+	Uid      string `json:"uid"`
+
+	// Reasons lists why user is privileged, as uid-0 or group:sudo.
+	Reasons []string `json:"reasons"`
+}
+
+// PrivilegedUsers returns users with uid 0 and members of wheel, sudo and
+// admin groups, including users having them as primary group. Members not
+// in passwd database are reported without uid.
+func (u *Userinfo) PrivilegedUsers() ([]PrivilegedUser, error) {
+	passwd, err := u.readDB("passwd", userDB)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := u.ListGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]*PrivilegedUser)
+	uids := make(map[string]string)
+	add := func(name, reason string) {
+		p, ok := found[name]
+		if !ok {
+			p = &PrivilegedUser{Username: name, Uid: uids[name]}
+			found[name] = p
+		}
+		p.Reasons = append(p.Reasons, reason)
+	}
+
+	for _, fields := range passwd {
+		if len(fields) >= 4 {
+			uids[fields[0]] = fields[2]
+		}
+	}
+	for _, fields := range passwd {
+		if len(fields) < 4 || fields[2] != "0" {
+			continue
+		}
+		if fields[0] == "root" {
+			add(fields[0], ReasonRoot)
+		} else {
+			add(fields[0], ReasonUidAlias)
+		}
+	}
+
+	for _, g := range groups {
+		if !isAdminGroup(g.Name) {
+			continue
+		}
+		for _, m := range g.Members {
+			add(m, ReasonGroup+g.Name)
+		}
+		for _, fields := range passwd {
+			if len(fields) >= 4 && fields[3] == g.Gid {
+				add(fields[0], ReasonPrimary+g.Name)
+			}
+		}
+	}
+
+	users := []PrivilegedUser{}
+	for _, p := range found {
+		users = append(users, *p)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Username < users[j].Username
+	})
+
+	return users, nil
+}
+
+// Returns true if group grants administration
+func isAdminGroup(name string) bool {
+	for _, g := range adminGroups {
+		if g == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,36 @@
+package users
+
+import "encoding/json"
+
+// Redacted returns copy of user without secrets
+func (u Userinfo) Redacted() Userinfo {
+	u.UserPasswd = ""
+	return u
+}
+
+// MarshalJSON marshals user without secrets, for Decode and
+// all responses. Use Fixture() for schema files with password.
+func (u Userinfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(userFixture(u.Redacted()))
+}
+
+// String formats user as json for logs, without secrets
+func (u Userinfo) String() string {
+	data, err := json.Marshal(u)
+	if err != nil {
+		return u.Username
+	}
+
+	return string(data)
+}
+
+// Fixture marshals user with password, for test fixtures and
+// schema files. Output must not be logged.
+func (u *Userinfo) Fixture() (string, error) {
+	data, err := json.MarshalIndent(userFixture(*u), "", "   ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,46 @@
+package users
+
+import "errors"
+
+// Resolvers of local users and groups
+const (
+	// ResolverAuto uses os/user as built, NSS of libc with cgo,
+	// passwd and group files without cgo or with osusergo tag.
+	ResolverAuto string = "auto"
+
+	// ResolverFiles parses passwd and group files, users of
+	// NSS sources as ldap or sssd are not found.
+	ResolverFiles string = "files"
+
+	// ResolverNSS resolves with getent, users of all NSS sources
+	// regardless of build.
+	ResolverNSS string = "nss"
+)
+
+// Group database of linux
+const groupDB string = "/etc/group"
+
+// Resolver of local lookups, set before ops are used
+var resolver = ResolverAuto
+
+// SetResolver selects resolver of local users and groups,
+// auto | files | nss. Remote hosts always resolve with getent.
+func SetResolver(name string) error {
+	switch name {
+	case ResolverAuto, ResolverFiles, ResolverNSS:
+		resolver = name
+		return nil
+	}
+
+	return errors.New("Resolver " + name + " not supported.")
+}
+
+// Resolver returns resolver of local lookups, auto is reported
+// as files or nss by build of os/user.
+func Resolver() string {
+	if resolver == ResolverAuto {
+		return buildResolver
+	}
+
+	return resolver
+}
@@ -0,0 +1,7 @@
+//go:build cgo && !osusergo
+// +build cgo,!osusergo
+
+package users
+
+// os/user resolves with NSS of libc
+const buildResolver = ResolverNSS
@@ -0,0 +1,7 @@
+//go:build !cgo || osusergo
+// +build !cgo osusergo
+
+package users
+
+// os/user parses passwd and group files
+const buildResolver = ResolverFiles
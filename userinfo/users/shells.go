@@ -0,0 +1,79 @@
+package users
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+
+	log "github.com/prashant-sb/go-utils/logging"
+)
+
+const (
+	shellsDB string = "/etc/shells" // Valid login shells
+	catCmd   string = "cat"         // Command for reading files of remote host
+)
+
+// Shells disabling login, accepted though not listed in /etc/shells
+var noLoginShells = map[string]bool{
+	"/usr/sbin/nologin": true,
+	"/sbin/nologin":     true,
+	"/bin/false":        true,
+	"/usr/bin/false":    true,
+}
+
+// ListValidShells returns login shells of /etc/shells
+func (u *Userinfo) ListValidShells() ([]string, error) {
+	var data []byte
+	var err error
+	if u.remote {
+		data, err = u.cmdRunner().Run(context.Background(), "", catCmd, shellsDB)
+	} else {
+		data, err = ioutil.ReadFile(shellsDB)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	shells := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		shells = append(shells, line)
+	}
+
+	return shells, scanner.Err()
+}
+
+// Checks shell of schema is listed in /etc/shells, user with other
+// shell can't login. Refused unless AllowInvalidShell is set, which
+// only warns. Shells disabling login are always accepted.
+func (u *Userinfo) checkShell(uinfo *Userinfo, shell string) error {
+	if noLoginShells[shell] {
+		return nil
+	}
+
+	shells, err := u.ListValidShells()
+	if err != nil {
+		log.Warn("Error in reading ", shellsDB, ", shell ", shell, " not validated: ", err)
+		return nil
+	}
+	for _, s := range shells {
+		if s == shell {
+			return nil
+		}
+	}
+
+	msg := "Shell " + shell + " of user " + uinfo.Username + " is not in " + shellsDB + ", user can't login."
+	if uinfo.AllowInvalidShell {
+		log.Warn(msg)
+		return nil
+	}
+
+	return errors.New(msg)
+}
@@ -0,0 +1,176 @@
+package users
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	sshPort    string        = "22"               // Port of host without port
+	sshTimeout time.Duration = 10 * time.Second   // Default dial timeout
+	knownHosts string        = ".ssh/known_hosts" // Known hosts under home of user
+)
+
+// SSHOptions for running commands on remote host
+type SSHOptions struct {
+	// Host is the host or host:port, port 22 will be default.
+	Host string
+
+	// User is the login name on remote host.
+	User string
+
+	// KeyFile is the private key for authentication.
+	KeyFile string
+
+	// KnownHosts is the file verifying host key,
+	// ~/.ssh/known_hosts will be default.
+	KnownHosts string
+
+	// Sudo runs commands with sudo -n, login user needs
+	// password-less sudo for user and group commands.
+	Sudo bool
+
+	// Timeout of connecting, 10s will be default.
+	Timeout time.Duration
+}
+
+// SSHRunner executes commands on remote host over single connection
+type SSHRunner interface {
+	Runner
+	Close() error
+}
+
+type sshRunner struct {
+	client *ssh.Client
+	sudo   bool
+}
+
+// Exit status of remote command, retried by status as local commands
+type remoteExitError struct {
+	status int
+	stderr string
+}
+
+// NewSSHRunner connects to host with key authentication,
+// host key must be in known hosts.
+func NewSSHRunner(opts SSHOptions) (SSHRunner, error) {
+	key, err := ioutil.ReadFile(opts.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.KnownHosts == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		opts.KnownHosts = filepath.Join(home, knownHosts)
+	}
+	hostKeys, err := knownhosts.New(opts.KnownHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = sshTimeout
+	}
+
+	addr := opts.Host
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, sshPort)
+	}
+
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            opts.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeys,
+		Timeout:         opts.Timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &sshRunner{client: client, sudo: opts.Sudo}, nil
+}
+
+// Run executes command in new session, input is passed on stdin
+func (r *sshRunner) Run(ctx context.Context, input, name string, args ...string) ([]byte, error) {
+	session, err := r.client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if input != "" {
+		session.Stdin = strings.NewReader(input)
+	}
+
+	cmd := shellQuote(append([]string{name}, args...))
+	if r.sudo {
+		cmd = "sudo -n " + cmd
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- session.Run(cmd) }()
+
+	select {
+	case <-ctx.Done():
+		session.Signal(ssh.SIGKILL)
+		return nil, ctx.Err()
+	case err = <-done:
+	}
+
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.Bytes(), &remoteExitError{status: exitErr.ExitStatus(), stderr: stderr.String()}
+	}
+
+	return stdout.Bytes(), err
+}
+
+// Close closes connection to host
+func (r *sshRunner) Close() error {
+	return r.client.Close()
+}
+
+func (e *remoteExitError) Error() string {
+	msg := "remote command exited with status " + strconv.Itoa(e.status)
+	if s := strings.TrimSpace(e.stderr); s != "" {
+		msg += ": " + s
+	}
+
+	return msg
+}
+
+// ExitCode returns exit status of remote command
+func (e *remoteExitError) ExitCode() int {
+	return e.status
+}
+
+// Joins arguments quoted for remote shell
+func shellQuote(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = "'" + strings.Replace(a, "'", `'\''`, -1) + "'"
+	}
+
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,355 @@
+package users
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/prashant-sb/go-utils/logging"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	sshDir         string = ".ssh"            // Directory of ssh files in home
+	authorizedKeys string = "authorized_keys" // Keys allowed to login as user
+	rotatedTag     string = "rotated="        // Comment tag of rotated keys, with install time
+)
+
+// RotateOptions are options of rotating ssh key of user
+type RotateOptions struct {
+	// RemoveOlderThan removes keys installed by rotation longer ago,
+	// zero keeps all keys. Keys not installed by rotation are kept.
+	RemoveOlderThan time.Duration `json:"removeOlderThan,omitempty"`
+}
+
+// RotatedKey is the key pair generated for user
+type RotatedKey struct {
+	Username string `json:"userName"`
+
+	// PrivateKey is the OpenSSH private key, it is not stored
+	// and returned only once.
+	PrivateKey []byte `json:"-"`
+
+	// PublicKey is the line installed in authorized_keys.
+	PublicKey string `json:"publicKey"`
+
+	// Fingerprint is the SHA256 fingerprint of key.
+	Fingerprint string `json:"fingerprint"`
+
+	// Removed lists fingerprints of rotated keys removed for age.
+	Removed []string `json:"removed,omitempty"`
+}
+
+// RotateSSHKey generates ed25519 key pair for user, installs public key in
+// authorized_keys of home, tagged with install time, and returns private key
+// to caller. With RemoveOlderThan, rotated keys installed before are removed.
+func (u *Userinfo) RotateSSHKey(userName string, opts RotateOptions) (*RotatedKey, error) {
+	if u.remote {
+		return nil, errors.New("Rotating ssh key of user on remote host not supported.")
+	}
+
+	current, err := u.Get(userName)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := strconv.Atoi(current.Uid)
+	if err != nil {
+		return nil, err
+	}
+	gid, err := strconv.Atoi(current.Gid)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := openKeyDir(current.HomeDir, uid, gid, true)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+	lines, err := dir.readLines(authorizedKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	comment := userName + " " + rotatedTag + now.Format(time.RFC3339)
+	key := &RotatedKey{
+		Username:    userName,
+		PublicKey:   strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub))) + " " + comment,
+		Fingerprint: ssh.FingerprintSHA256(sshPub),
+	}
+	if key.PrivateKey, err = marshalPrivateKey(priv, comment); err != nil {
+		return nil, err
+	}
+
+	var kept []string
+	for _, line := range lines {
+		if opts.RemoveOlderThan > 0 {
+			if fp, installed, ok := rotatedKey(line); ok && now.Sub(installed) > opts.RemoveOlderThan {
+				key.Removed = append(key.Removed, fp)
+				continue
+			}
+		}
+		kept = append(kept, line)
+	}
+	kept = append(kept, key.PublicKey)
+
+	if err := dir.writeLines(authorizedKeys, kept, uid, gid); err != nil {
+		log.Error("Error in installing ssh key of ", userName, ": ", err)
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Ssh directory of home opened without following symlinks. Files are
+// accessed relative to its descriptor, so user swapping directory or
+// files for symlinks can't redirect writes running as root.
+type keyDir struct {
+	fd   int
+	path string
+}
+
+// Opens ssh directory of home, created owned by user when missing
+// with create. Directory must not be symlink, and be owned by user
+// or root. Returns nil directory when missing without create.
+func openKeyDir(home string, uid, gid int, create bool) (*keyDir, error) {
+	homeFd, err := syscall.Open(home, syscall.O_RDONLY|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: home, Err: err}
+	}
+	defer syscall.Close(homeFd)
+
+	path := filepath.Join(home, sshDir)
+	flags := syscall.O_RDONLY | syscall.O_DIRECTORY | syscall.O_NOFOLLOW | syscall.O_CLOEXEC
+	fd, err := syscall.Openat(homeFd, sshDir, flags, 0)
+	if err == syscall.ENOENT && create {
+		if err := syscall.Mkdirat(homeFd, sshDir, 0700); err != nil {
+			return nil, &os.PathError{Op: "mkdir", Path: path, Err: err}
+		}
+		if fd, err = syscall.Openat(homeFd, sshDir, flags, 0); err == nil {
+			err = syscall.Fchown(fd, uid, gid)
+		}
+	}
+	switch {
+	case err == syscall.ENOENT && !create:
+		return nil, nil
+	case err == syscall.ELOOP || err == syscall.ENOTDIR:
+		return nil, errors.New("Path " + path + " is not a directory.")
+	case err != nil:
+		if fd >= 0 {
+			syscall.Close(fd)
+		}
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+
+	d := &keyDir{fd: fd, path: path}
+	var st syscall.Stat_t
+	if err := syscall.Fstat(fd, &st); err != nil {
+		d.Close()
+		return nil, err
+	}
+	if int(st.Uid) != uid && st.Uid != 0 {
+		d.Close()
+		return nil, errors.New("Path " + path + " is not owned by uid " + strconv.Itoa(uid) + ".")
+	}
+
+	return d, nil
+}
+
+// Closes descriptor of directory
+func (d *keyDir) Close() error {
+	return syscall.Close(d.fd)
+}
+
+// Opens regular file of directory without following symlink, returns
+// nil file when missing.
+func (d *keyDir) open(name string) (*os.File, error) {
+	path := filepath.Join(d.path, name)
+	fd, err := syscall.Openat(d.fd, name, syscall.O_RDONLY|syscall.O_NOFOLLOW|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if err == syscall.ENOENT {
+		return nil, nil
+	}
+	if err == syscall.ELOOP {
+		return nil, errors.New("Path " + path + " is not a regular file.")
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+
+	f := os.NewFile(uintptr(fd), path)
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		f.Close()
+		return nil, errors.New("Path " + path + " is not a regular file.")
+	}
+
+	return f, nil
+}
+
+// Returns true if file of directory exists, symlink of name exists
+func (d *keyDir) exists(name string) bool {
+	f, err := d.open(name)
+	if f != nil {
+		f.Close()
+	}
+
+	return f != nil || err != nil
+}
+
+// Returns lines of authorized keys, none when file is missing.
+// File must not be symlink.
+func (d *keyDir) readLines(name string) ([]string, error) {
+	f, err := d.open(name)
+	if f == nil || err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+// Writes lines to temporary file owned by user renamed to name,
+// so keys are either old or complete.
+func (d *keyDir) writeLines(name string, lines []string, uid, gid int) error {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	tmpName := "." + name + "." + hex.EncodeToString(suffix)
+
+	fd, err := syscall.Openat(d.fd, tmpName,
+		syscall.O_WRONLY|syscall.O_CREAT|syscall.O_EXCL|syscall.O_NOFOLLOW|syscall.O_CLOEXEC, 0600)
+	if err != nil {
+		return &os.PathError{Op: "create", Path: filepath.Join(d.path, tmpName), Err: err}
+	}
+	tmp := os.NewFile(uintptr(fd), filepath.Join(d.path, tmpName))
+	defer syscall.Unlinkat(d.fd, tmpName)
+
+	if _, err := tmp.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chown(uid, gid); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return d.rename(tmpName, name)
+}
+
+// Renames file of directory, within directory
+func (d *keyDir) rename(from, to string) error {
+	if err := syscall.Renameat(d.fd, from, d.fd, to); err != nil {
+		return &os.LinkError{Op: "rename", Old: filepath.Join(d.path, from), New: filepath.Join(d.path, to), Err: err}
+	}
+
+	return nil
+}
+
+// Returns fingerprint and install time of key installed by rotation
+func rotatedKey(line string) (string, time.Time, bool) {
+	pub, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return "", time.Time{}, false
+	}
+
+	for _, field := range strings.Fields(comment) {
+		if !strings.HasPrefix(field, rotatedTag) {
+			continue
+		}
+		installed, err := time.Parse(time.RFC3339, strings.TrimPrefix(field, rotatedTag))
+		if err != nil {
+			return "", time.Time{}, false
+		}
+		return ssh.FingerprintSHA256(pub), installed, true
+	}
+
+	return "", time.Time{}, false
+}
+
+// Returns unencrypted private key in openssh-key-v1 format, as of ssh-keygen
+func marshalPrivateKey(key ed25519.PrivateKey, comment string) ([]byte, error) {
+	pub, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	check := make([]byte, 4)
+	if _, err := rand.Read(check); err != nil {
+		return nil, err
+	}
+
+	private := &bytes.Buffer{}
+	private.Write(check)
+	private.Write(check)
+	writeSSHString(private, []byte(ssh.KeyAlgoED25519))
+	writeSSHString(private, key.Public().(ed25519.PublicKey))
+	writeSSHString(private, key)
+	writeSSHString(private, []byte(comment))
+	for i := byte(1); private.Len()%8 != 0; i++ {
+		private.WriteByte(i)
+	}
+
+	out := &bytes.Buffer{}
+	out.WriteString("openssh-key-v1\x00")
+	writeSSHString(out, []byte("none")) // Cipher
+	writeSSHString(out, []byte("none")) // KDF
+	writeSSHString(out, nil)            // KDF options
+	binary.Write(out, binary.BigEndian, uint32(1))
+	writeSSHString(out, pub.Marshal())
+	writeSSHString(out, private.Bytes())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: out.Bytes()}), nil
+}
+
+// Writes length prefixed string of ssh wire format
+func writeSSHString(buf *bytes.Buffer, s []byte) {
+	binary.Write(buf, binary.BigEndian, uint32(len(s)))
+	buf.Write(s)
+}
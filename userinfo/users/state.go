@@ -0,0 +1,80 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Files of state kept by this tool
+const (
+	pendingDB string = "pending-deletions.json" // Users locked for deletion
+	deletedDB string = "deleted-users.json"     // Deleted users, for detecting reuse of their uids
+)
+
+// Directory of state files
+var stateDir = "/var/lib/userinfo"
+
+// SetStateDir sets directory of pending deletions and records of
+// deleted users. /var/lib/userinfo will be default.
+func SetStateDir(dir string) error {
+	if !filepath.IsAbs(dir) {
+		return errors.New("State directory " + dir + " must be absolute path.")
+	}
+
+	stateDir = filepath.Clean(dir)
+	return nil
+}
+
+// Returns path of state file
+func statePath(name string) string {
+	return filepath.Join(stateDir, name)
+}
+
+// Reads state file into v, v is left as is when file is missing
+func readState(name string, v interface{}) error {
+	data, err := ioutil.ReadFile(statePath(name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// Writes v as json to temporary file renamed to state file, so
+// file is either old or complete state.
+func writeState(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "   ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(stateDir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(stateDir, "."+name)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), statePath(name))
+}
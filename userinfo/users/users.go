@@ -7,12 +7,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"os/user"
 	"strings"
 	"syscall"
+	"time"
 
-	log "github.com/golang/glog"
+	log "github.com/prashant-sb/go-utils/logging"
 	"golang.org/x/crypto/ssh/terminal"
 )
 
@@ -21,6 +21,9 @@ const (
 	userShell string = "/bin/bash"   // Default user shell
 	userAdd   string = "useradd"     // Command for adding user
 	userDel   string = "userdel"     // Command for deleting user
+	groupAdd  string = "groupadd"    // Command for adding group
+	chage     string = "chage"       // Command for password aging
+	chpasswd  string = "chpasswd"    // Command for setting password
 )
 
 type Userinfo struct {
@@ -33,21 +36,53 @@ type Userinfo struct {
 	// Username is the login name.
 	Username string `json:"userName,omitempty"`
 
-	// Group name / optional
-	Groupname string `json:"groupName,omitempty"`
+	// PrimaryGroup is the name of primary group / optional
+	PrimaryGroup string `json:"primaryGroup,omitempty"`
+
+	// SupplementaryGroups are the additional group names / optional
+	SupplementaryGroups []string `json:"supplementaryGroups,omitempty"`
+
+	// CreateGroup creates the primary group if missing,
+	// with Gid when set / optional
+	CreateGroup bool `json:"createGroup,omitempty"`
+
+	// AllowUidReuse allows Uid of deleted user / optional
+	AllowUidReuse bool `json:"allowUidReuse,omitempty"`
+
+	// MustChangePassword expires the password, forcing
+	// the change at first login / optional
+	MustChangePassword bool `json:"mustChangePassword,omitempty"`
 
 	// Name is the user's real or display name.
 	// It might be blank.
 	Name string `json:"name,omitempty"`
 
+	// Gecos is the parsed comment field, Name is its full name.
+	Gecos *Gecos `json:"gecos,omitempty"`
+
 	// HomeDir is the path to the user's home directory
 	// (if they have one).
 	HomeDir string `json:"homeDir,omitempty"`
-	// Added for unit tests
 
+	// Shell is the login shell, /bin/bash will be default
+	// for new user / optional
+	Shell string `json:"shell,omitempty"`
+
+	// AllowInvalidShell allows Shell not in /etc/shells,
+	// warning instead / optional
+	AllowInvalidShell bool `json:"allowInvalidShell,omitempty"`
+
+	// UserPasswd is read from schema, added for unit tests.
+	// It is never marshaled, see Fixture().
 	UserPasswd string `json:"userPasswd,omitempty"`
+
+	runner Runner // Runs user and group commands of ops
+	remote bool   // Users and groups are looked up over runner
 }
 
+// Userinfo without methods, for marshaling with secrets
+type userFixture Userinfo
+
 type UserList struct {
 	// Userinfo lists for all system users
 	Users []Userinfo `json:"users"`
@@ -57,12 +92,41 @@ type UserOps interface {
 
 	// Exported methods for Userinfo
 	Get(string) (*Userinfo, error)
+	GetByUid(string) (*Userinfo, error)
 	AddUser(string) (string, error)
 	DeleteUser(string) (string, error)
+	DeleteUsers([]string, DeleteOptions) ([]DeleteResult, error)
+	ScheduleDeletion(string, time.Duration) (*PendingDeletion, error)
+	ListPendingDeletions() ([]PendingDeletion, error)
+	CancelDeletion(string) error
+	PurgeDeletions() ([]DeleteResult, error)
+	PlanChanges(string) (*Plan, error)
+	Apply(string) (*Plan, error)
+	Modify(string) (*Plan, error)
+	GetGroup(string) (*Groupinfo, error)
+	ListGroups() ([]Groupinfo, error)
+	ListValidShells() ([]string, error)
+	PrivilegedUsers() ([]PrivilegedUser, error)
+	MoveHome(string, string) error
+	RotateSSHKey(string, RotateOptions) (*RotatedKey, error)
+	CanAccess(string, string, uint32) (*Access, error)
+	UidCollisions(UserOps, CollisionOptions) ([]UidCollision, error)
+	AddGroupMember(string, string) error
+	RemoveGroupMember(string, string) error
+	SetGroupAdmins(string, []string) error
+	SetGroupMembers(string, []string) error
+	SyncGroupMembers(string, []string, bool) (*GroupChanges, error)
+	RemoveGroupPassword(string) error
 
 	// Private methods for Userinfo
 	add(*Userinfo) error
 	delete(*Userinfo) error
+	ensureGroup(string, string, bool) error
+	expirePassword(*Userinfo) error
+	modify(*Userinfo, *Plan) error
+	plan(*Userinfo) *Plan
+	loadUser(string) (*Userinfo, error)
+	loggedInUsers() (map[string]bool, error)
 	creadential() (string, error)
 	readUsers(string) ([]byte, error)
 }
@@ -80,6 +144,18 @@ func NewUserOps() UserOps {
 	return &Userinfo{}
 }
 
+// NewUserOpsWithRunner inits the interface for Userinfo, commands
+// are executed by runner, as over ssh or faked in tests.
+func NewUserOpsWithRunner(r Runner) UserOps {
+	return &Userinfo{runner: r}
+}
+
+// NewRemoteUserOps inits the interface for Userinfo of remote host,
+// users and groups are looked up and changed over runner, as ssh.
+func NewRemoteUserOps(r Runner) UserOps {
+	return &Userinfo{runner: r, remote: true}
+}
+
 // NewUserList inits the interface for UserList
 func NewUserList() UserListOps {
 	return &UserList{
@@ -99,25 +175,13 @@ func (ul *UserList) Get() (*UserList, error) {
 		return nil, err
 	}
 
+	lookup := &Userinfo{}
 	for i := range ulist {
-		u, err := user.Lookup(ulist[i])
+		uinfo, err := lookup.lookupUser(ulist[i])
 		if err != nil {
 			return nil, err
 		}
-		g, err := user.LookupGroupId(u.Gid)
-		if err != nil {
-			return nil, err
-		}
-
-		uinfo := Userinfo{
-			Uid:       u.Uid,
-			Gid:       u.Gid,
-			Name:      u.Name,
-			HomeDir:   u.HomeDir,
-			Username:  u.Username,
-			Groupname: g.Name,
-		}
-		userlist = append(userlist, uinfo)
+		userlist = append(userlist, *uinfo)
 	}
 
 	return &UserList{
@@ -149,45 +213,59 @@ func (ul *UserList) ReadEtcPasswd(f string) ([]string, error) {
 
 // Get user schema with username
 func (u *Userinfo) Get(userName string) (*Userinfo, error) {
+	return u.lookupUser(userName)
+}
+
+// GetByUid gets user schema with numeric user id
+func (u *Userinfo) GetByUid(uid string) (*Userinfo, error) {
+	return u.lookupUid(uid)
+}
 
-	ui, err := user.Lookup(userName)
+// Constructs user schema from system user
+func fromUser(ui *user.User) (*Userinfo, error) {
+
+	g, err := user.LookupGroupId(ui.Gid)
 	if err != nil {
 		return nil, err
 	}
-	g, err := user.LookupGroupId(ui.Gid)
+	sg, err := supplementaryGroups(ui)
 	if err != nil {
 		return nil, err
 	}
 
+	// Users of other sources as ldap are not in passwd file,
+	// user.Lookup keeps only the first sub-field of comment as Name.
+	var gecos *Gecos
+	var shell string
+	if fields, err := fileEntry(userDB, ui.Username); err == nil && len(fields) >= 7 {
+		if fields[4] != "" {
+			gecos = ParseGecos(fields[4])
+		}
+		shell = fields[6]
+	}
+
 	return &Userinfo{
-		Uid:       ui.Uid,
-		Gid:       ui.Gid,
-		Name:      ui.Name,
-		HomeDir:   ui.HomeDir,
-		Username:  ui.Username,
-		Groupname: g.Name,
+		Uid:                 ui.Uid,
+		Gid:                 ui.Gid,
+		Name:                ui.Name,
+		Gecos:               gecos,
+		HomeDir:             ui.HomeDir,
+		Shell:               shell,
+		Username:            ui.Username,
+		PrimaryGroup:        g.Name,
+		SupplementaryGroups: sg,
 	}, nil
 }
 
 // AddUser adds the system user with provided schema
 func (u *Userinfo) AddUser(usrJsonFile string) (string, error) {
 
-	var usr string
-	uinfo := Userinfo{}
-
-	b, err := u.readUsers(usrJsonFile)
-	if err != nil {
-		log.Error("Error in reading ", usrJsonFile)
-		return usr, err
-	}
-
-	err = json.Unmarshal(b, &uinfo)
+	uinfo, err := u.loadUser(usrJsonFile)
 	if err != nil {
-		log.Error("Error in unmarshal: ", err.Error())
-		return usr, err
+		return "", err
 	}
 
-	if err = u.add(&uinfo); err != nil {
+	if err = u.add(uinfo); err != nil {
 		log.Error("Error in adding user ", uinfo.Username)
 		return "", err
 	}
@@ -234,6 +312,10 @@ func (u *Userinfo) add(uinfo *Userinfo) error {
 		return errors.New("User " + uinfo.Username + " already added.")
 	}
 
+	if err := u.checkIds(uinfo); err != nil {
+		return err
+	}
+
 	u.Username = uinfo.Username
 
 	if uinfo.UserPasswd != "" {
@@ -244,14 +326,65 @@ func (u *Userinfo) add(uinfo *Userinfo) error {
 			return err
 		}
 	}
-	argUser := []string{"-m", "-d", uinfo.HomeDir, "-G", uinfo.Groupname, "-s", userShell, uinfo.Username, "-p", passwd}
-	userCmd := exec.Command(userAdd, argUser...)
+	shell := uinfo.Shell
+	if shell == "" {
+		shell = userShell
+	}
+	if err := u.checkShell(uinfo, shell); err != nil {
+		return err
+	}
+
+	argUser := []string{"-m", "-d", uinfo.HomeDir, "-s", shell}
+	if comment := uinfo.comment(nil); comment != "" {
+		argUser = append(argUser, "-c", comment)
+	}
+	if uinfo.Uid != "" {
+		argUser = append(argUser, "-u", uinfo.Uid)
+	}
+
+	if uinfo.PrimaryGroup != "" {
+		if err := u.ensureGroup(uinfo.PrimaryGroup, uinfo.Gid, uinfo.CreateGroup); err != nil {
+			return err
+		}
+		argUser = append(argUser, "-g", uinfo.PrimaryGroup)
+	}
+	if len(uinfo.SupplementaryGroups) > 0 {
+		argUser = append(argUser, "-G", strings.Join(uinfo.SupplementaryGroups, ","))
+	}
+	argUser = append(argUser, uinfo.Username)
 
-	if _, err := userCmd.Output(); err != nil {
+	if _, err := u.runCmd(userAdd, argUser...); err != nil {
 		log.Error("Error in adding user : ", u.Username, " ", err.Error())
 		return err
 	}
 
+	// Password is hashed by chpasswd, read from stdin
+	if _, err := u.runCmdInput(uinfo.Username+":"+passwd+"\n", chpasswd); err != nil {
+		log.Error("Error in setting password : ", u.Username, " ", err.Error())
+		return err
+	}
+	if uinfo.Uid == "" && !u.remote {
+		u.reportUidReuse(uinfo.Username)
+	}
+
+	if uinfo.MustChangePassword {
+		if err := u.expirePassword(uinfo); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expires password of user, so that it must be changed at next login
+func (u *Userinfo) expirePassword(uinfo *Userinfo) error {
+
+	argUser := []string{"-d", "0", uinfo.Username}
+	if _, err := u.runCmd(chage, argUser...); err != nil {
+		log.Error("Error in expiring password : ", uinfo.Username, " ", err.Error())
+		return err
+	}
+
 	return nil
 }
 
@@ -259,13 +392,19 @@ func (u *Userinfo) add(uinfo *Userinfo) error {
 func (u *Userinfo) delete(uinfo *Userinfo) error {
 
 	argUser := []string{"-r", uinfo.Username}
-	userCmd := exec.Command(userDel, argUser...)
-
-	if _, err := userCmd.Output(); err != nil {
+	if _, err := u.runCmd(userDel, argUser...); err != nil {
 		log.Error("Error in deleting user : ", uinfo.Username, "-", err.Error())
 		return err
 	}
 
+	// Records are kept for local host
+	if u.remote {
+		return nil
+	}
+	if err := recordDeleted(uinfo); err != nil {
+		log.Warn("Error in recording deleted user ", uinfo.Username, ": ", err)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,133 @@
+package walker
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prashant-sb/go-utils/pool"
+	"github.com/prashant-sb/go-utils/walker"
+)
+
+// Files of test tree, relative to root
+var testFiles = []string{"a", "b/c", "b/d.log", "cache/e", "f/g/h"}
+
+// Creates test tree, returns its root
+func writeTree(t *testing.T) string {
+	root, err := ioutil.TempDir("", "walker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range testFiles {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root
+}
+
+// Walks root, returns visited paths relative to root in delivery order
+func walk(t *testing.T, root string, opts walker.Options) ([]string, error) {
+	var got []string
+	opts.OnResult = func(r pool.Result) {
+		if r.Err == nil {
+			got = append(got, r.Value.(string))
+		}
+	}
+
+	err := walker.Walk(context.Background(), root, opts, func(ctx context.Context, path string, info os.FileInfo) (interface{}, error) {
+		rel, err := filepath.Rel(root, path)
+		if info.IsDir() {
+			rel += "/"
+		}
+		return rel, err
+	})
+
+	return got, err
+}
+
+func TestWalk(t *testing.T) {
+	root := writeTree(t)
+	defer os.RemoveAll(root)
+
+	got, err := walk(t, root, walker.Options{Workers: 4, Ordered: true})
+	if err != nil || strings.Join(got, " ") != strings.Join(testFiles, " ") {
+		t.Errorf("Walk() FAILED, expected: %v got: %v, %v", testFiles, got, err)
+	}
+	t.Logf("Walk() PASSED")
+}
+
+func TestWalkExclude(t *testing.T) {
+	root := writeTree(t)
+	defer os.RemoveAll(root)
+
+	got, err := walk(t, root, walker.Options{
+		Ordered: true,
+		Exclude: []string{"*.log", filepath.Join(root, "cache")},
+	})
+	if want := "a b/c f/g/h"; err != nil || strings.Join(got, " ") != want {
+		t.Errorf("Walk() FAILED, excluded expected: %v got: %v, %v", want, got, err)
+	}
+
+	if !walker.Excluded("/var/log/x.log", []string{"*.log"}) || !walker.Excluded("/var/cache", []string{"/var/*"}) ||
+		walker.Excluded("/var/log/x.txt", []string{"*.log", "/tmp/*"}) {
+		t.Errorf("Excluded() FAILED")
+	}
+	t.Logf("WalkExclude() PASSED")
+}
+
+func TestWalkDirs(t *testing.T) {
+	root := writeTree(t)
+	defer os.RemoveAll(root)
+
+	got, err := walk(t, root, walker.Options{Ordered: true, Dirs: true, Exclude: []string{"cache", "b"}})
+	if want := "./ a f/ f/g/ f/g/h"; err != nil || strings.Join(got, " ") != want {
+		t.Errorf("Walk() FAILED, dirs expected: %v got: %v, %v", want, got, err)
+	}
+	t.Logf("WalkDirs() PASSED")
+}
+
+func TestWalkErrors(t *testing.T) {
+	root := writeTree(t)
+	defer os.RemoveAll(root)
+
+	// Errors of visits are delivered, walk continues
+	var errs []error
+	visited := 0
+	err := walker.Walk(context.Background(), root, walker.Options{
+		OnResult: func(r pool.Result) {
+			if r.Err != nil {
+				errs = append(errs, r.Err)
+				return
+			}
+			visited++
+		},
+	}, func(ctx context.Context, path string, info os.FileInfo) (interface{}, error) {
+		if filepath.Base(path) == "c" {
+			return nil, errors.New("can't read " + path)
+		}
+		return path, nil
+	})
+	if err == nil || len(errs) != 1 || visited != len(testFiles)-1 {
+		t.Errorf("Walk() FAILED, %d visited, errors %v, %v", visited, errs, err)
+	}
+
+	// Error of missing root is delivered as result
+	errs = nil
+	err = walker.Walk(context.Background(), filepath.Join(root, "missing"), walker.Options{
+		OnResult: func(r pool.Result) { errs = append(errs, r.Err) },
+	}, func(ctx context.Context, path string, info os.FileInfo) (interface{}, error) {
+		return path, nil
+	})
+	if err == nil || len(errs) != 1 || !os.IsNotExist(errs[0]) {
+		t.Errorf("Walk() FAILED, missing root delivered %v, %v", errs, err)
+	}
+	t.Logf("WalkErrors() PASSED")
+}
@@ -0,0 +1,92 @@
+package walker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/prashant-sb/go-utils/pool"
+)
+
+// Visit is called for each file from pool worker,
+// returned value is delivered as pool.Result.
+type Visit func(ctx context.Context, path string, info os.FileInfo) (interface{}, error)
+
+// Options for walking the directory tree
+type Options struct {
+	// Workers is the number of concurrent visits,
+	// number of CPUs will be default.
+	Workers int
+
+	// Ordered delivers results in walk order.
+	Ordered bool
+
+	// Exclude lists glob patterns matched with base name
+	// and full path, matching directories are not walked.
+	Exclude []string
+
+	// Dirs visits directories as well as files.
+	Dirs bool
+
+	// OnResult is called for each visited file and walk error
+	// from single goroutine.
+	OnResult func(pool.Result)
+}
+
+// Walk visits all files under root concurrently.
+// Directories are walked, visited only with Dirs. Errors in reading
+// entries are delivered as results and walking continues.
+func Walk(ctx context.Context, root string, opts Options, visit Visit) error {
+
+	p := pool.NewPool(ctx, pool.Options{
+		Workers:  opts.Workers,
+		Ordered:  opts.Ordered,
+		OnResult: opts.OnResult,
+	})
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return p.Submit(func(ctx context.Context) (interface{}, error) {
+				return nil, err
+			})
+		}
+
+		if Excluded(path, opts.Exclude) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() && !opts.Dirs {
+			return nil
+		}
+
+		return p.Submit(func(ctx context.Context) (interface{}, error) {
+			return visit(ctx, path, info)
+		})
+	})
+
+	_, perr := p.Wait()
+	if err != nil {
+		return err
+	}
+
+	return perr
+}
+
+// Excluded returns true if path or its base name matches any pattern
+func Excluded(path string, patterns []string) bool {
+	base := filepath.Base(path)
+
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+
+	return false
+}